@@ -0,0 +1,123 @@
+package prouter
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+const (
+	defaultAffinityCookie = "prouter_affinity"
+	affinityKeySize       = 16
+)
+
+// Affinity is the stickiness of the current client, Key is stable for the
+// lifetime of the affinity cookie so a load balancer can hash on it.
+type Affinity struct {
+	Key string
+	// Node is the node owning Key on the consistent hash ring, empty if no nodes are configured
+	Node string
+}
+
+// AffinityMiddleware sets and validates a cookie carrying an affinity key for load
+// balancers doing cookie based stickiness. The key is derived from the session ID
+// when the session middleware runs before it, otherwise it is random.
+type AffinityMiddleware struct {
+	cookieName string
+	options    *sessions.Options
+	nodes      []string
+}
+
+type AffinityOption func(*AffinityMiddleware)
+
+func WithAffinityCookie(name string) AffinityOption {
+	return func(m *AffinityMiddleware) {
+		m.cookieName = name
+	}
+}
+
+func WithAffinityCookieOptions(opts *sessions.Options) AffinityOption {
+	return func(m *AffinityMiddleware) {
+		m.options = opts
+	}
+}
+
+// WithAffinityNodes sets the nodes of the consistent hash ring used to compute Affinity.Node.
+func WithAffinityNodes(nodes ...string) AffinityOption {
+	return func(m *AffinityMiddleware) {
+		m.nodes = nodes
+	}
+}
+
+func NewAffinityMiddleware(opts ...AffinityOption) *AffinityMiddleware {
+	m := &AffinityMiddleware{
+		cookieName: defaultAffinityCookie,
+		options: &sessions.Options{
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func validAffinityKey(key string) bool {
+	if len(key) != affinityKeySize {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+func (m *AffinityMiddleware) newKey(ctx *Context) string {
+	if ctx.session != nil && ctx.session.ID() != "" {
+		sum := sha256.Sum256([]byte(ctx.session.ID()))
+		return hex.EncodeToString(sum[:affinityKeySize/2])
+	}
+
+	b := make([]byte, affinityKeySize/2)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// node picks the owner of key with rendezvous hashing, so removing a node
+// only moves the keys it owned.
+func (m *AffinityMiddleware) node(key string) string {
+	var (
+		owner string
+		max   uint64
+	)
+	for _, node := range m.nodes {
+		h := fnv.New64a()
+		h.Write([]byte(node))
+		h.Write([]byte(key))
+		if sum := h.Sum64(); owner == "" || sum > max {
+			owner, max = node, sum
+		}
+	}
+	return owner
+}
+
+func (m *AffinityMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		var key string
+		if c, err := ctx.Request.Cookie(m.cookieName); err == nil && validAffinityKey(c.Value) {
+			key = c.Value
+		} else {
+			key = m.newKey(ctx)
+			http.SetCookie(ctx.Writer, sessions.NewCookie(m.cookieName, key, m.options))
+		}
+
+		ctx.affinity = &Affinity{Key: key, Node: m.node(key)}
+		return handler.Handle(ctx)
+	})
+}
@@ -0,0 +1,287 @@
+package prouter
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const (
+	defaultMaxArchiveSize   = 100 << 20
+	defaultMaxExtractedSize = 1 << 30
+	defaultMaxArchiveFiles  = 10000
+	defaultArchiveField     = "file"
+)
+
+// ZipDirectory streams the subtree root of fsys as a zip attachment named after
+// root. Files are compressed while they are written, so memory stays bounded by
+// the deflate window whatever the size of the tree.
+func (c *Context) ZipDirectory(fsys fs.FS, root string) (Response, error) {
+	root = path.Clean(strings.TrimPrefix(root, "/"))
+	if root == "" {
+		root = "."
+	}
+	if stat, err := fs.Stat(fsys, root); err != nil || !stat.IsDir() {
+		return nil, ResourceNotFound(http.StatusNotFound, "directory not found").SetComponent(ErrProuter)
+	}
+
+	name := path.Base(root)
+	if name == "." || name == "/" {
+		name = "archive"
+	}
+	h := c.Writer.Header()
+	h.Set("Content-Type", "application/zip")
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".zip"}))
+	c.Writer.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := c.Err(); err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = strings.TrimPrefix(p, root+"/")
+		if root == "." {
+			header.Name = p
+		}
+		if d.IsDir() {
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	})
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		// the status is sent, the client sees a truncated archive
+		plog.Errorc(c, "zip directory %s failed: %v", root, err)
+	}
+	return nil, nil
+}
+
+type extractConfig struct {
+	field            string
+	maxArchiveSize   int64
+	maxExtractedSize int64
+	maxFiles         int
+}
+
+type ExtractOption func(*extractConfig)
+
+// WithArchiveField sets the multipart field of the archive, file by default.
+// Requests which are not multipart send the archive as the body.
+func WithArchiveField(field string) ExtractOption {
+	return func(c *extractConfig) {
+		c.field = field
+	}
+}
+
+// WithMaxArchiveSize caps the uploaded archive, 100MB by default.
+func WithMaxArchiveSize(n int64) ExtractOption {
+	return func(c *extractConfig) {
+		c.maxArchiveSize = n
+	}
+}
+
+// WithMaxExtractedSize caps the uncompressed size of all files, 1GB by default,
+// which stops zip bombs.
+func WithMaxExtractedSize(n int64) ExtractOption {
+	return func(c *extractConfig) {
+		c.maxExtractedSize = n
+	}
+}
+
+// WithMaxArchiveFiles caps the number of entries of the archive, 10000 by default.
+func WithMaxArchiveFiles(n int) ExtractOption {
+	return func(c *extractConfig) {
+		c.maxFiles = n
+	}
+}
+
+func archiveError(code int, format string, args ...any) error {
+	err := MsgError(code, fmt.Sprintf(format, args...)).SetComponent(ErrProuter)
+	if code == http.StatusBadRequest {
+		err = err.SetResponseType(BadRequest)
+	}
+	return err
+}
+
+// archiveSource returns the uploaded archive, from the multipart field or the body
+func (c *Context) archiveSource(cfg *extractConfig) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return c.Request.Body, nil
+	}
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, archiveError(http.StatusBadRequest, "invalid multipart body")
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, archiveError(http.StatusBadRequest, "multipart field %s missing", cfg.field)
+		}
+		if err != nil {
+			return nil, archiveError(http.StatusBadRequest, "invalid multipart body")
+		}
+		if part.FormName() == cfg.field {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// ExtractZip extracts the uploaded zip archive into the directory target and
+// returns the extracted files relative to it. The archive is spooled to a
+// temporary file, entries with absolute paths, .. segments or links are
+// rejected and the sizes are capped, see the ExtractOptions.
+func (c *Context) ExtractZip(target string, opts ...ExtractOption) ([]string, error) {
+	cfg := &extractConfig{
+		field:            defaultArchiveField,
+		maxArchiveSize:   defaultMaxArchiveSize,
+		maxExtractedSize: defaultMaxExtractedSize,
+		maxFiles:         defaultMaxArchiveFiles,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	src, err := c.archiveSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "prouter-upload-*.zip")
+	if err != nil {
+		return nil, NewErr(http.StatusInternalServerError, err, "spool archive failed").SetComponent(ErrProuter)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, io.LimitReader(src, cfg.maxArchiveSize+1))
+	if err != nil {
+		return nil, NewErr(http.StatusBadRequest, err, "read archive failed").SetComponent(ErrProuter).SetResponseType(BadRequest)
+	}
+	if size > cfg.maxArchiveSize {
+		return nil, archiveError(http.StatusRequestEntityTooLarge, "archive exceeds %d bytes", cfg.maxArchiveSize)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, archiveError(http.StatusBadRequest, "invalid zip archive")
+	}
+	if len(zr.File) > cfg.maxFiles {
+		return nil, archiveError(http.StatusRequestEntityTooLarge, "archive has more than %d entries", cfg.maxFiles)
+	}
+
+	// every entry is checked before anything is written
+	for _, f := range zr.File {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) || strings.Contains(f.Name, `\`) {
+			return nil, archiveError(http.StatusBadRequest, "unsafe path %q in archive", f.Name)
+		}
+		if mode := f.Mode(); !mode.IsDir() && !mode.IsRegular() {
+			return nil, archiveError(http.StatusBadRequest, "unsupported entry %q in archive", f.Name)
+		}
+	}
+
+	var (
+		files   []string
+		written int64
+	)
+	for _, f := range zr.File {
+		dst := filepath.Join(target, filepath.FromSlash(f.Name))
+		if f.Mode().IsDir() {
+			if err := os.MkdirAll(dst, 0o755); err != nil {
+				return files, NewErr(http.StatusInternalServerError, err, "extract archive failed").SetComponent(ErrProuter)
+			}
+			continue
+		}
+
+		n, err := extractFile(f, dst, cfg.maxExtractedSize-written)
+		written += n
+		if errors.Is(err, errExtractLimit) {
+			return files, archiveError(http.StatusRequestEntityTooLarge, "archive expands to more than %d bytes", cfg.maxExtractedSize)
+		}
+		if err != nil {
+			return files, NewErr(http.StatusBadRequest, err, "extract archive failed").SetComponent(ErrProuter).SetResponseType(BadRequest)
+		}
+		files = append(files, filepath.ToSlash(f.Name))
+	}
+	return files, nil
+}
+
+var errExtractLimit = errors.New("extract limit exceeded")
+
+// extractFile writes the entry to dst and stops after limit bytes, the sizes in
+// the zip headers are not trusted
+func extractFile(f *zip.File, dst string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return 0, err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, io.LimitReader(rc, limit+1))
+	if cErr := out.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil && n > limit {
+		err = errExtractLimit
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return n, err
+}
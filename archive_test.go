@@ -0,0 +1,159 @@
+package prouter
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name string
+	mode fs.FileMode
+	body string
+}
+
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			header.SetMode(e.mode)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractZip(t *testing.T) {
+	var (
+		target  string
+		options []ExtractOption
+		files   []string
+	)
+	router := New()
+	router.POST("/upload", func(ctx *Context) (Response, error) {
+		var err error
+		files, err = ctx.ExtractZip(target, options...)
+		return nil, err
+	})
+
+	large := strings.Repeat("a", 4096)
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		opts    []ExtractOption
+		code    int
+		files   []string
+	}{
+		{
+			name:    "extracted",
+			entries: []zipEntry{{name: "a.txt", body: "a"}, {name: "dir/", mode: fs.ModeDir | 0o755}, {name: "dir/b.txt", body: "b"}},
+			code:    http.StatusOK,
+			files:   []string{"a.txt", "dir/b.txt"},
+		},
+		{name: "parent traversal", entries: []zipEntry{{name: "ok.txt"}, {name: "../evil.txt", body: "x"}}, code: http.StatusBadRequest},
+		{name: "nested traversal", entries: []zipEntry{{name: "dir/../../evil.txt", body: "x"}}, code: http.StatusBadRequest},
+		{name: "absolute path", entries: []zipEntry{{name: "/etc/evil.txt", body: "x"}}, code: http.StatusBadRequest},
+		{name: "backslash", entries: []zipEntry{{name: `..\evil.txt`, body: "x"}}, code: http.StatusBadRequest},
+		{name: "symlink", entries: []zipEntry{{name: "link", mode: fs.ModeSymlink | 0o777, body: "/etc/passwd"}}, code: http.StatusBadRequest},
+		{
+			name:    "archive size",
+			entries: []zipEntry{{name: "a.txt", body: large}},
+			opts:    []ExtractOption{WithMaxArchiveSize(64)},
+			code:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:    "extracted size",
+			entries: []zipEntry{{name: "a.txt", body: large}, {name: "b.txt", body: large}},
+			opts:    []ExtractOption{WithMaxExtractedSize(int64(len(large)) + 1)},
+			code:    http.StatusRequestEntityTooLarge,
+			files:   []string{"a.txt"},
+		},
+		{
+			name:    "file count",
+			entries: []zipEntry{{name: "a.txt"}, {name: "b.txt"}, {name: "c.txt"}},
+			opts:    []ExtractOption{WithMaxArchiveFiles(2)},
+			code:    http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, options, files = filepath.Join(t.TempDir(), "out"), tt.opts, nil
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(buildZip(t, tt.entries...))))
+			if rec.Code != tt.code {
+				t.Fatalf("code = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if !slices.Equal(files, tt.files) {
+				t.Errorf("files = %v, want %v", files, tt.files)
+			}
+
+			var written []string
+			filepath.WalkDir(filepath.Dir(target), func(p string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					rel, _ := filepath.Rel(target, p)
+					written = append(written, filepath.ToSlash(rel))
+				}
+				return nil
+			})
+			if !slices.Equal(written, tt.files) {
+				t.Errorf("written = %v, want %v", written, tt.files)
+			}
+		})
+	}
+}
+
+func TestExtractZipMultipart(t *testing.T) {
+	target := t.TempDir()
+	router := New()
+	router.POST("/upload", func(ctx *Context) (Response, error) {
+		files, err := ctx.ExtractZip(target, WithArchiveField("archive"))
+		return SuccessResponse(files), err
+	})
+
+	upload := func(field string) *http.Request {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("comment", "ignored")
+		w, _ := mw.CreateFormFile(field, "upload.zip")
+		w.Write(buildZip(t, zipEntry{name: "a.txt", body: "a"}))
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, upload("archive"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d: %s", rec.Code, rec.Body)
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "a.txt")); err != nil || string(data) != "a" {
+		t.Errorf("a.txt = %q, %v", data, err)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, upload("file"))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing field: code = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,171 @@
+package prouter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	assetHashLength   = 10
+	assetCacheControl = "public, max-age=31536000, immutable"
+)
+
+// AssetManifest maps the logical names of fingerprinted static files, like
+// css/app.css, to their URLs with the content hash in the filename, like
+// /static/css/app.3f2a9c1b0d.css.
+type AssetManifest struct {
+	fs http.FileSystem
+	// urls maps logical names to hashed URLs
+	urls map[string]string
+	// files maps hashed names to logical names
+	files  map[string]string
+	hashes map[string]string
+	prefix string
+}
+
+// hashedName inserts the hash before the extension, app.min.js is app.min.<hash>.js
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+func isSidecar(fsys fs.FS, name string) bool {
+	for _, enc := range staticEncodings {
+		if base, ok := strings.CutSuffix(name, enc.ext); ok {
+			if _, err := fs.Stat(fsys, base); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newAssetManifest(prefix string, fsys fs.FS) (*AssetManifest, error) {
+	m := &AssetManifest{
+		fs:     http.FS(fsys),
+		urls:   make(map[string]string),
+		files:  make(map[string]string),
+		hashes: make(map[string]string),
+		prefix: prefix,
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isSidecar(fsys, name) {
+			return err
+		}
+
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		hash := hex.EncodeToString(h.Sum(nil))[:assetHashLength]
+
+		hashed := hashedName(name, hash)
+		m.files[hashed] = name
+		m.hashes[name] = hash
+		m.urls[name] = (&url.URL{Path: path.Join(prefix, hashed)}).EscapedPath()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Lookup returns the hashed URL of the logical name.
+func (m *AssetManifest) Lookup(name string) (string, bool) {
+	u, ok := m.urls[strings.TrimPrefix(path.Clean("/"+name), "/")]
+	return u, ok
+}
+
+// URL is Lookup falling back to the unhashed URL of unknown names, which is
+// served without the immutable cache headers.
+func (m *AssetManifest) URL(name string) string {
+	if u, ok := m.Lookup(name); ok {
+		return u
+	}
+	return (&url.URL{Path: path.Join(m.prefix, name)}).EscapedPath()
+}
+
+// Manifest returns a copy of the logical names and their hashed URLs, e.g. to
+// hand them to a frontend build.
+func (m *AssetManifest) Manifest() map[string]string {
+	return maps.Clone(m.urls)
+}
+
+// FuncMap returns the asset template func resolving logical names:
+//
+//	tmpl := template.New("page").Funcs(assets.FuncMap())
+//	<script src="{{ asset "js/app.js" }}"></script>
+func (m *AssetManifest) FuncMap() template.FuncMap {
+	return template.FuncMap{"asset": m.URL}
+}
+
+func (m *AssetManifest) handler() HandleFunc {
+	fileServer := http.FileServer(m.fs)
+
+	return func(ctx *Context) (Response, error) {
+		r := ctx.Request
+		name := strings.TrimPrefix(path.Clean("/"+ctx.ParamPath(staticPathVar)), "/")
+
+		h := ctx.Writer.Header()
+		if logical, ok := m.files[name]; ok {
+			name = logical
+			h.Set("Cache-Control", assetCacheControl)
+			h.Set("ETag", `W/"`+m.hashes[logical]+`"`)
+		} else {
+			h.Set("Cache-Control", "no-cache")
+		}
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + name
+		r2.URL.RawPath = ""
+
+		if servePrecompressed(ctx.Writer, r2, m.fs, r2.URL.Path) {
+			return nil, nil
+		}
+		fileServer.ServeHTTP(ctx.Writer, r2)
+		return nil, nil
+	}
+}
+
+// StaticAssets serves the files of fsys under relativePath by the fingerprinted
+// names of the returned manifest with immutable cache headers, the unhashed
+// names are served too, but revalidated. Precompressed sidecars are served like
+// in StaticFS and share the hash of their file.
+func (rg *RouterGroup) StaticAssets(relativePath string, fsys fs.FS, opts ...RouteOption) *AssetManifest {
+	if !strings.HasPrefix(relativePath, "/") {
+		relativePath = "/" + relativePath
+	}
+
+	m, err := newAssetManifest(strings.TrimRight(rg.prefix, "/")+relativePath, fsys)
+	if err != nil {
+		panic(err)
+	}
+
+	urlPattern := path.Join(relativePath, "{"+staticPathVar+":path}")
+	handler := &wrapHandler{
+		name:    "StaticAssetsHandler",
+		handler: m.handler(),
+	}
+
+	rg.handleRoute(http.MethodGet, urlPattern, handler, opts...)
+	return m
+}
@@ -0,0 +1,286 @@
+package prouter
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PrincipalFunc extracts the identity of the caller, an empty principal is answered with 401.
+type PrincipalFunc func(ctx *Context) string
+
+// Authorizer decides whether principal may access the route served by ctx.
+type Authorizer interface {
+	Authorize(ctx *Context, principal string) (bool, error)
+}
+
+type AuthorizerFunc func(ctx *Context, principal string) (bool, error)
+
+func (f AuthorizerFunc) Authorize(ctx *Context, principal string) (bool, error) {
+	return f(ctx, principal)
+}
+
+type principalKey struct{}
+
+// Principal returns the principal authorized by the authz middleware.
+func Principal(ctx *Context) string {
+	p, _ := ctx.Value(principalKey{}).(string)
+	return p
+}
+
+type AuthzMiddleware struct {
+	authorizer Authorizer
+	principal  PrincipalFunc
+	cache      *DecisionCache
+}
+
+type AuthzOption func(*AuthzMiddleware)
+
+func WithPrincipalFunc(fn PrincipalFunc) AuthzOption {
+	return func(m *AuthzMiddleware) {
+		m.principal = fn
+	}
+}
+
+// WithDecisionCache caches the decisions of the authorizer by route, request
+// path and principal, so a decision for /users/1 is not reused for /users/2.
+// The query string is not part of the key, an authorizer deciding on it must
+// not be cached. The cache can be shared with the code which changes the
+// policies to invalidate it.
+func WithDecisionCache(cache *DecisionCache) AuthzOption {
+	return func(m *AuthzMiddleware) {
+		m.cache = cache
+	}
+}
+
+func NewAuthzMiddleware(authorizer Authorizer, opts ...AuthzOption) *AuthzMiddleware {
+	m := &AuthzMiddleware{
+		authorizer: authorizer,
+		principal: func(ctx *Context) string {
+			user, _, _ := ctx.Request.BasicAuth()
+			return user
+		},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Cache returns the decision cache, nil if caching is disabled.
+func (m *AuthzMiddleware) Cache() *DecisionCache {
+	return m.cache
+}
+
+func (m *AuthzMiddleware) authorize(ctx *Context, principal string) (bool, error) {
+	if m.cache == nil {
+		return m.authorizer.Authorize(ctx, principal)
+	}
+
+	key := decisionKey{
+		route:     routeKey(ctx.Request.Method, ctx.RouteTemplate()),
+		path:      ctx.Request.URL.Path,
+		principal: principal,
+	}
+	now := ctx.Now()
+	if allowed, ok := m.cache.get(key, now); ok {
+		return allowed, nil
+	}
+
+	allowed, err := m.authorizer.Authorize(ctx, principal)
+	if err != nil {
+		return false, err
+	}
+	m.cache.set(key, allowed, now)
+	return allowed, nil
+}
+
+func (m *AuthzMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		principal := m.principal(ctx)
+		if principal == "" {
+			return nil, MsgError(http.StatusUnauthorized, "unauthorized").SetComponent(ErrProuter)
+		}
+
+		allowed, err := m.authorize(ctx, principal)
+		if err != nil {
+			return nil, NewErr(http.StatusInternalServerError, err, "authorize failed").
+				SetComponent(ErrProuter).
+				SetResponseType(InternalServerError)
+		}
+		if !allowed {
+			return nil, MsgError(http.StatusForbidden, "forbidden").
+				SetComponent(ErrProuter).
+				SetResponseType(Forbidden)
+		}
+
+		ctx.WithValue(principalKey{}, principal)
+		return handler.Handle(ctx)
+	})
+}
+
+type decisionKey struct {
+	route     string
+	path      string
+	principal string
+}
+
+type decision struct {
+	key      decisionKey
+	allowed  bool
+	expireAt time.Time
+}
+
+// DecisionCacheStats is a snapshot of the cache counters
+type DecisionCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+func (s DecisionCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+const defaultDecisionCacheEntries = 10000
+
+// DecisionCache holds authorization decisions for ttl, errors of the authorizer
+// are never cached. It keeps at most 10000 decisions by default, the least
+// recently used one is evicted first, see WithMaxEntries.
+type DecisionCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu sync.Mutex
+	// lru holds the *decision entries, the most recently used first
+	lru       *list.List
+	entries   map[decisionKey]*list.Element
+	nextSweep time.Time
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewDecisionCache(ttl time.Duration) *DecisionCache {
+	return &DecisionCache{
+		ttl:        ttl,
+		maxEntries: defaultDecisionCacheEntries,
+		lru:        list.New(),
+		entries:    make(map[decisionKey]*list.Element),
+	}
+}
+
+// WithMaxEntries bounds the number of decisions kept, n <= 0 keeps the default.
+func (c *DecisionCache) WithMaxEntries(n int) *DecisionCache {
+	if n > 0 {
+		c.maxEntries = n
+	}
+	return c
+}
+
+func (c *DecisionCache) get(key decisionKey, now time.Time) (bool, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	var d *decision
+	if ok {
+		d = e.Value.(*decision)
+		if ok = !now.After(d.expireAt); ok {
+			c.lru.MoveToFront(e)
+		}
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.misses.Add(1)
+		return false, false
+	}
+	c.hits.Add(1)
+	return d.allowed, true
+}
+
+func (c *DecisionCache) set(key decisionKey, allowed bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// expired entries are swept at most once per ttl
+	if now.After(c.nextSweep) {
+		for k, e := range c.entries {
+			if now.After(e.Value.(*decision).expireAt) {
+				c.remove(k, e)
+			}
+		}
+		c.nextSweep = now.Add(c.ttl)
+	}
+
+	if e, ok := c.entries[key]; ok {
+		d := e.Value.(*decision)
+		d.allowed, d.expireAt = allowed, now.Add(c.ttl)
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&decision{key: key, allowed: allowed, expireAt: now.Add(c.ttl)})
+	for len(c.entries) > c.maxEntries {
+		oldest := c.lru.Back()
+		c.remove(oldest.Value.(*decision).key, oldest)
+		c.evictions.Add(1)
+	}
+}
+
+func (c *DecisionCache) remove(key decisionKey, e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, key)
+}
+
+func (c *DecisionCache) invalidate(match func(decisionKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if match(k) {
+			c.remove(k, e)
+		}
+	}
+}
+
+// InvalidatePrincipal drops the decisions of principal, e.g. after its roles changed.
+func (c *DecisionCache) InvalidatePrincipal(principal string) {
+	c.invalidate(func(k decisionKey) bool { return k.principal == principal })
+}
+
+// InvalidateRoute drops the decisions of the route given by method and its path template.
+func (c *DecisionCache) InvalidateRoute(method, template string) {
+	route := routeKey(method, template)
+	c.invalidate(func(k decisionKey) bool { return k.route == route })
+}
+
+// Purge drops all decisions, e.g. after the policies were reloaded.
+func (c *DecisionCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.lru.Init()
+}
+
+func (c *DecisionCache) Stats() DecisionCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return DecisionCacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   entries,
+	}
+}
@@ -0,0 +1,95 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthzDecisionCacheIsPerPath(t *testing.T) {
+	calls := 0
+	authz := NewAuthzMiddleware(AuthorizerFunc(func(ctx *Context, principal string) (bool, error) {
+		calls++
+		return ctx.Var("id") == "1", nil
+	}), WithDecisionCache(NewDecisionCache(time.Minute)))
+
+	router := New()
+	router.UseMiddleware(authz)
+	router.GET("/users/{id}", func(ctx *Context) (Response, error) {
+		return SuccessResponse(ctx.Var("id")), nil
+	})
+
+	tests := []struct {
+		path  string
+		code  int
+		calls int
+	}{
+		{"/users/1", http.StatusOK, 1},
+		{"/users/1", http.StatusOK, 1},
+		{"/users/2", http.StatusForbidden, 2},
+		{"/users/2", http.StatusForbidden, 2},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.SetBasicAuth("alice", "")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("%s: code = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s: authorizer calls = %d, want %d", tt.path, calls, tt.calls)
+		}
+	}
+}
+
+func TestDecisionCacheEvictsTheLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	c := NewDecisionCache(time.Minute).WithMaxEntries(2)
+	key := func(path string) decisionKey {
+		return decisionKey{route: "GET /users/{id}", path: path, principal: "alice"}
+	}
+
+	c.set(key("/users/1"), true, now)
+	c.set(key("/users/2"), true, now)
+	c.get(key("/users/1"), now)
+	c.set(key("/users/3"), false, now)
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"/users/1", true},
+		{"/users/2", false},
+		{"/users/3", true},
+	}
+	for _, tt := range tests {
+		if _, ok := c.get(key(tt.path), now); ok != tt.ok {
+			t.Errorf("get(%s) cached = %v, want %v", tt.path, ok, tt.ok)
+		}
+	}
+	if st := c.Stats(); st.Entries != 2 || st.Evictions != 1 {
+		t.Errorf("stats = %+v, want 2 entries and 1 eviction", st)
+	}
+}
+
+func TestDecisionCacheBoundsDistinctPaths(t *testing.T) {
+	cache := NewDecisionCache(time.Hour).WithMaxEntries(16)
+	router := New()
+	router.UseMiddleware(NewAuthzMiddleware(AuthorizerFunc(func(*Context, string) (bool, error) {
+		return true, nil
+	}), WithDecisionCache(cache)))
+	router.GET("/users/{id}", func(*Context) (Response, error) { return nil, nil })
+
+	for i := range 100 {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", i), nil)
+		req.SetBasicAuth("alice", "")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if st := cache.Stats(); st.Entries != 16 || st.Evictions != 84 {
+		t.Errorf("stats = %+v, want 16 entries and 84 evictions", st)
+	}
+}
@@ -0,0 +1,95 @@
+package prouter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+type backgroundTask struct {
+	name string
+	fn   func(ctx context.Context)
+}
+
+type backgroundTasks struct {
+	mu      sync.Mutex
+	tasks   []backgroundTask
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	// serving is set by the first startBackground, it is kept by stopBackground
+	serving bool
+}
+
+// Background runs fn alongside the router, e.g. a cleanup loop. Tasks start with
+// Run or RunTLS, or at once if the router is serving, their ctx is cancelled by
+// Shutdown, which waits for them to return.
+func (v *Prouter) Background(name string, fn func(ctx context.Context)) {
+	b := &v.background
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	task := backgroundTask{name: name, fn: fn}
+	b.tasks = append(b.tasks, task)
+	if b.ctx != nil {
+		b.start(task)
+	}
+}
+
+func (b *backgroundTasks) start(task backgroundTask) {
+	ctx := b.ctx
+	b.running.Add(1)
+	go func() {
+		defer b.running.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				plog.Errorf("background task %s panic: %v", task.name, r)
+			}
+		}()
+		task.fn(ctx)
+	}()
+}
+
+func (v *Prouter) startBackground() {
+	b := &v.background
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.serving = true
+	if b.ctx != nil {
+		return
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	for _, task := range b.tasks {
+		b.start(task)
+	}
+}
+
+// stopBackground cancels the tasks and waits for them until ctx expires, they
+// start again with startBackground
+func (v *Prouter) stopBackground(ctx context.Context) error {
+	b := &v.background
+	b.mu.Lock()
+	cancel := b.cancel
+	n := len(b.tasks)
+	b.ctx, b.cancel = nil, nil
+	b.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("prouter: not all of %d background tasks stopped: %w", n, ctx.Err())
+	}
+}
@@ -0,0 +1,204 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	BaggageHeader = "baggage"
+
+	// limits of the W3C Baggage spec, members beyond them are dropped
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+type baggageKey struct{}
+
+// BaggageMember is a key=value;property entry of the baggage header.
+type BaggageMember struct {
+	Key        string
+	Value      string
+	Properties []string
+}
+
+// Baggage is the W3C baggage of the request, e.g. tenant and experiment ids
+// forwarded across services. It is not safe for concurrent modification.
+type Baggage struct {
+	members []BaggageMember
+}
+
+// ParseBaggage parses a baggage header, invalid members are skipped.
+func ParseBaggage(header string) *Baggage {
+	b := &Baggage{}
+	if len(header) > maxBaggageBytes {
+		header = header[:maxBaggageBytes]
+	}
+
+	for _, raw := range strings.Split(header, ",") {
+		if len(b.members) == maxBaggageMembers {
+			break
+		}
+		parts := strings.Split(raw, ";")
+		key, value, ok := strings.Cut(parts[0], "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"(),/:;<=>?@[\\]{}") {
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		m := BaggageMember{Key: key, Value: value}
+		for _, p := range parts[1:] {
+			if p = strings.TrimSpace(p); p != "" {
+				m.Properties = append(m.Properties, p)
+			}
+		}
+		b.set(m)
+	}
+	return b
+}
+
+func (b *Baggage) set(m BaggageMember) {
+	if i := slices.IndexFunc(b.members, func(o BaggageMember) bool { return o.Key == m.Key }); i >= 0 {
+		b.members[i] = m
+		return
+	}
+	b.members = append(b.members, m)
+}
+
+// Set adds or replaces the member key.
+func (b *Baggage) Set(key, value string, properties ...string) *Baggage {
+	b.set(BaggageMember{Key: key, Value: value, Properties: properties})
+	return b
+}
+
+func (b *Baggage) Delete(key string) {
+	b.members = slices.DeleteFunc(b.members, func(m BaggageMember) bool { return m.Key == key })
+}
+
+func (b *Baggage) Lookup(key string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	for _, m := range b.members {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+func (b *Baggage) Get(key string) string {
+	v, _ := b.Lookup(key)
+	return v
+}
+
+// Int returns the member key as integer, ok is false if it is missing or no integer.
+func (b *Baggage) Int(key string) (n int64, ok bool) {
+	v, ok := b.Lookup(key)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	return n, err == nil
+}
+
+func (b *Baggage) Bool(key string) (value bool, ok bool) {
+	v, ok := b.Lookup(key)
+	if !ok {
+		return false, false
+	}
+	value, err := strconv.ParseBool(v)
+	return value, err == nil
+}
+
+func (b *Baggage) Members() []BaggageMember {
+	if b == nil {
+		return nil
+	}
+	return slices.Clone(b.members)
+}
+
+func (b *Baggage) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.members)
+}
+
+// Merge copies the members of other into b, they replace members of the same key.
+func (b *Baggage) Merge(other *Baggage) *Baggage {
+	if other != nil {
+		for _, m := range other.members {
+			b.set(m)
+		}
+	}
+	return b
+}
+
+// String encodes the baggage header value, members beyond the size limit are dropped.
+func (b *Baggage) String() string {
+	if b == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, m := range b.members {
+		entry := m.Key + "=" + url.PathEscape(m.Value)
+		for _, p := range m.Properties {
+			entry += ";" + p
+		}
+		if sb.Len()+len(entry)+1 > maxBaggageBytes {
+			break
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(entry)
+	}
+	return sb.String()
+}
+
+// ContextWithBaggage returns a copy of ctx carrying b, for outgoing requests
+// made outside of a handler.
+func ContextWithBaggage(ctx context.Context, b *Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// BaggageFromContext returns the baggage of ctx, the request baggage for a
+// *Context. It is nil if there is none.
+func BaggageFromContext(ctx context.Context) *Baggage {
+	if c, ok := ctx.(*Context); ok {
+		return c.Baggage()
+	}
+	b, _ := ctx.Value(baggageKey{}).(*Baggage)
+	return b
+}
+
+// Baggage returns the baggage of the request header, middlewares add entries
+// with Set which the client package forwards on outgoing requests made with ctx.
+// It is parsed when the request starts, a Context not created by the router
+// parses it on the first call.
+func (c *Context) Baggage() *Baggage {
+	if b, ok := c.Value(baggageKey{}).(*Baggage); ok {
+		return b
+	}
+
+	b := requestBaggage(c.Request)
+	c.WithValue(baggageKey{}, b)
+	return b
+}
+
+func requestBaggage(r *http.Request) *Baggage {
+	if r == nil {
+		return &Baggage{}
+	}
+	return ParseBaggage(strings.Join(r.Header.Values(BaggageHeader), ","))
+}
@@ -0,0 +1,43 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestBaggageFromDerivedContext(t *testing.T) {
+	router := New()
+	router.GET("/", func(ctx *Context) (Response, error) {
+		// derived before the first call of ctx.Baggage
+		derived := context.WithoutCancel(ctx)
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = ctx.Baggage()
+			}()
+		}
+		wg.Wait()
+
+		b := BaggageFromContext(derived)
+		if b == nil {
+			t.Fatal("no baggage in a context derived from the request")
+		}
+		if got := b.Get("tenant"); got != "acme" {
+			t.Errorf("tenant = %q, want acme", got)
+		}
+		if b != ctx.Baggage() {
+			t.Error("derived context has another baggage than the request")
+		}
+		return nil, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(BaggageHeader, "tenant=acme")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,93 @@
+package ban
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func adminError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return prouter.MsgError(http.StatusNotFound, err.Error()).
+			SetComponent(prouter.ErrProuter).
+			SetResponseType(prouter.NotFound)
+	}
+	return prouter.NewErr(http.StatusInternalServerError, err).
+		SetComponent(prouter.ErrProuter).
+		SetResponseType(prouter.InternalServerError)
+}
+
+type banRequest struct {
+	IP     string `json:"ip" binding:"required"`
+	Reason string `json:"reason"`
+	// Duration like "1h", a day if empty
+	Duration string `json:"duration"`
+}
+
+// Mount registers the admin routes to inspect and lift the bans in rg, protect
+// the group with an auth middleware:
+//
+//	GET    /bans
+//	POST   /bans
+//	DELETE /bans/{ip}
+func (e *Engine) Mount(rg *prouter.RouterGroup) {
+	rg.GET("/bans", func(ctx *prouter.Context) (prouter.Response, error) {
+		entries, err := e.store.List(ctx)
+		if err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(entries), nil
+	})
+
+	rg.POST("/bans", func(ctx *prouter.Context) (prouter.Response, error) {
+		req := new(banRequest)
+		if err := ctx.Bind(req); err != nil {
+			return nil, err
+		}
+
+		d := 24 * time.Hour
+		if req.Duration != "" {
+			var err error
+			if d, err = time.ParseDuration(req.Duration); err != nil || d <= 0 {
+				return nil, prouter.MsgError(http.StatusBadRequest, "invalid duration: "+req.Duration).
+					SetComponent(prouter.ErrProuter).
+					SetResponseType(prouter.BadRequest)
+			}
+		}
+		ip, err := canonicalIP(req.IP)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := e.Ban(ctx, ip, req.Reason, d); err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(nil), nil
+	})
+
+	rg.DELETE("/bans/{ip}", func(ctx *prouter.Context) (prouter.Response, error) {
+		ip, err := canonicalIP(ctx.Var("ip"))
+		if err != nil {
+			return nil, err
+		}
+		if err := e.store.Unban(ctx, ip); err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(nil), nil
+	})
+}
+
+// canonicalIP returns ip in the form of ctx.ClientIp, e.g. 1.2.3.4 for
+// ::ffff:1.2.3.4 and 2001:db8::1 for 2001:DB8::1
+func canonicalIP(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", prouter.MsgError(http.StatusBadRequest, "invalid ip: "+ip).
+			SetComponent(prouter.ErrProuter).
+			SetResponseType(prouter.BadRequest)
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,61 @@
+package ban
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func TestAdminCanonicalizesIPs(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		clientIP string
+	}{
+		{"ipv4", "192.0.2.1", "192.0.2.1"},
+		{"ipv4 mapped", "::ffff:192.0.2.1", "192.0.2.1"},
+		{"ipv6 upper case", "2001:DB8::1", "2001:db8::1"},
+		{"ipv6 expanded", "2001:0db8:0000:0000:0000:0000:0000:0001", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			router := prouter.New()
+			NewEngine(store).Mount(&router.RouterGroup)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/bans", strings.NewReader(`{"ip":"`+tt.ip+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("ban: code = %d, body = %s", rec.Code, rec.Body)
+			}
+
+			if denied, _ := store.Denied(context.Background(), tt.clientIP); !denied {
+				t.Fatalf("%s is not denied after banning %s", tt.clientIP, tt.ip)
+			}
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/bans/"+url.PathEscape(tt.ip), nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unban: code = %d, body = %s", rec.Code, rec.Body)
+			}
+			if denied, _ := store.Denied(context.Background(), tt.clientIP); denied {
+				t.Errorf("%s is still denied after unbanning %s", tt.clientIP, tt.ip)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	router := prouter.New()
+	NewEngine(NewMemoryStore()).Mount(&router.RouterGroup)
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/bans/not-an-ip", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unban of an invalid ip: code = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,161 @@
+package ban
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// Rule bans a client which made MaxHits matching requests within Window for BanFor.
+type Rule struct {
+	Name string
+	// Match reports whether the finished request counts against the client
+	Match   func(ctx *prouter.Context) bool
+	MaxHits int
+	Window  time.Duration
+	BanFor  time.Duration
+}
+
+// StatusRule counts the responses with status, e.g. 10 401s in 5 minutes.
+func StatusRule(status, maxHits int, window, banFor time.Duration) Rule {
+	return Rule{
+		Name: http.StatusText(status),
+		Match: func(ctx *prouter.Context) bool {
+			return ctx.Writer.StatusCode() == status
+		},
+		MaxHits: maxHits,
+		Window:  window,
+		BanFor:  banFor,
+	}
+}
+
+type ruleHits struct {
+	rule Rule
+
+	mu    sync.Mutex
+	hits  map[string][]time.Time
+	swept time.Time
+}
+
+// hit records a strike of ip and reports whether it reached the limit
+func (r *ruleHits) hit(ip string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := now.Add(-r.rule.Window)
+	if now.Sub(r.swept) > r.rule.Window {
+		for k, ts := range r.hits {
+			if len(ts) == 0 || ts[len(ts)-1].Before(cutoff) {
+				delete(r.hits, k)
+			}
+		}
+		r.swept = now
+	}
+
+	ts := r.hits[ip]
+	i := 0
+	for i < len(ts) && ts[i].Before(cutoff) {
+		i++
+	}
+	ts = append(ts[i:], now)
+	if len(ts) >= r.rule.MaxHits {
+		delete(r.hits, ip)
+		return true
+	}
+	r.hits[ip] = ts
+	return false
+}
+
+// Engine bans clients by its rules into the store. Use its Middleware to apply
+// the rules and guard the routes with prouter.NewIPFilterMiddleware(prouter.WithDenyChecker(store)):
+//
+//	store := ban.NewMemoryStore()
+//	engine := ban.NewEngine(store, ban.StatusRule(http.StatusUnauthorized, 10, 5*time.Minute, time.Hour))
+//	r.UseMiddleware(prouter.NewIPFilterMiddleware(prouter.WithDenyChecker(store)))
+//	r.Use(engine.Middleware())
+type Engine struct {
+	store Store
+	rules []*ruleHits
+	onBan func(e Entry)
+}
+
+func NewEngine(store Store, rules ...Rule) *Engine {
+	e := &Engine{store: store}
+	for _, r := range rules {
+		e.rules = append(e.rules, &ruleHits{rule: r, hits: make(map[string][]time.Time)})
+	}
+	return e
+}
+
+// OnBan calls fn for every ban made by the engine.
+func (e *Engine) OnBan(fn func(entry Entry)) *Engine {
+	e.onBan = fn
+	return e
+}
+
+func (e *Engine) Store() Store {
+	return e.store
+}
+
+// Ban bans ip for d.
+func (e *Engine) Ban(ctx context.Context, ip, reason string, d time.Duration) error {
+	entry := Entry{IP: ip, Reason: reason, Until: time.Now().Add(d)}
+	if err := e.store.Ban(ctx, entry); err != nil {
+		return err
+	}
+
+	plog.Infoc(ctx, "ban %s for %v: %s", ip, d, reason)
+	if e.onBan != nil {
+		e.onBan(entry)
+	}
+	return nil
+}
+
+func (e *Engine) observe(ctx *prouter.Context) {
+	ip := ctx.ClientIp
+	if ip == "" || ctx.ThrottleBypassed() {
+		return
+	}
+
+	now := time.Now()
+	for _, r := range e.rules {
+		if !r.rule.Match(ctx) || !r.hit(ip, now) {
+			continue
+		}
+		if err := e.Ban(context.WithoutCancel(ctx), ip, r.rule.Name, r.rule.BanFor); err != nil {
+			plog.Errorc(ctx, "ban %s error: %v", ip, err)
+		}
+		return
+	}
+}
+
+// Middleware applies the rules to the requests of the group it is used in.
+func (e *Engine) Middleware() prouter.HandleFunc {
+	return func(ctx *prouter.Context) (prouter.Response, error) {
+		ctx.Writer.OnFinish(func() {
+			e.observe(ctx)
+		})
+		return nil, nil
+	}
+}
+
+// Honeypot registers paths in rg which no legit client requests, e.g. /wp-login.php,
+// a hit bans the client for banFor at once and is answered with 404.
+func (e *Engine) Honeypot(rg *prouter.RouterGroup, banFor time.Duration, paths ...string) {
+	for _, p := range paths {
+		rg.Any(p, func(ctx *prouter.Context) (prouter.Response, error) {
+			if ctx.ClientIp != "" {
+				if err := e.Ban(ctx, ctx.ClientIp, "honeypot "+ctx.RouteTemplate(), banFor); err != nil {
+					plog.Errorc(ctx, "ban %s error: %v", ctx.ClientIp, err)
+				}
+			}
+			return nil, prouter.MsgError(http.StatusNotFound, http.StatusText(http.StatusNotFound)).
+				SetComponent(prouter.ErrProuter).
+				SetResponseType(prouter.NotFound)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package ban
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/goredis"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotFound = errors.New("ban: not found")
+
+type Entry struct {
+	IP     string    `json:"ip" binding:"required"`
+	Reason string    `json:"reason,omitempty"`
+	Until  time.Time `json:"until"`
+}
+
+// Store keeps the banned IPs, entries expire at Until. Its Denied method makes
+// it a prouter.DenyChecker for the IP filter middleware.
+type Store interface {
+	Ban(ctx context.Context, e Entry) error
+	Unban(ctx context.Context, ip string) error
+	Denied(ctx context.Context, ip string) (bool, error)
+	// List returns the active bans ordered by IP
+	List(ctx context.Context) ([]Entry, error)
+}
+
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+func (s *MemoryStore) Ban(_ context.Context, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[e.IP] = e
+	return nil
+}
+
+func (s *MemoryStore) Unban(_ context.Context, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[ip]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, ip)
+	return nil
+}
+
+func (s *MemoryStore) Denied(_ context.Context, ip string) (bool, error) {
+	s.mu.RLock()
+	e, ok := s.entries[ip]
+	s.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	if time.Now().After(e.Until) {
+		s.mu.Lock()
+		if cur, ok := s.entries[ip]; ok && cur.Until.Equal(e.Until) {
+			delete(s.entries, ip)
+		}
+		s.mu.Unlock()
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) List(_ context.Context) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	ret := make([]Entry, 0, len(s.entries))
+	for ip, e := range s.entries {
+		if now.After(e.Until) {
+			delete(s.entries, ip)
+			continue
+		}
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].IP < ret[j].IP })
+	return ret, nil
+}
+
+// RedisStore shares the bans between instances, each ban is a key expiring with it.
+type RedisStore struct {
+	client *goredis.PuzzleRedisClient
+	prefix string
+}
+
+func NewRedisStore(client *goredis.PuzzleRedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) key(ip string) string {
+	return s.prefix + ":" + ip
+}
+
+func (s *RedisStore) Ban(ctx context.Context, e Entry) error {
+	ttl := time.Until(e.Until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.key(e.IP), b, ttl).Err()
+}
+
+func (s *RedisStore) Unban(ctx context.Context, ip string) error {
+	n, err := s.client.Del(ctx, s.key(ip)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) Denied(ctx context.Context, ip string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.key(ip)).Result()
+	return n > 0, err
+}
+
+func (s *RedisStore) List(ctx context.Context) ([]Entry, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, s.prefix+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	ret := make([]Entry, 0, len(values))
+	for _, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			// expired between SCAN and MGET
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(str), &e); err != nil {
+			return nil, err
+		}
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].IP < ret[j].IP })
+	return ret, nil
+}
@@ -0,0 +1,66 @@
+package prouter
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// StartupInfo is the summary written by WithStartupBanner once the listener is open.
+type StartupInfo struct {
+	Mode        string    `json:"mode"`
+	Listener    string    `json:"listener"`
+	TLS         bool      `json:"tls"`
+	Middlewares []string  `json:"middlewares"`
+	Routes      int       `json:"routes"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+// WithStartupBanner writes the StartupInfo of Run and RunTLS as a JSON line to w,
+// e.g. for the log collector or a deploy check.
+func WithStartupBanner(w io.Writer) RouterOption {
+	return func(v *Prouter) {
+		v.banner = w
+	}
+}
+
+func modeName(mode int) string {
+	switch mode {
+	case ReleaseMode:
+		return "release"
+	case TestMode:
+		return "test"
+	default:
+		return "debug"
+	}
+}
+
+// StartupInfo summarizes the router serving on l.
+func (v *Prouter) StartupInfo(l net.Listener, tls bool) StartupInfo {
+	info := StartupInfo{
+		Mode:        modeName(prouterMode),
+		TLS:         tls,
+		Middlewares: make([]string, 0, len(v.middlewares)),
+		Routes:      len(v.RouteTable()),
+		StartedAt:   time.Now(),
+	}
+	if l != nil {
+		info.Listener = l.Addr().String()
+	}
+	for _, m := range v.middlewares {
+		info.Middlewares = append(info.Middlewares, middlewareName(m))
+	}
+	return info
+}
+
+func (v *Prouter) printBanner(l net.Listener, tls bool) {
+	if v.banner == nil {
+		return
+	}
+	if err := json.NewEncoder(v.banner).Encode(v.StartupInfo(l, tls)); err != nil {
+		plog.Errorf("write startup banner error: %v", err)
+	}
+}
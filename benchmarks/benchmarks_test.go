@@ -0,0 +1,180 @@
+// Package benchmarks measures the hot paths of prouter: route lookup,
+// middleware chains, envelope serialization, the session middleware and query
+// parameter access.
+//
+// Run them with `make bench` and compare two runs with benchstat, see
+// `make bench-compare`.
+package benchmarks
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+func init() {
+	prouter.SetMode(prouter.ReleaseMode)
+	plog.SetOutput(io.Discard)
+}
+
+// discardWriter is a reusable http.ResponseWriter which drops the body.
+type discardWriter struct {
+	header http.Header
+}
+
+func newDiscardWriter() *discardWriter {
+	return &discardWriter{header: make(http.Header)}
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *discardWriter) WriteHeader(int) {}
+
+func (w *discardWriter) reset() {
+	clear(w.header)
+}
+
+func serve(b *testing.B, h http.Handler, r *http.Request) {
+	w := newDiscardWriter()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.reset()
+		h.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkRouting(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprint(n), routing(n))
+	}
+}
+
+func BenchmarkMiddlewareChain(b *testing.B) {
+	for _, depth := range []int{1, 5, 20} {
+		b.Run(fmt.Sprint(depth), middlewareChain(depth))
+	}
+}
+
+func BenchmarkEnvelope(b *testing.B) {
+	b.Run("Small", envelope(1))
+	b.Run("Large", envelope(1000))
+}
+
+func BenchmarkSession(b *testing.B) {
+	b.Run("Cookie", sessionCookie)
+}
+
+func BenchmarkQuery(b *testing.B) {
+	b.Run("URL", queryParams(false))
+	b.Run("Context", queryParams(true))
+}
+
+func okHandler(ctx *prouter.Context) (prouter.Response, error) {
+	return prouter.SuccessResponse("ok"), nil
+}
+
+func routing(n int) func(b *testing.B) {
+	return func(b *testing.B) {
+		router := prouter.New()
+		for i := 0; i < n; i++ {
+			router.GET(fmt.Sprintf("/r%d/items/{id}", i), okHandler)
+		}
+
+		r, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/r%d/items/42", n-1), nil)
+		serve(b, router, r)
+	}
+}
+
+func nopMiddleware(ctx *prouter.Context) (prouter.Response, error) {
+	return nil, nil
+}
+
+func middlewareChain(depth int) func(b *testing.B) {
+	return func(b *testing.B) {
+		router := prouter.New()
+		for i := 0; i < depth; i++ {
+			router.Use(nopMiddleware)
+		}
+		router.GET("/chain", okHandler)
+
+		r, _ := http.NewRequest(http.MethodGet, "/chain", nil)
+		serve(b, router, r)
+	}
+}
+
+type item struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Score float64  `json:"score"`
+}
+
+func envelope(items int) func(b *testing.B) {
+	data := make([]item, items)
+	for i := range data {
+		data[i] = item{ID: i, Name: fmt.Sprintf("item-%d", i), Tags: []string{"a", "b"}, Score: float64(i) / 3}
+	}
+
+	return func(b *testing.B) {
+		router := prouter.New()
+		router.GET("/envelope", func(ctx *prouter.Context) (prouter.Response, error) {
+			return prouter.SuccessResponse(data), nil
+		})
+
+		r, _ := http.NewRequest(http.MethodGet, "/envelope", nil)
+		serve(b, router, r)
+	}
+}
+
+func sessionCookie(b *testing.B) {
+	router := prouter.New()
+	router.UseMiddleware(prouter.NewSessionMiddleware("bench"))
+	router.GET("/session", func(ctx *prouter.Context) (prouter.Response, error) {
+		if err := ctx.Session().Set("key", "value"); err != nil {
+			return nil, err
+		}
+		return prouter.SuccessResponse("ok"), nil
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/session", nil)
+	serve(b, router, r)
+}
+
+var queryKeys = []string{"page", "limit", "sort", "q", "status", "owner", "from", "to"}
+
+// queryParams reads every query parameter twice, like a handler and a filter
+// middleware would, from the cached ctx.Query or by parsing the raw query each time.
+func queryParams(cached bool) func(b *testing.B) {
+	return func(b *testing.B) {
+		router := prouter.New()
+		router.GET("/search", func(ctx *prouter.Context) (prouter.Response, error) {
+			n := 0
+			for i := 0; i < 2; i++ {
+				for _, key := range queryKeys {
+					if cached {
+						n += len(ctx.Query(key))
+					} else {
+						n += len(ctx.Request.URL.Query().Get(key))
+					}
+				}
+			}
+			return prouter.SuccessResponse(n), nil
+		})
+
+		r, _ := http.NewRequest(http.MethodGet,
+			"/search?page=2&limit=50&sort=-created&q=router&status=open&owner=me&from=2024-01-01&to=2024-12-31", nil)
+		serve(b, router, r)
+	}
+}
@@ -0,0 +1,407 @@
+package prouter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+// defaultMultipartMemory is the part of multipart bodies kept in memory, the rest goes to temp files
+const defaultMultipartMemory = 32 << 20
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	timeType            = reflect.TypeOf(time.Time{})
+
+	formKeyReplacer = strings.NewReplacer("][", ".", "].", ".", "[", ".")
+)
+
+// BinderFunc decodes the request into obj for a registered content type.
+type BinderFunc func(r *http.Request, obj any) error
+
+// RegisterBinder makes ctx.Bind and BodyParser decode requests of contentType
+// with fn, the result is validated like the built-in binders. It must be
+// called before serving.
+func (v *Prouter) RegisterBinder(contentType string, fn BinderFunc) {
+	if v.binders == nil {
+		v.binders = make(map[string]BinderFunc)
+	}
+	v.binders[strings.ToLower(contentType)] = fn
+}
+
+func validate(obj any) error {
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// validate runs the validator of the router, see WithValidator
+func (c *Context) validate(obj any) error {
+	if c.router != nil && c.router.validator != nil {
+		return validateValue(c.router.validator, reflect.ValueOf(obj))
+	}
+	return validate(obj)
+}
+
+// validateValue passes the structs of v to val, like the default validator of
+// gin it goes into pointers, slices and arrays and skips other kinds
+func validateValue(val Validator, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return val.ValidateStruct(v.Interface())
+		}
+		return validateValue(val, v.Elem())
+	case reflect.Struct:
+		return val.ValidateStruct(v.Interface())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(val, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func bindError(obj any, err error) Error {
+	return NewErr(http.StatusBadRequest, validationCause(obj, err), "parse request data failed").
+		SetComponent(ErrProuter).
+		SetResponseType(BadRequest)
+}
+
+// Bind decodes the request into obj with the binder registered for its
+// Content-Type, falling back to the built-in JSON, XML, form, protobuf, yaml
+// and toml binders.
+func (c *Context) Bind(obj any) error {
+	if err := c.bindBody(obj); err != nil {
+		return bindError(obj, err)
+	}
+	return nil
+}
+
+func (c *Context) bindBody(obj any) error {
+	if err := c.decodeBody(obj); err != nil {
+		return err
+	}
+	return c.validate(obj)
+}
+
+// decodeBody decodes the body without validating obj. The JSON, XML and form
+// bodies are decoded here, the other gin binders validate with
+// binding.Validator while decoding.
+func (c *Context) decodeBody(obj any) error {
+	c.rewindBody()
+	r := c.Request
+	ct := strings.ToLower(contentType(r))
+
+	if c.router != nil {
+		if fn, ok := c.router.binders[ct]; ok {
+			return fn(r, obj)
+		}
+	}
+
+	switch binder := binding.Default(r.Method, ct); binder {
+	case binding.Form, binding.FormMultipart:
+		return mapForm(r, obj)
+	case binding.JSON:
+		return c.decodeJSON(obj)
+	case binding.XML:
+		return decodeXML(r, obj)
+	default:
+		return binder.Bind(r, obj)
+	}
+}
+
+// decodeJSON decodes like binding.JSON, with the unknown field policy of the route
+func (c *Context) decodeJSON(obj any) error {
+	if err := c.checkUnknownFields(obj); err != nil {
+		return err
+	}
+	if c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+	decoder := json.NewDecoder(c.Request.Body)
+	if binding.EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	if binding.EnableDecoderDisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(obj)
+}
+
+func decodeXML(r *http.Request, obj any) error {
+	if r.Body == nil {
+		return errors.New("invalid request")
+	}
+	return xml.NewDecoder(r.Body).Decode(obj)
+}
+
+// BindJSON decodes the json request body into obj and validates it, whatever
+// the Content-Type of the request.
+func (c *Context) BindJSON(obj any) error {
+	c.rewindBody()
+	if err := c.decodeJSON(obj); err != nil {
+		return bindError(obj, err)
+	}
+	if err := c.validate(obj); err != nil {
+		return bindError(obj, err)
+	}
+	return nil
+}
+
+// BindQuery decodes the query of the request into obj using `form` tags and
+// validates it, the body is not read.
+func (c *Context) BindQuery(obj any) error {
+	if err := binding.MapFormWithTag(obj, c.QueryValues(), "form"); err != nil {
+		return bindError(obj, err)
+	}
+	if err := c.validate(obj); err != nil {
+		return bindError(obj, err)
+	}
+	return nil
+}
+
+// BindXML decodes the xml request body into obj and validates it.
+func (c *Context) BindXML(obj any) error {
+	c.rewindBody()
+	if err := decodeXML(c.Request, obj); err != nil {
+		return bindError(obj, err)
+	}
+	if err := c.validate(obj); err != nil {
+		return bindError(obj, err)
+	}
+	return nil
+}
+
+// BindForm decodes the query and the urlencoded or multipart form of the request
+// into obj using `form` tags and validates it.
+//
+// Besides the flat fields supported by gin, nested structs and slices of
+// structs are filled from keys like "address.city", "address[city]" or
+// "items[0][name]", and *multipart.FileHeader fields from uploaded files.
+func (c *Context) BindForm(obj any) error {
+	c.rewindBody()
+	if err := mapForm(c.Request, obj); err != nil {
+		return bindError(obj, err)
+	}
+	if err := c.validate(obj); err != nil {
+		return bindError(obj, err)
+	}
+	return nil
+}
+
+// mapForm fills obj from the query and form of r without validating it
+func mapForm(r *http.Request, obj any) error {
+	err := r.ParseMultipartForm(defaultMultipartMemory)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+
+	form := normalizeFormKeys(r.Form)
+	var files map[string][]*multipart.FileHeader
+	if r.MultipartForm != nil {
+		files = normalizeFormKeys(r.MultipartForm.File)
+	}
+
+	if err := binding.MapFormWithTag(obj, form, "form"); err != nil {
+		return err
+	}
+	return mapNestedForm(reflect.ValueOf(obj), form, files)
+}
+
+// headerForm collects the `header` tags of t with the values of h, gin matches
+// the tags by their canonical form, so the keys are the tags as written
+func headerForm(t reflect.Type, h http.Header, form map[string][]string, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("header"), ",")
+		if name == "-" {
+			continue
+		}
+		if name != "" {
+			if values := h.Values(name); len(values) > 0 {
+				form[name] = values
+			}
+		}
+		headerForm(sf.Type, h, form, seen)
+	}
+}
+
+// mapHeader is binding.Header without the validation
+func mapHeader(r *http.Request, obj any) error {
+	form := make(map[string][]string)
+	headerForm(reflect.TypeOf(obj), r.Header, form, make(map[reflect.Type]bool))
+	return binding.MapFormWithTag(obj, form, "header")
+}
+
+// normalizeFormKeys rewrites the bracket notation a[b][0] into a.b.0 and drops
+// the trailing [] of array fields.
+func normalizeFormKeys[T any](form map[string][]T) map[string][]T {
+	ret := make(map[string][]T, len(form))
+	for key, vals := range form {
+		if strings.ContainsRune(key, '[') {
+			key = strings.TrimSuffix(key, "[]")
+			key = formKeyReplacer.Replace(key)
+			key = strings.TrimSuffix(key, "]")
+		}
+		ret[key] = append(ret[key], vals...)
+	}
+	return ret
+}
+
+func subForm[T any](form map[string][]T, prefix string) map[string][]T {
+	var ret map[string][]T
+	for key, vals := range form {
+		if rest, ok := strings.CutPrefix(key, prefix+"."); ok {
+			if ret == nil {
+				ret = make(map[string][]T)
+			}
+			ret[rest] = vals
+		}
+	}
+	return ret
+}
+
+func formIndexes[T any](form map[string][]T, indexes map[int]struct{}) {
+	for key := range form {
+		idx, _, _ := strings.Cut(key, ".")
+		if i, err := strconv.Atoi(idx); err == nil && i >= 0 {
+			indexes[i] = struct{}{}
+		}
+	}
+}
+
+func formFieldKey(sf reflect.StructField) string {
+	key, _, _ := strings.Cut(sf.Tag.Get("form"), ",")
+	if key == "" {
+		key = sf.Name
+	}
+	return key
+}
+
+func mapNestedForm(v reflect.Value, form map[string][]string, files map[string][]*multipart.FileHeader) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		sf := t.Field(i)
+		if (sf.PkgPath != "" && !sf.Anonymous) || sf.Tag.Get("form") == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous {
+			if err := mapNestedForm(fv, form, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := formFieldKey(sf)
+		switch {
+		case sf.Type == fileHeaderType:
+			if fs := files[key]; len(fs) > 0 {
+				fv.Set(reflect.ValueOf(fs[0]))
+			}
+		case sf.Type == fileHeaderSliceType:
+			if fs := files[key]; len(fs) > 0 {
+				fv.Set(reflect.ValueOf(fs))
+			}
+		case isNestedStruct(sf.Type):
+			if err := mapNestedStruct(fv, subForm(form, key), subForm(files, key)); err != nil {
+				return errors.Wrap(err, key)
+			}
+		case sf.Type.Kind() == reflect.Slice && isNestedStruct(sf.Type.Elem()):
+			if err := mapNestedSlice(fv, subForm(form, key), subForm(files, key)); err != nil {
+				return errors.Wrap(err, key)
+			}
+		}
+	}
+	return nil
+}
+
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && t != fileHeaderType.Elem()
+}
+
+func mapNestedStruct(fv reflect.Value, form map[string][]string, files map[string][]*multipart.FileHeader) error {
+	if len(form) == 0 && len(files) == 0 {
+		return nil
+	}
+
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	if err := binding.MapFormWithTag(fv.Addr().Interface(), form, "form"); err != nil {
+		return err
+	}
+	return mapNestedForm(fv, form, files)
+}
+
+func mapNestedSlice(fv reflect.Value, form map[string][]string, files map[string][]*multipart.FileHeader) error {
+	indexes := make(map[int]struct{})
+	formIndexes(form, indexes)
+	formIndexes(files, indexes)
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	sorted := make([]int, 0, len(indexes))
+	for i := range indexes {
+		sorted = append(sorted, i)
+	}
+	sort.Ints(sorted)
+
+	// indexes are compacted so a sparse input doesn't allocate a huge slice
+	slice := reflect.MakeSlice(fv.Type(), len(sorted), len(sorted))
+	for n, i := range sorted {
+		idx := strconv.Itoa(i)
+		if err := mapNestedStruct(slice.Index(n), subForm(form, idx), subForm(files, idx)); err != nil {
+			return errors.Wrap(err, idx)
+		}
+	}
+	fv.Set(slice)
+	return nil
+}
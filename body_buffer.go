@@ -0,0 +1,90 @@
+package prouter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+const defaultBodyBufferLimit = 4 << 20
+
+// WithBodyBufferLimit caps the body buffered by ctx.BodyBytes, 4MB by default.
+func WithBodyBufferLimit(n int64) RouterOption {
+	return func(v *Prouter) {
+		v.bodyBufferLimit = n
+	}
+}
+
+func (c *Context) bodyBufferLimit() int64 {
+	if c.router != nil && c.router.bodyBufferLimit > 0 {
+		return c.router.bodyBufferLimit
+	}
+	return defaultBodyBufferLimit
+}
+
+// BodyBytes reads the request body once and keeps it, so signature checks, audit
+// logs and Bind can all read it: the request body is reset to the start on every
+// call and before the binders run. A body over the limit of WithBodyBufferLimit
+// is answered with 413, it is left readable for streaming.
+func (c *Context) BodyBytes() ([]byte, error) {
+	if c.bodyBuf != nil {
+		c.rewindBody()
+		return c.bodyBuf, nil
+	}
+	if c.bodySpool != nil {
+		return c.spooledBytes()
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		c.bodyBuf = []byte{}
+		return c.bodyBuf, nil
+	}
+
+	limit := c.bodyBufferLimit()
+	original := c.Request.Body
+	buf, err := io.ReadAll(io.LimitReader(original, limit+1))
+	if err != nil {
+		return nil, bodyReadError(err)
+	}
+	if int64(len(buf)) > limit {
+		c.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), original), original}
+		return nil, MsgError(http.StatusRequestEntityTooLarge, "request body too large").SetComponent(ErrProuter)
+	}
+
+	original.Close()
+	c.keepBody(buf)
+	return c.bodyBuf, nil
+}
+
+func (c *Context) keepBody(buf []byte) {
+	c.bodyBuf = buf
+	c.Request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(c.bodyBuf)), nil
+	}
+	c.rewindBody()
+}
+
+// spooledBytes reads a spooled body into memory, within the buffer limit
+func (c *Context) spooledBytes() ([]byte, error) {
+	c.rewindBody()
+	if c.bodySpoolSize > c.bodyBufferLimit() {
+		return nil, MsgError(http.StatusRequestEntityTooLarge, "request body too large").SetComponent(ErrProuter)
+	}
+	buf, err := io.ReadAll(io.NewSectionReader(c.bodySpool, 0, c.bodySpoolSize))
+	if err != nil {
+		return nil, bodyReadError(err)
+	}
+	return buf, nil
+}
+
+// rewindBody resets the request body to the start of a buffered or spooled body
+func (c *Context) rewindBody() {
+	switch {
+	case c.bodyBuf != nil:
+		c.Request.Body = io.NopCloser(bytes.NewReader(c.bodyBuf))
+	case c.bodySpool != nil:
+		c.Request.Body = io.NopCloser(io.NewSectionReader(c.bodySpool, 0, c.bodySpoolSize))
+	}
+}
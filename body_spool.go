@@ -0,0 +1,123 @@
+package prouter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+)
+
+// WithMaxBodySize caps the request body of the route at n bytes. A larger
+// Content-Length is answered with 413 before the handler runs, a chunked body
+// fails with 413 when it is read past n.
+func WithMaxBodySize(n int64) RouteOption {
+	return func(c *routeConfig) {
+		c.maxBodySize = n
+	}
+}
+
+// WithBodySpool keeps request bodies of up to threshold bytes in memory for
+// ctx.BodyReader, larger ones are spooled to a temporary file which is removed
+// once the response is written, e.g. for upload routes:
+//
+//	router.POST("/uploads", upload, prouter.WithMaxBodySize(1<<30), prouter.WithBodySpool(1<<20))
+func WithBodySpool(threshold int64) RouteOption {
+	return func(c *routeConfig) {
+		c.spoolThreshold = threshold
+	}
+}
+
+func maxBodyHandler(limit int64, handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		if ctx.Request.ContentLength > limit {
+			return nil, preconditionError(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", limit)
+		}
+		if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+			ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit)
+		}
+		return handler.Handle(ctx)
+	})
+}
+
+// bodyReadError is the response to a failed read of the request body
+func bodyReadError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return preconditionError(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", maxErr.Limit)
+	}
+	return NewErr(http.StatusBadRequest, err, "read body failed").
+		SetComponent(ErrProuter).
+		SetResponseType(BadRequest)
+}
+
+func (c *Context) spoolThreshold() int64 {
+	if c.route == nil {
+		return 0
+	}
+	return c.route.spoolThreshold
+}
+
+// BodyReader returns a reader from the start of the request body, which can be
+// called again and read along with Bind. Without WithBodySpool on the route it
+// reads the body with BodyBytes, else bodies over the threshold are read from
+// their temporary file.
+func (c *Context) BodyReader() (io.ReadSeeker, error) {
+	if c.bodySpool != nil {
+		return io.NewSectionReader(c.bodySpool, 0, c.bodySpoolSize), nil
+	}
+	threshold := c.spoolThreshold()
+	if threshold <= 0 || c.bodyBuf != nil {
+		buf, err := c.BodyBytes()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(buf), nil
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		c.bodyBuf = []byte{}
+		return bytes.NewReader(c.bodyBuf), nil
+	}
+
+	original := c.Request.Body
+	head, err := io.ReadAll(io.LimitReader(original, threshold+1))
+	if err != nil {
+		return nil, bodyReadError(err)
+	}
+	if int64(len(head)) <= threshold {
+		original.Close()
+		c.keepBody(head)
+		return bytes.NewReader(head), nil
+	}
+
+	if err := c.spoolBody(io.MultiReader(bytes.NewReader(head), original)); err != nil {
+		return nil, err
+	}
+	original.Close()
+	return io.NewSectionReader(c.bodySpool, 0, c.bodySpoolSize), nil
+}
+
+func (c *Context) spoolBody(body io.Reader) error {
+	f, err := os.CreateTemp("", "prouter-body-*")
+	if err != nil {
+		return NewErr(http.StatusInternalServerError, err, "spool body failed").
+			SetComponent(ErrProuter).
+			SetResponseType(InternalServerError)
+	}
+	c.Writer.OnFinish(func() {
+		f.Close()
+		os.Remove(f.Name())
+	})
+
+	n, err := io.Copy(f, body)
+	if err != nil {
+		return bodyReadError(err)
+	}
+	c.bodySpool = f
+	c.bodySpoolSize = n
+	c.Request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(f, 0, n)), nil
+	}
+	c.rewindBody()
+	return nil
+}
@@ -0,0 +1,50 @@
+package prouter
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// bufferInitSize is the capacity of newly allocated response buffers
+	bufferInitSize atomic.Int64
+	// bufferMaxSize is the largest buffer kept in the pool, larger ones are left to the GC
+	bufferMaxSize atomic.Int64
+
+	bufferPool = sync.Pool{
+		New: func() any {
+			return bytes.NewBuffer(make([]byte, 0, bufferInitSize.Load()))
+		},
+	}
+)
+
+func init() {
+	bufferInitSize.Store(4 << 10)
+	bufferMaxSize.Store(1 << 20)
+}
+
+// SetResponseBufferSize configures the pooled buffers used to encode responses.
+// initSize is the capacity of new buffers and buffers grown beyond maxSize are
+// not reused, which bounds the memory held by the pool. It is safe to call
+// while serving.
+func SetResponseBufferSize(initSize, maxSize int) {
+	if initSize > 0 {
+		bufferInitSize.Store(int64(initSize))
+	}
+	if maxSize > 0 {
+		bufferMaxSize.Store(int64(maxSize))
+	}
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if int64(buf.Cap()) > bufferMaxSize.Load() {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
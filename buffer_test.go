@@ -0,0 +1,44 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSetResponseBufferSizeWhileServing(t *testing.T) {
+	defer SetResponseBufferSize(4<<10, 1<<20)
+
+	router := New()
+	router.GET("/", func(*Context) (Response, error) {
+		return SuccessResponse("ok"), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				SetResponseBufferSize(1<<10*(i+1), 1<<16+j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	SetResponseBufferSize(2048, 0)
+	SetResponseBufferSize(0, 512)
+	if got := bufferInitSize.Load(); got != 2048 {
+		t.Errorf("init size = %d, want 2048", got)
+	}
+	if got := bufferMaxSize.Load(); got != 512 {
+		t.Errorf("max size = %d, want 512", got)
+	}
+}
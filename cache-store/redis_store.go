@@ -0,0 +1,99 @@
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/goredis"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ prouter.CacheStore = (*RedisStore)(nil)
+
+// RedisStore is a prouter.CacheStore shared between instances.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStoreWithAddr(addr string, db int, prefix string) *RedisStore {
+	return NewRedisStoreWithClient(goredis.NewRedisClient(addr, db), prefix)
+}
+
+// NewRedisStoreWithClient stores the entries with client, a single node, a
+// sentinel failover or a cluster client:
+//
+//	client := redis.NewUniversalClient(&redis.UniversalOptions{
+//		Addrs:      []string{"sentinel-1:26379", "sentinel-2:26379"},
+//		MasterName: "cache",
+//	})
+//	store := cachestore.NewRedisStoreWithClient(client, "cache")
+func NewRedisStoreWithClient(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Key(k string) string {
+	if s.prefix == "" {
+		return k
+	}
+	return s.prefix + ":" + k
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.client.Get(ctx, s.Key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, prouter.ErrCacheMiss
+	}
+	return b, err
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.Key(key), value, ttl).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.Key(key)).Err()
+}
+
+// GetMany returns the entries of keys which are cached. The keys are read as a
+// pipeline of single key commands rather than one MGET, so keys of different
+// cluster slots do not fail with CROSSSLOT, the cluster client sends each
+// command to the node of its slot.
+func (s *RedisStore) GetMany(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.Get(ctx, s.Key(key))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	ret := make(map[string][]byte, len(keys))
+	for i, cmd := range cmds {
+		b, err := cmd.Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret[keys[i]] = b
+	}
+	return ret, nil
+}
+
+// DeleteMany deletes keys, pipelined per key like GetMany.
+func (s *RedisStore) DeleteMany(ctx context.Context, keys ...string) error {
+	_, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			p.Del(ctx, s.Key(key))
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,265 @@
+package prouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+var ErrCacheMiss = errors.New("cache miss")
+
+// CacheStore keeps the encoded values of ctx.Cached, see cache-store for Redis.
+type CacheStore interface {
+	// Get returns ErrCacheMiss for an absent or expired key
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is a CacheStore for a single instance, expired entries are swept
+// at most once per minute.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	swept   time.Time
+	clock   Clock
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+// WithClock replaces the clock the entries expire by. By default it is the
+// clock of the router serving the request, see the WithClock RouterOption, and
+// the wall clock outside of a request.
+func (c *MemoryCache) WithClock(clock Clock) *MemoryCache {
+	c.clock = clock
+	return c
+}
+
+func (c *MemoryCache) now(ctx context.Context) time.Time {
+	if c.clock != nil {
+		return c.clock.Now()
+	}
+	return contextNow(ctx)
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	now := c.now(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || now.After(e.expires) {
+		return nil, ErrCacheMiss
+	}
+	return e.value, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := c.now(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.swept) > time.Minute {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.swept = now
+	}
+	c.entries[key] = memoryCacheEntry{value: value, expires: now.Add(ttl)}
+	return nil
+}
+
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
+
+// flightGroup runs one computation per key at a time, the other callers wait for
+// its result until their own ctx is done
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.value, c.err
+		case <-ctx.Done():
+			return nil, cacheError(ctx.Err())
+		}
+	}
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	func() {
+		// the waiters get the panic as error instead of an empty value
+		defer func() {
+			if r := recover(); r != nil {
+				c.err = NewErr(http.StatusInternalServerError, fmt.Errorf("panic recovered in cache computation of %s: %v", key, r)).
+					SetComponent(ErrRecovery)
+			}
+		}()
+		c.value, c.err = fn()
+	}()
+	return c.value, c.err
+}
+
+type cacheConfigKey struct{}
+
+type cacheConfig struct {
+	store     CacheStore
+	namespace string
+	flight    *flightGroup
+}
+
+// WithCache sets the store of ctx.Cached for the routes registered in the group
+// afterwards, including its sub groups.
+func (rg *RouterGroup) WithCache(store CacheStore) {
+	rg.WithValue(cacheConfigKey{}, &cacheConfig{store: store, flight: new(flightGroup)})
+}
+
+// CacheNamespace prefixes the keys of ctx.Cached in the group with namespace,
+// a sub group appends its namespace to the one of its parent: users:avatars:<key>.
+func (rg *RouterGroup) CacheNamespace(namespace string) {
+	var parent *cacheConfig
+	for _, cv := range rg.values {
+		if cv.key == (cacheConfigKey{}) {
+			parent = cv.val.(*cacheConfig)
+		}
+	}
+	if parent == nil {
+		plog.PanicError(errors.New("prouter: CacheNamespace needs WithCache on the group or a parent"))
+	}
+
+	cfg := *parent
+	if cfg.namespace != "" {
+		namespace = cfg.namespace + ":" + namespace
+	}
+	cfg.namespace = namespace
+	rg.WithValue(cacheConfigKey{}, &cfg)
+}
+
+func cacheError(err error) error {
+	return NewErr(http.StatusInternalServerError, err, "cache failed").
+		SetComponent(ErrProuter).
+		SetResponseType(InternalServerError)
+}
+
+// cached returns the JSON encoding of the cached value of key, fn computes it
+// on a miss once for all concurrent callers. fn runs under a ctx which is not
+// cancelled with the request of the caller running it, as the others wait for it.
+// A waiting caller gives up when its own request is done, a panic of fn is
+// returned as error to all of them.
+func (c *Context) cached(key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) ([]byte, error) {
+	cfg, _ := c.Value(cacheConfigKey{}).(*cacheConfig)
+	if cfg == nil {
+		return nil, cacheError(errors.New("no cache store configured, see RouterGroup.WithCache"))
+	}
+	if cfg.namespace != "" {
+		key = cfg.namespace + ":" + key
+	}
+
+	b, err := cfg.store.Get(c, key)
+	if err == nil {
+		return b, nil
+	}
+	if !errors.Is(err, ErrCacheMiss) {
+		plog.Errorc(c, "cache get %s error: %v", key, err)
+	}
+
+	return cfg.flight.do(c, key, func() ([]byte, error) {
+		ctx := context.WithoutCancel(c)
+		v, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, cacheError(err)
+		}
+		if err := cfg.store.Set(ctx, key, b, ttl); err != nil {
+			plog.Errorc(c, "cache set %s error: %v", key, err)
+		}
+		return b, nil
+	})
+}
+
+// Cached returns the value of key from the cache store of the group, on a miss
+// fn computes it once for all concurrent requests and it is stored for ttl.
+// Errors of fn are returned as is and not cached. The value is decoded from
+// JSON on hits and misses alike, use CachedAs to decode into a type:
+//
+//	v, err := ctx.Cached("top", time.Minute, func(ctx context.Context) (any, error) {
+//		return store.Top(ctx, 10)
+//	})
+func (c *Context) Cached(key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) (any, error) {
+	b, err := c.cached(key, ttl, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, cacheError(err)
+	}
+	return v, nil
+}
+
+// CachedAs is Cached decoding the value into T, the value computed by fn is
+// decoded as well so every caller gets the same value.
+func CachedAs[T any](c *Context, key string, ttl time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	var ret T
+	b, err := c.cached(key, ttl, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return ret, cacheError(err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,223 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type cachedUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestCachedReturnsTheSameTypeOnMissAndHit(t *testing.T) {
+	router := New()
+	router.WithCache(NewMemoryCache())
+
+	var values []any
+	var typed []cachedUser
+	router.GET("/", func(ctx *Context) (Response, error) {
+		v, err := ctx.Cached("any", time.Minute, func(context.Context) (any, error) {
+			return cachedUser{Name: "jane", Age: 30}, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		u, err := CachedAs(ctx, "typed", time.Minute, func(context.Context) (cachedUser, error) {
+			return cachedUser{Name: "jane", Age: 30}, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		typed = append(typed, u)
+		return nil, nil
+	})
+
+	for range 2 {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	for i, v := range values {
+		m, ok := v.(map[string]any)
+		if !ok || m["name"] != "jane" {
+			t.Errorf("Cached call %d = %#v, want the decoded map", i, v)
+		}
+	}
+	for i, u := range typed {
+		if u != (cachedUser{Name: "jane", Age: 30}) {
+			t.Errorf("CachedAs call %d = %#v", i, u)
+		}
+	}
+}
+
+func TestCachedFollowersSurviveTheLeaderCancel(t *testing.T) {
+	router := New()
+	router.WithCache(NewMemoryCache())
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.GET("/", func(ctx *Context) (Response, error) {
+		v, err := CachedAs(ctx, "slow", time.Minute, func(ctx context.Context) (string, error) {
+			if calls.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+			return "value", ctx.Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+		return SuccessResponse(v), nil
+	})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	codes := make([]int, 3)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if i == 0 {
+				req = req.WithContext(leaderCtx)
+			} else {
+				<-started
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			codes[i] = rec.Code
+		}()
+		if i == 0 {
+			<-started
+		}
+	}
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("fn ran %d times, want once", calls.Load())
+	}
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d: code = %d, want 200", i, code)
+		}
+	}
+}
+
+func TestCachedWaiterGivesUpOnItsDeadline(t *testing.T) {
+	router := New()
+	router.WithCache(NewMemoryCache())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var waiterErr error
+	router.GET("/", func(ctx *Context) (Response, error) {
+		v, err := CachedAs(ctx, "slow", time.Minute, func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "value", nil
+		})
+		if ctx.Request.URL.Query().Has("waiter") {
+			waiterErr = err
+		}
+		if err != nil {
+			return nil, err
+		}
+		return SuccessResponse(v), nil
+	})
+
+	leader := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		leader <- rec.Code
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?waiter", nil).WithContext(ctx))
+	if rec.Code != http.StatusInternalServerError || waiterErr == nil || !strings.Contains(waiterErr.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("waiter: code = %d, err = %v, want its deadline", rec.Code, waiterErr)
+	}
+
+	close(release)
+	if code := <-leader; code != http.StatusOK {
+		t.Errorf("leader: code = %d, want 200", code)
+	}
+}
+
+func TestCachedLeaderPanic(t *testing.T) {
+	router := New()
+	router.WithCache(NewMemoryCache())
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	errs := make(chan error, 2)
+	router.GET("/", func(ctx *Context) (Response, error) {
+		v, err := ctx.Cached("broken", time.Minute, func(context.Context) (any, error) {
+			if calls.Add(1) == 1 {
+				close(started)
+				<-release
+				panic("store exploded")
+			}
+			return "value", nil
+		})
+		if err != nil {
+			errs <- err
+			return nil, err
+		}
+		return SuccessResponse(v), nil
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i > 0 {
+				<-started
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes[i] = rec.Code
+		}()
+	}
+	<-started
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for i, code := range codes {
+		if code != http.StatusInternalServerError {
+			t.Errorf("request %d: code = %d, want 500", i, code)
+		}
+	}
+	for err := range errs {
+		if err == nil || !strings.Contains(err.Error(), "store exploded") {
+			t.Errorf("err = %v, want the recovered panic", err)
+		}
+	}
+
+	// the panic is not cached, the next request computes the value
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("after the panic: code = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,126 @@
+package prouter
+
+import (
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+var discoveryMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// Capabilities is the document answered to OPTIONS with WithOptionsDiscovery.
+type Capabilities struct {
+	Path    string             `json:"path"`
+	Methods []MethodCapability `json:"methods"`
+}
+
+// MethodCapability describes a route of the path, built from its route options.
+type MethodCapability struct {
+	Method string `json:"method"`
+	Name   string `json:"name,omitempty"`
+	// Schema is the link of WithSchemaURL
+	Schema string           `json:"schema,omitempty"`
+	Query  *QueryCapability `json:"query,omitempty"`
+	// Meta holds the values of WithCapability, e.g. the rate limit of the route
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// QueryCapability is the QueryAllowlist of WithQuerySpec.
+type QueryCapability struct {
+	Filters  map[string][]FilterOp `json:"filters,omitempty"`
+	Sort     []string              `json:"sort,omitempty"`
+	MaxLimit int                   `json:"maxLimit,omitempty"`
+}
+
+// WithOptionsDiscovery answers OPTIONS requests of paths without an OPTIONS
+// route with their Capabilities and the Allow header, for clients discovering
+// the API. Only routes registered WithDiscoverable are listed, the document is
+// answered before any middleware runs. CORS preflights are not affected.
+func WithOptionsDiscovery() RouterOption {
+	return func(v *Prouter) {
+		v.optionsDiscovery = true
+	}
+}
+
+// WithDiscoverable lists the route in the OPTIONS capabilities, anyone can read
+// them as no middleware of the route, e.g. its authentication, runs for them.
+func WithDiscoverable() RouteOption {
+	return func(c *routeConfig) {
+		c.discoverable = true
+	}
+}
+
+// WithSchemaURL links the schema of the route body in the OPTIONS capabilities.
+func WithSchemaURL(url string) RouteOption {
+	return func(c *routeConfig) {
+		c.schemaURL = url
+	}
+}
+
+// WithCapability adds key to the meta of the route in the OPTIONS capabilities,
+// e.g. WithCapability("rateLimit", "100/min").
+func WithCapability(key string, value any) RouteOption {
+	return func(c *routeConfig) {
+		if c.capabilities == nil {
+			c.capabilities = make(map[string]any)
+		}
+		c.capabilities[key] = value
+	}
+}
+
+func (info *routeInfo) capability(method string) MethodCapability {
+	mc := MethodCapability{
+		Method: method,
+		Name:   info.name,
+		Schema: info.schemaURL,
+		Meta:   maps.Clone(info.capabilities),
+	}
+	if q := info.queryAllowlist; q != nil {
+		mc.Query = &QueryCapability{Filters: q.Filters, Sort: q.Sort, MaxLimit: q.MaxLimit}
+	}
+	return mc
+}
+
+// serveCapabilities answers an OPTIONS request, it reports false if a route
+// handles OPTIONS itself or the path has no routes.
+func (v *Prouter) serveCapabilities(w http.ResponseWriter, r *http.Request) bool {
+	var match mux.RouteMatch
+	if v.router.Match(r, &match) {
+		return false
+	}
+
+	doc := Capabilities{Path: r.URL.Path}
+	for _, method := range discoveryMethods {
+		req := new(http.Request)
+		*req = *r
+		req.Method = method
+
+		match = mux.RouteMatch{}
+		if !v.router.Match(req, &match) {
+			continue
+		}
+		slot, ok := match.Handler.(*routeSlot)
+		if !ok || !slot.info.discoverable {
+			continue
+		}
+		if doc.Path == r.URL.Path {
+			doc.Path = slot.info.template
+		}
+		doc.Methods = append(doc.Methods, slot.info.capability(method))
+	}
+	if len(doc.Methods) == 0 {
+		return false
+	}
+
+	allow := make([]string, 0, len(doc.Methods)+1)
+	for _, mc := range doc.Methods {
+		allow = append(allow, mc.Method)
+	}
+	w.Header().Set("Allow", strings.Join(append(allow, http.MethodOptions), ", "))
+	_ = v.writeJSON(w, http.StatusOK, SuccessResponse(doc))
+	return true
+}
@@ -0,0 +1,50 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOptionsDiscoveryListsDiscoverableRoutesOnly(t *testing.T) {
+	router := New(WithOptionsDiscovery())
+	noop := func(*Context) (Response, error) { return nil, nil }
+
+	router.GET("/public", noop, WithDiscoverable(), WithCapability("rateLimit", "100/min"))
+	admin := router.Group("/admin", func(ctx *Context) (Response, error) {
+		return nil, MsgError(http.StatusUnauthorized, "unauthorized")
+	})
+	admin.GET("/users", noop, WithName("admin.users"), WithCapability("internal", true))
+	router.GET("/mixed", noop, WithDiscoverable())
+	router.DELETE("/mixed", noop, WithName("mixed.delete"))
+
+	tests := []struct {
+		path  string
+		code  int
+		allow string
+		leaks []string
+	}{
+		{"/public", http.StatusOK, "GET, OPTIONS", nil},
+		{"/admin/users", http.StatusMethodNotAllowed, "", []string{"admin.users", "internal"}},
+		{"/mixed", http.StatusOK, "GET, OPTIONS", []string{"mixed.delete"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, tt.path, nil))
+
+			if rec.Code != tt.code {
+				t.Errorf("code = %d, want %d", rec.Code, tt.code)
+			}
+			if got := rec.Header().Get("Allow"); tt.allow != "" && got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+			for _, leak := range tt.leaks {
+				if strings.Contains(rec.Body.String(), leak) {
+					t.Errorf("%s leaked in %s", leak, rec.Body)
+				}
+			}
+		})
+	}
+}
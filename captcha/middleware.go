@@ -0,0 +1,134 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const DefaultTokenHeader = "X-Captcha-Token"
+
+type verificationKey struct{}
+
+// Verifier checks the captcha token of requests before the handler runs.
+type Verifier struct {
+	provider Provider
+	minScore float64
+	action   string
+	header   string
+	routes   []string
+	failOpen bool
+}
+
+type Option func(*Verifier)
+
+// WithMinScore rejects tokens scored below min, tokens without score pass.
+func WithMinScore(min float64) Option {
+	return func(v *Verifier) {
+		v.minScore = min
+	}
+}
+
+// WithAction rejects tokens issued for another action of the widget.
+func WithAction(action string) Option {
+	return func(v *Verifier) {
+		v.action = action
+	}
+}
+
+// WithTokenHeader sets the header carrying the token of JSON requests, X-Captcha-Token by default.
+func WithTokenHeader(header string) Option {
+	return func(v *Verifier) {
+		v.header = header
+	}
+}
+
+// WithRoutes limits the verification to routes given as "METHOD /template",
+// e.g. "POST /signup", no routes verifies every route of the group.
+func WithRoutes(routes ...string) Option {
+	return func(v *Verifier) {
+		v.routes = append(v.routes, routes...)
+	}
+}
+
+// WithFailOpen lets requests in when the provider cannot be reached.
+func WithFailOpen() Option {
+	return func(v *Verifier) {
+		v.failOpen = true
+	}
+}
+
+func New(provider Provider, opts ...Option) *Verifier {
+	v := &Verifier{
+		provider: provider,
+		header:   DefaultTokenHeader,
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// FromContext returns the verification of the request, nil on routes not verified.
+func FromContext(ctx context.Context) *Verification {
+	ret, _ := ctx.Value(verificationKey{}).(*Verification)
+	return ret
+}
+
+func (v *Verifier) token(ctx *prouter.Context) string {
+	if token := ctx.Request.Header.Get(v.header); token != "" {
+		return token
+	}
+	return ctx.Request.FormValue(v.provider.TokenField())
+}
+
+func forbidden(msg string) error {
+	return prouter.MsgError(http.StatusForbidden, msg).
+		SetComponent(prouter.ErrProuter).
+		SetResponseType(prouter.Forbidden)
+}
+
+// Middleware verifies the token sent in the form field of the provider or the
+// token header, register it with rg.Use.
+func (v *Verifier) Middleware() prouter.HandleFunc {
+	return func(ctx *prouter.Context) (prouter.Response, error) {
+		route := ctx.Request.Method + " " + ctx.RouteTemplate()
+		if len(v.routes) > 0 && !slices.Contains(v.routes, route) {
+			return nil, nil
+		}
+
+		token := v.token(ctx)
+		if token == "" {
+			return nil, prouter.MsgError(http.StatusBadRequest, "captcha token missing").
+				SetComponent(prouter.ErrProuter).
+				SetResponseType(prouter.BadRequest)
+		}
+
+		ret, err := v.provider.Verify(ctx, token, ctx.ClientIp)
+		if err != nil {
+			plog.Errorc(ctx, "captcha verify error: %v", err)
+			if v.failOpen {
+				return nil, nil
+			}
+			return nil, prouter.MsgError(http.StatusServiceUnavailable, "captcha verification unavailable").
+				SetComponent(prouter.ErrProuter)
+		}
+
+		switch {
+		case !ret.Success:
+			return nil, forbidden("captcha verification failed")
+		case v.action != "" && ret.Action != v.action:
+			return nil, forbidden("captcha action mismatch")
+		case ret.HasScore && ret.Score < v.minScore:
+			return nil, forbidden("captcha score too low")
+		}
+
+		ctx.WithValue(verificationKey{}, ret)
+		return nil, nil
+	}
+}
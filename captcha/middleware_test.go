@@ -0,0 +1,143 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-puzzles/prouter"
+)
+
+// siteverify answers the tokens of the table, unknown tokens fail
+func newSiteVerify(t *testing.T, answers map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "secret" {
+			w.Write([]byte(`{"success":false,"error-codes":["invalid-input-secret"]}`))
+			return
+		}
+		token := r.FormValue("response")
+		if token == "unavailable" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		answer, ok := answers[token]
+		if !ok {
+			answer = `{"success":false,"error-codes":["invalid-input-response"]}`
+		}
+		w.Write([]byte(answer))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVerifier(t *testing.T) {
+	srv := newSiteVerify(t, map[string]string{
+		"human":  `{"success":true,"score":0.9,"action":"signup","hostname":"example.org","challenge_ts":"2024-01-01T00:00:00Z"}`,
+		"bot":    `{"success":true,"score":0.1,"action":"signup"}`,
+		"login":  `{"success":true,"score":0.9,"action":"login"}`,
+		"v2":     `{"success":true,"action":"signup"}`,
+		"reused": `{"success":false,"error-codes":["timeout-or-duplicate"]}`,
+	})
+	provider := &SiteVerify{URL: srv.URL, Secret: "secret", Field: "cf-turnstile-response"}
+
+	newRouter := func(opts ...Option) *prouter.Prouter {
+		router := prouter.New()
+		group := router.Group("", New(provider, opts...).Middleware())
+		handler := func(ctx *prouter.Context) (prouter.Response, error) {
+			ret := FromContext(ctx)
+			if ret == nil {
+				return prouter.SuccessResponse("unverified"), nil
+			}
+			return prouter.SuccessResponse(ret.Hostname), nil
+		}
+		group.POST("/signup", handler)
+		group.POST("/search", handler)
+		return router
+	}
+	strict := newRouter(WithMinScore(0.5), WithAction("signup"), WithRoutes("POST /signup"))
+	failOpen := newRouter(WithFailOpen())
+
+	tests := []struct {
+		name   string
+		router *prouter.Prouter
+		path   string
+		header string
+		form   string
+		code   int
+		data   string
+	}{
+		{"header token", strict, "/signup", "human", "", http.StatusOK, "example.org"},
+		{"form token", strict, "/signup", "", "human", http.StatusOK, "example.org"},
+		{"missing token", strict, "/signup", "", "", http.StatusBadRequest, ""},
+		{"failed", strict, "/signup", "reused", "", http.StatusForbidden, ""},
+		{"unknown token", strict, "/signup", "forged", "", http.StatusForbidden, ""},
+		{"low score", strict, "/signup", "bot", "", http.StatusForbidden, ""},
+		{"other action", strict, "/signup", "login", "", http.StatusForbidden, ""},
+		{"no score passes", strict, "/signup", "v2", "", http.StatusOK, ""},
+		{"route not verified", strict, "/search", "", "", http.StatusOK, "unverified"},
+		{"provider unavailable", strict, "/signup", "unavailable", "", http.StatusServiceUnavailable, ""},
+		{"provider unavailable fail open", failOpen, "/signup", "unavailable", "", http.StatusOK, "unverified"},
+		{"fail open still rejects", failOpen, "/signup", "reused", "", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.form != "" {
+				form.Set(provider.Field, tt.form)
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set(DefaultTokenHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Fatalf("code = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Data != tt.data {
+				t.Errorf("data = %q, want %q", body.Data, tt.data)
+			}
+		})
+	}
+}
+
+func TestSiteVerify(t *testing.T) {
+	srv := newSiteVerify(t, map[string]string{
+		"human": `{"success":true,"score":0.7,"action":"signup","challenge_ts":"2024-01-01T00:00:00Z"}`,
+	})
+
+	ret, err := (&SiteVerify{URL: srv.URL, Secret: "secret"}).Verify(context.Background(), "human", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ret.Success || !ret.HasScore || ret.Score != 0.7 || ret.Action != "signup" || ret.ChallengeTS.IsZero() {
+		t.Errorf("Verify = %+v", ret)
+	}
+
+	ret, err = (&SiteVerify{URL: srv.URL, Secret: "wrong"}).Verify(context.Background(), "human", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.Success || len(ret.ErrorCodes) != 1 || ret.ErrorCodes[0] != "invalid-input-secret" {
+		t.Errorf("Verify with wrong secret = %+v", ret)
+	}
+
+	if _, err := (&SiteVerify{URL: srv.URL, Secret: "secret"}).Verify(context.Background(), "unavailable", ""); err == nil {
+		t.Error("Verify with a failing provider: want error")
+	}
+}
@@ -0,0 +1,108 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Purger invalidates the cached responses tagged with keys by
+// prouter.WithSurrogateKeys or ctx.AddSurrogateKeys.
+type Purger interface {
+	PurgeKeys(ctx context.Context, keys ...string) error
+}
+
+type PurgerFunc func(ctx context.Context, keys ...string) error
+
+func (f PurgerFunc) PurgeKeys(ctx context.Context, keys ...string) error {
+	return f(ctx, keys...)
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("cdn: purge failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// Fastly purges by surrogate key through the Fastly API.
+type Fastly struct {
+	ServiceID string
+	Token     string
+	// Soft marks the content stale instead of removing it
+	Soft   bool
+	Client *http.Client
+}
+
+func (f *Fastly) PurgeKeys(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	url := "https://api.fastly.com/service/" + f.ServiceID + "/purge"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Fastly-Key", f.Token)
+	req.Header.Set("Surrogate-Key", strings.Join(keys, " "))
+	if f.Soft {
+		req.Header.Set("Fastly-Soft-Purge", "1")
+	}
+
+	client := f.Client
+	if client == nil {
+		client = defaultClient
+	}
+	return do(client, req)
+}
+
+// Cloudflare purges by cache tag through the Cloudflare API.
+type Cloudflare struct {
+	ZoneID string
+	Token  string
+	Client *http.Client
+}
+
+// cloudflareMaxTags is the number of tags a single purge request accepts
+const cloudflareMaxTags = 30
+
+func (c *Cloudflare) PurgeKeys(ctx context.Context, keys ...string) error {
+	client := c.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	for len(keys) > 0 {
+		batch := keys[:min(len(keys), cloudflareMaxTags)]
+		keys = keys[len(batch):]
+
+		body, _ := json.Marshal(map[string][]string{"tags": batch})
+		url := "https://api.cloudflare.com/client/v4/zones/" + c.ZoneID + "/purge_cache"
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		req.Header.Set("Content-Type", "application/json")
+
+		if err := do(client, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
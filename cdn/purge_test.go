@@ -0,0 +1,134 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// recorder answers every request with code and records it
+func recorder(code int, requests *[]*http.Request, bodies *[]string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		if r.Body != nil {
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+		}
+		*requests = append(*requests, r)
+		*bodies = append(*bodies, body)
+		return &http.Response{
+			StatusCode: code,
+			Body:       io.NopCloser(strings.NewReader(" denied \n")),
+			Request:    r,
+		}, nil
+	})}
+}
+
+func TestFastly(t *testing.T) {
+	tests := []struct {
+		name     string
+		soft     bool
+		keys     []string
+		code     int
+		requests int
+		err      string
+	}{
+		{"purge", false, []string{"product-1", "list"}, http.StatusOK, 1, ""},
+		{"soft purge", true, []string{"product-1"}, http.StatusOK, 1, ""},
+		{"no keys", false, nil, http.StatusOK, 0, ""},
+		{"api error", false, []string{"product-1"}, http.StatusForbidden, 1, "cdn: purge failed with status 403: denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				requests []*http.Request
+				bodies   []string
+			)
+			f := &Fastly{ServiceID: "svc", Token: "token", Soft: tt.soft, Client: recorder(tt.code, &requests, &bodies)}
+			err := f.PurgeKeys(context.Background(), tt.keys...)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("PurgeKeys = %v, want %q", err, tt.err)
+			}
+			if len(requests) != tt.requests {
+				t.Fatalf("requests = %d, want %d", len(requests), tt.requests)
+			}
+			if tt.requests == 0 {
+				return
+			}
+
+			r := requests[0]
+			if r.Method != http.MethodPost || r.URL.String() != "https://api.fastly.com/service/svc/purge" {
+				t.Errorf("request = %s %s", r.Method, r.URL)
+			}
+			if r.Header.Get("Fastly-Key") != "token" || r.Header.Get("Surrogate-Key") != strings.Join(tt.keys, " ") {
+				t.Errorf("headers = %v", r.Header)
+			}
+			if soft := r.Header.Get("Fastly-Soft-Purge") == "1"; soft != tt.soft {
+				t.Errorf("soft purge = %v, want %v", soft, tt.soft)
+			}
+		})
+	}
+}
+
+func TestCloudflare(t *testing.T) {
+	keys := make([]string, cloudflareMaxTags+5)
+	for i := range keys {
+		keys[i] = "tag-" + strconv.Itoa(i)
+	}
+
+	var (
+		requests []*http.Request
+		bodies   []string
+	)
+	c := &Cloudflare{ZoneID: "zone", Token: "token", Client: recorder(http.StatusOK, &requests, &bodies)}
+	if err := c.PurgeKeys(context.Background(), keys...); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tags are sent in batches of the API limit
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(requests))
+	}
+	var purged []string
+	for i, r := range requests {
+		if r.URL.String() != "https://api.cloudflare.com/client/v4/zones/zone/purge_cache" {
+			t.Errorf("url = %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal([]byte(bodies[i]), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Tags) > cloudflareMaxTags {
+			t.Errorf("batch %d has %d tags", i, len(body.Tags))
+		}
+		purged = append(purged, body.Tags...)
+	}
+	if strings.Join(purged, ",") != strings.Join(keys, ",") {
+		t.Errorf("purged %v, want %v", purged, keys)
+	}
+
+	// a failed batch stops the purge
+	requests, bodies = nil, nil
+	c.Client = recorder(http.StatusTooManyRequests, &requests, &bodies)
+	if err := c.PurgeKeys(context.Background(), keys...); err == nil {
+		t.Error("PurgeKeys with a failing API: want error")
+	}
+	if len(requests) != 1 {
+		t.Errorf("requests after a failure = %d, want 1", len(requests))
+	}
+}
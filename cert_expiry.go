@@ -0,0 +1,129 @@
+package prouter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const certCheckInterval = 12 * time.Hour
+
+// CertStatus is the expiry of a certificate served by RunTLS.
+type CertStatus struct {
+	Subject   string
+	DNSNames  []string
+	NotAfter  time.Time
+	Remaining time.Duration
+}
+
+type CertExpiryFunc func(status CertStatus)
+
+type certMonitor struct {
+	before time.Duration
+	alert  CertExpiryFunc
+
+	mu    sync.Mutex
+	certs []*x509.Certificate
+}
+
+// WithCertExpiryAlert checks the certificates served by RunTLS twice a day and
+// calls fn for each one expiring within before, e.g. 14 days. Expiring
+// certificates are logged as well, fn may be nil to only log them.
+func WithCertExpiryAlert(before time.Duration, fn CertExpiryFunc) RouterOption {
+	return func(v *Prouter) {
+		v.certs.before = before
+		v.certs.alert = fn
+	}
+}
+
+func (m *certMonitor) add(cert *x509.Certificate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.certs = append(m.certs, cert)
+}
+
+func (m *certMonitor) status(now time.Time) []CertStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := make([]CertStatus, 0, len(m.certs))
+	for _, c := range m.certs {
+		ret = append(ret, CertStatus{
+			Subject:   c.Subject.String(),
+			DNSNames:  c.DNSNames,
+			NotAfter:  c.NotAfter,
+			Remaining: c.NotAfter.Sub(now),
+		})
+	}
+	return ret
+}
+
+func (m *certMonitor) check(now time.Time) {
+	for _, st := range m.status(now) {
+		if st.Remaining > m.before {
+			continue
+		}
+		plog.Warnf("tls certificate %s expires at %s, in %s", st.Subject, st.NotAfter.Format(time.RFC3339), st.Remaining.Round(time.Minute))
+		if m.alert != nil {
+			m.alert(st)
+		}
+	}
+}
+
+// run checks the certificates until ctx is done, the remaining time is taken
+// from now, the clock of the router.
+func (m *certMonitor) run(ctx context.Context, now func() time.Time) {
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		m.check(now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// serveCertificate loads the key pair of RunTLS into cfg so the certificate
+// checked is the one served.
+func (v *Prouter) serveCertificate(cfg *tls.Config, certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	cfg.Certificates = append(cfg.Certificates, cert)
+
+	v.certs.add(cert.Leaf)
+	if v.certs.before > 0 {
+		v.Background("tls-cert-expiry", func(ctx context.Context) {
+			v.certs.run(ctx, v.now)
+		})
+	}
+	return nil
+}
+
+// CertStatus returns the expiry of the certificates served by RunTLS, the
+// remaining time is measured by the router clock.
+func (v *Prouter) CertStatus() []CertStatus {
+	return v.certs.status(v.now())
+}
+
+func (v *Prouter) writeCertExpiry(b *strings.Builder) {
+	certs := v.CertStatus()
+	if len(certs) == 0 {
+		return
+	}
+
+	b.WriteString("# HELP prouter_tls_cert_expiry_seconds Seconds until the served certificate expires.\n# TYPE prouter_tls_cert_expiry_seconds gauge\n")
+	for _, c := range certs {
+		fmt.Fprintf(b, "prouter_tls_cert_expiry_seconds{subject=\"%s\"} %d\n", promLabelReplacer.Replace(c.Subject), int64(c.Remaining.Seconds()))
+	}
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-puzzles/prouter"
+)
+
+// BaggageTransport forwards the baggage of the request context, e.g. the
+// prouter.Context of the handler, on every request sent by base,
+// http.DefaultTransport if nil. Members already set on the request win.
+func BaggageTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		b := prouter.BaggageFromContext(r.Context())
+		if b.Len() == 0 {
+			return base.RoundTrip(r)
+		}
+
+		r = r.Clone(r.Context())
+		existing := prouter.ParseBaggage(strings.Join(r.Header.Values(prouter.BaggageHeader), ","))
+		merged := (&prouter.Baggage{}).Merge(b).Merge(existing)
+		r.Header.Set(prouter.BaggageHeader, merged.String())
+		return base.RoundTrip(r)
+	})
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func TestBaggageTransport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(prouter.BaggageHeader)))
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: BaggageTransport(nil)}
+
+	router := prouter.New()
+	router.GET("/", func(ctx *prouter.Context) (prouter.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if member := ctx.Request.URL.Query().Get("set"); member != "" {
+			req.Header.Set(prouter.BaggageHeader, member)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		forwarded, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return prouter.SuccessResponse(prouter.ParseBaggage(string(forwarded)).Members()), nil
+	})
+
+	tests := []struct {
+		name    string
+		baggage string
+		target  string
+		want    map[string]string
+	}{
+		{"no baggage", "", "/", map[string]string{}},
+		{"forwarded", "tenant=acme,user=1", "/", map[string]string{"tenant": "acme", "user": "1"}},
+		{"set on the request wins", "tenant=acme,user=1", "/?set=user%3D2", map[string]string{"tenant": "acme", "user": "2"}},
+		{"set without incoming baggage", "", "/?set=user%3D2", map[string]string{"user": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.baggage != "" {
+				req.Header.Set(prouter.BaggageHeader, tt.baggage)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("code = %d: %s", rec.Code, rec.Body)
+			}
+
+			var body struct {
+				Data []prouter.BaggageMember `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, m := range body.Data {
+				got[m.Key] = m.Value
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("forwarded %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("forwarded %s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestBaggageTransportKeepsRequest(t *testing.T) {
+	var sent *http.Request
+	transport := BaggageTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	}))
+
+	ctx := prouter.ContextWithBaggage(context.Background(), (&prouter.Baggage{}).Set("tenant", "acme"))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://upstream/", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := sent.Header.Get(prouter.BaggageHeader); got != "tenant=acme" {
+		t.Errorf("sent baggage = %q, want tenant=acme", got)
+	}
+	if got := req.Header.Get(prouter.BaggageHeader); got != "" {
+		t.Errorf("caller request modified: baggage = %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+)
+
+// Signer signs outgoing requests for prouter.HMACAuthMiddleware.
+type Signer struct {
+	keyID   string
+	secret  []byte
+	headers []string
+	now     func() time.Time
+}
+
+type SignerOption func(*Signer)
+
+// WithSignedHeaders adds headers to the signature, host, x-date and x-nonce are always signed.
+func WithSignedHeaders(headers ...string) SignerOption {
+	return func(s *Signer) {
+		for _, h := range headers {
+			h = strings.ToLower(h)
+			if !slices.Contains(s.headers, h) {
+				s.headers = append(s.headers, h)
+			}
+		}
+	}
+}
+
+func NewSigner(keyID string, secret []byte, opts ...SignerOption) *Signer {
+	s := &Signer{
+		keyID:   keyID,
+		secret:  secret,
+		headers: []string{"host", "x-date", "x-nonce"},
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	slices.Sort(s.headers)
+	return s
+}
+
+// Sign sets the date, nonce, body hash and Authorization headers of r, the
+// body is read and replaced so it can still be sent. Each call signs a new
+// nonce, a request sent again must be signed again.
+func (s *Signer) Sign(r *http.Request) error {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	if r.Host == "" {
+		r.Host = r.URL.Host
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	date := s.now().UTC().Format(prouter.HMACDateFormat)
+	bodyHash := prouter.HashBody(body)
+	r.Header.Set(prouter.HMACDateHeader, date)
+	r.Header.Set(prouter.HMACNonceHeader, hex.EncodeToString(nonce))
+	r.Header.Set(prouter.HMACBodyHashHeader, bodyHash)
+
+	signature := prouter.HMACSignature(s.secret, date, prouter.CanonicalRequest(r, s.headers, bodyHash))
+	r.Header.Set("Authorization", prouter.HMACAlgorithm+" KeyId="+s.keyID+
+		", SignedHeaders="+strings.Join(s.headers, ";")+", Signature="+signature)
+	return nil
+}
+
+// Transport signs every request before it is sent by base, http.DefaultTransport if nil:
+//
+//	client := &http.Client{Transport: signer.Transport(nil)}
+func (s *Signer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		if err := s.Sign(r); err != nil {
+			return nil, err
+		}
+		return base.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func TestSignerTransport(t *testing.T) {
+	router := prouter.New()
+	router.UseMiddleware(prouter.NewHMACAuthMiddleware(prouter.HMACKeys{"svc": []byte("secret")}))
+	router.POST("/orders", func(ctx *prouter.Context) (prouter.Response, error) {
+		body, err := ctx.BodyBytes()
+		if err != nil {
+			return nil, err
+		}
+		return prouter.SuccessResponse(prouter.HMACKeyID(ctx) + ":" + string(body)), nil
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	signer := NewSigner("svc", []byte("secret"), WithSignedHeaders("X-Tenant"))
+	var tamper func(r *http.Request)
+	signed := &http.Client{Transport: signer.Transport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if tamper != nil {
+			tamper(r)
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	}))}
+
+	post := func(body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/orders?ref=1", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Tenant", "acme")
+		resp, err := signed.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		if req.Header.Get("Authorization") != "" {
+			t.Error("Transport signed the request of the caller")
+		}
+		return resp.StatusCode, string(data)
+	}
+
+	// each request is signed with a new nonce, sending the same one twice is not a replay
+	for i := 0; i < 2; i++ {
+		code, body := post(`{"id":1}`)
+		if code != http.StatusOK || !strings.Contains(body, `svc:{\"id\":1}`) {
+			t.Fatalf("request %d: %d %s", i, code, body)
+		}
+	}
+
+	tamper = func(r *http.Request) { r.Header.Set("X-Tenant", "other") }
+	if code, body := post(`{"id":1}`); code != http.StatusUnauthorized {
+		t.Errorf("tampered signed header: %d %s", code, body)
+	}
+}
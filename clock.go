@@ -0,0 +1,145 @@
+package prouter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the router: request start and latency, cache and
+// token expiry. Tests replace it with a FakeClock to move time explicitly. A
+// Clock with an After method like the one of FakeClock also runs the timeouts of
+// the router, e.g. the drain timeout.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the wall clock, the default.
+var SystemClock Clock = systemClock{}
+
+// WithClock replaces the clock of the router.
+func WithClock(c Clock) RouterOption {
+	return func(v *Prouter) {
+		v.clock = c
+	}
+}
+
+func clockNow(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+func (v *Prouter) now() time.Time {
+	return clockNow(v.clock)
+}
+
+// clockAfter is time.After on the clock c, for a FakeClock it fires once the
+// clock is moved past d.
+func clockAfter(c Clock, d time.Duration) <-chan time.Time {
+	if t, ok := c.(interface {
+		After(d time.Duration) <-chan time.Time
+	}); ok {
+		return t.After(d)
+	}
+	return time.After(d)
+}
+
+// Now is the current time of the router clock.
+func (c *Context) Now() time.Time {
+	if c.router == nil {
+		return time.Now()
+	}
+	return c.router.now()
+}
+
+// clockKey is answered by the Value of a Context with its router, so the
+// router clock reaches stores which only get a context.Context, also one
+// detached by context.WithoutCancel.
+type clockKey struct{}
+
+// contextNow is the router clock of the request of ctx, the wall clock
+// outside of a request.
+func contextNow(ctx context.Context) time.Time {
+	if ctx != nil {
+		if v, ok := ctx.Value(clockKey{}).(*Prouter); ok && v != nil {
+			return v.now()
+		}
+	}
+	return time.Now()
+}
+
+// FakeClock is a Clock which only moves by Advance and Set, for deterministic
+// tests of latency and expiry:
+//
+//	clock := prouter.NewFakeClock(time.Now())
+//	router := prouter.New(prouter.WithClock(clock))
+//	url, _ := router.SignedURL("download", nil, time.Minute)
+//	clock.Advance(2 * time.Minute)
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// After returns a channel receiving the time of the clock once it is moved d
+// past the current time, the router waits on it instead of time.After, e.g.
+// for the drain timeout of RemoveRoute.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	c.fire()
+	return t.c
+}
+
+// fire must be called with c.mu held
+func (c *FakeClock) fire() {
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if c.now.Before(t.at) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+}
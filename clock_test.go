@@ -0,0 +1,91 @@
+package prouter
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouterClockExpiresMemoryCache(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock))
+	router.WithCache(NewMemoryCache())
+
+	calls := 0
+	router.GET("/", func(ctx *Context) (Response, error) {
+		_, err := CachedAs(ctx, "key", time.Minute, func(context.Context) (int, error) {
+			calls++
+			return calls, nil
+		})
+		return nil, err
+	})
+
+	tests := []struct {
+		advance time.Duration
+		calls   int
+	}{
+		{0, 1},
+		{30 * time.Second, 1},
+		{30 * time.Second, 1},
+		{time.Second, 2},
+		{59 * time.Second, 2},
+	}
+	for i, tt := range tests {
+		clock.Advance(tt.advance)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if calls != tt.calls {
+			t.Errorf("step %d: fn ran %d times, want %d", i, calls, tt.calls)
+		}
+	}
+}
+
+func TestRouterClockMovesTheSLOWindow(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock))
+	m := NewMetricsMiddleware(WithSLOWindow(time.Hour))
+	router.UseMiddleware(m)
+	router.GET("/", func(*Context) (Response, error) { return nil, nil }, WithSLO(time.Second, 0.01))
+
+	tests := []struct {
+		advance  time.Duration
+		requests uint64
+	}{
+		{0, 1},
+		{30 * time.Minute, 2},
+		{2 * time.Hour, 1},
+	}
+	for i, tt := range tests {
+		clock.Advance(tt.advance)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		st := m.Snapshot()
+		if len(st) != 1 || st[0].Requests != tt.requests {
+			t.Errorf("step %d: snapshot = %+v, want %d requests", i, st, tt.requests)
+		}
+	}
+}
+
+func TestCertStatusUsesRouterClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	router := New(WithClock(clock))
+	router.certs.add(&x509.Certificate{NotAfter: start.Add(48 * time.Hour)})
+
+	tests := []struct {
+		advance   time.Duration
+		remaining time.Duration
+	}{
+		{0, 48 * time.Hour},
+		{24 * time.Hour, 24 * time.Hour},
+		{36 * time.Hour, -12 * time.Hour},
+	}
+	for i, tt := range tests {
+		clock.Advance(tt.advance)
+		st := router.CertStatus()
+		if len(st) != 1 || st[0].Remaining != tt.remaining {
+			t.Errorf("step %d: status = %+v, want %v remaining", i, st, tt.remaining)
+		}
+	}
+}
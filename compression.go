@@ -0,0 +1,284 @@
+package prouter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultCompressionMinLength = 1024
+
+// compressedContentTypes are not worth compressing again
+var compressedContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/x-bzip2", "application/x-7z-compressed", "application/x-rar-compressed",
+	"application/zstd", "application/wasm",
+	"text/event-stream",
+}
+
+// routeCompression is the per route override of the CompressionMiddleware
+type routeCompression struct {
+	disabled  bool
+	minLength int
+}
+
+// WithoutCompression disables response compression for the route,
+// e.g. for SSE, WebSocket or pre-compressed file responses.
+func WithoutCompression() RouteOption {
+	return func(c *routeConfig) {
+		c.compression.disabled = true
+	}
+}
+
+// WithCompressionMinLength overrides the minimum response size to compress for the route.
+func WithCompressionMinLength(n int) RouteOption {
+	return func(c *routeConfig) {
+		c.compression.minLength = n
+	}
+}
+
+type CompressionMiddleware struct {
+	level         int
+	minLength     int
+	excludedTypes []string
+	pool          sync.Pool
+}
+
+type CompressionOption func(*CompressionMiddleware)
+
+func WithCompressionLevel(level int) CompressionOption {
+	return func(m *CompressionMiddleware) {
+		m.level = level
+	}
+}
+
+// WithMinLength sets the minimum response size to compress, smaller responses are sent as is.
+func WithMinLength(n int) CompressionOption {
+	return func(m *CompressionMiddleware) {
+		m.minLength = n
+	}
+}
+
+// WithExcludedContentTypes adds content type prefixes which are never compressed.
+func WithExcludedContentTypes(types ...string) CompressionOption {
+	return func(m *CompressionMiddleware) {
+		m.excludedTypes = append(m.excludedTypes, types...)
+	}
+}
+
+func NewCompressionMiddleware(opts ...CompressionOption) *CompressionMiddleware {
+	m := &CompressionMiddleware{
+		level:         gzip.DefaultCompression,
+		minLength:     defaultCompressionMinLength,
+		excludedTypes: append([]string(nil), compressedContentTypes...),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.pool.New = func() any {
+		gz, err := gzip.NewWriterLevel(nil, m.level)
+		if err != nil {
+			gz = gzip.NewWriter(nil)
+		}
+		return gz
+	}
+
+	return m
+}
+
+func (m *CompressionMiddleware) acceptable(r *http.Request) bool {
+	if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CompressionMiddleware) excluded(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	for _, t := range m.excludedTypes {
+		if strings.HasPrefix(ct, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CompressionMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		minLength := m.minLength
+		if ctx.route != nil {
+			if ctx.route.compression.disabled {
+				return handler.Handle(ctx)
+			}
+			if ctx.route.compression.minLength > 0 {
+				minLength = ctx.route.compression.minLength
+			}
+		}
+
+		ctx.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !m.acceptable(ctx.Request) {
+			return handler.Handle(ctx)
+		}
+
+		gw := &gzipResponseWriter{
+			ResponseWriter: ctx.Writer.ResponseWriter,
+			m:              m,
+			minLength:      minLength,
+		}
+		ctx.Writer.ResponseWriter = gw
+		ctx.Writer.OnFinish(gw.Close)
+
+		return handler.Handle(ctx)
+	})
+}
+
+// gzipResponseWriter buffers the first minLength bytes to decide whether the
+// response is worth compressing, then either streams it through gzip or
+// writes it unchanged.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	m         *CompressionMiddleware
+	minLength int
+
+	status  int
+	decided bool
+	gz      *gzip.Writer
+	buf     bytes.Buffer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		if !w.decided {
+			w.status = code
+		}
+		return
+	}
+	w.status = code
+}
+
+func (w *gzipResponseWriter) shouldCompress() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" || w.status == http.StatusPartialContent {
+		return false
+	}
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if w.m.excluded(h.Get("Content-Type")) {
+		return false
+	}
+	if cl := h.Get("Content-Length"); cl != "" {
+		if n, err := strconv.Atoi(cl); err == nil && n < w.minLength {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *gzipResponseWriter) decide(compress bool) {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if compress {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		w.gz = w.m.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", http.DetectContentType(p))
+	}
+	if !w.shouldCompress() {
+		w.decide(false)
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf.Write(p)
+	if w.buf.Len() < w.minLength {
+		return len(p), nil
+	}
+
+	w.decide(true)
+	if _, err := w.gz.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(p), nil
+}
+
+// Flush forces the pending decision so streamed responses reach the client.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		compress := w.buf.Len() > 0 && w.shouldCompress()
+		w.decide(compress)
+		if w.buf.Len() > 0 {
+			if w.gz != nil {
+				_, _ = w.gz.Write(w.buf.Bytes())
+			} else {
+				_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+			}
+			w.buf.Reset()
+		}
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *gzipResponseWriter) Close() {
+	if !w.decided {
+		if w.status == 0 && w.buf.Len() == 0 {
+			return
+		}
+		w.decide(false)
+		if w.buf.Len() > 0 {
+			_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+			w.buf.Reset()
+		}
+		return
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		w.m.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
@@ -0,0 +1,51 @@
+package prouter
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressionResponseController(t *testing.T) {
+	router := New()
+	router.UseMiddleware(NewCompressionMiddleware(WithMinLength(16)))
+	router.GET("/stream", func(ctx *Context) (Response, error) {
+		rc := http.NewResponseController(ctx.Writer)
+		if err := rc.SetWriteDeadline(time.Now().Add(time.Minute)); err != nil {
+			t.Errorf("SetWriteDeadline: %v", err)
+		}
+		ctx.Writer.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(ctx.Writer, strings.Repeat("chunk ", 8))
+		if err := rc.Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+		return nil, nil
+	})
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(gz)
+	if string(body) != strings.Repeat("chunk ", 8) {
+		t.Errorf("body = %q", body)
+	}
+}
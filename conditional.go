@@ -0,0 +1,138 @@
+package prouter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DeltaTokenParam = "delta"
+
+// DeltaToken is the opaque sync position of a collection, the client sends back
+// the token of its last sync to receive only the items changed after it.
+type DeltaToken struct {
+	Since time.Time
+	// ID breaks ties between items changed at the same time
+	ID string
+}
+
+func NewDeltaToken(since time.Time, id string) DeltaToken {
+	return DeltaToken{Since: since, ID: id}
+}
+
+func (t DeltaToken) String() string {
+	raw := strconv.FormatInt(t.Since.UnixNano(), 10) + ":" + t.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseDeltaToken(s string) (DeltaToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return DeltaToken{}, fmt.Errorf("invalid delta token: %w", err)
+	}
+
+	nanos, id, _ := strings.Cut(string(raw), ":")
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return DeltaToken{}, fmt.Errorf("invalid delta token: %w", err)
+	}
+	return DeltaToken{Since: time.Unix(0, n), ID: id}, nil
+}
+
+// DeltaToken returns the token of the delta query parameter, ok is false on a full sync.
+func (c *Context) DeltaToken() (token DeltaToken, ok bool, err error) {
+	s := c.Query(DeltaTokenParam)
+	if s == "" {
+		return DeltaToken{}, false, nil
+	}
+
+	token, err = ParseDeltaToken(s)
+	if err != nil {
+		return DeltaToken{}, false, NewErr(http.StatusBadRequest, err, "invalid delta token").
+			SetComponent(ErrProuter).
+			SetResponseType(BadRequest)
+	}
+	return token, true, nil
+}
+
+func (c *Context) conditional() bool {
+	return c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+}
+
+func (c *Context) writeNotModified() {
+	h := c.Writer.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	c.Writer.WriteHeader(http.StatusNotModified)
+}
+
+// NotModifiedSince sets Last-Modified and answers 304 when the client copy is
+// not older than t, the handler then returns nil, nil:
+//
+//	if ctx.NotModifiedSince(updatedAt) {
+//		return nil, nil
+//	}
+func (c *Context) NotModifiedSince(t time.Time) bool {
+	t = t.Truncate(time.Second)
+	if !t.IsZero() {
+		c.Writer.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+	if !c.conditional() || t.IsZero() || c.Request.Header.Get("If-None-Match") != "" {
+		return false
+	}
+
+	since, err := http.ParseTime(c.Request.Header.Get("If-Modified-Since"))
+	if err != nil || t.After(since) {
+		return false
+	}
+
+	c.writeNotModified()
+	return true
+}
+
+func etagMatch(header, etag string) bool {
+	trim := func(s string) string {
+		return strings.TrimPrefix(strings.TrimSpace(s), "W/")
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || trim(candidate) == trim(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified sets the ETag and answers 304 when it matches If-None-Match, the
+// etag is quoted if it is not already.
+func (c *Context) NotModified(etag string) bool {
+	if !strings.HasSuffix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	c.Writer.Header().Set("ETag", etag)
+
+	inm := c.Request.Header.Get("If-None-Match")
+	if !c.conditional() || inm == "" || !etagMatch(inm, etag) {
+		return false
+	}
+
+	c.writeNotModified()
+	return true
+}
+
+// NotModifiedCollection short-circuits a list endpoint with 304 when neither the
+// newest change nor the number of items changed. It sets a weak ETag derived from
+// both and Last-Modified, the ETag takes precedence as in RFC 9110.
+func (c *Context) NotModifiedCollection(lastModified time.Time, count int) bool {
+	etag := fmt.Sprintf(`W/"%x-%x"`, lastModified.UnixNano(), count)
+	if !lastModified.IsZero() {
+		c.Writer.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if c.NotModified(etag) {
+		return true
+	}
+	return c.NotModifiedSince(lastModified)
+}
@@ -0,0 +1,398 @@
+package prouter
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/sessions"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// Duration is a time.Duration written as "30s" in config files, env and flags.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	parsed, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+type TimeoutConfig struct {
+	ReadHeader Duration `json:"readHeader" yaml:"readHeader"`
+	Read       Duration `json:"read" yaml:"read"`
+	Write      Duration `json:"write" yaml:"write"`
+	Idle       Duration `json:"idle" yaml:"idle"`
+	// Drain bounds waiting for the requests of removed and replaced routes
+	Drain Duration `json:"drain" yaml:"drain"`
+}
+
+// SessionConfig enables the session middleware with a cookie store signed by Secret.
+type SessionConfig struct {
+	Key    string `json:"key" yaml:"key"`
+	Secret string `json:"secret" yaml:"secret"`
+	// MaxAge of the cookie in seconds, 30 days by default
+	MaxAge int  `json:"maxAge" yaml:"maxAge"`
+	Secure bool `json:"secure" yaml:"secure"`
+}
+
+// StaticMount serves the directory Root under Path, written as "path=root" in env and flags.
+type StaticMount struct {
+	Path string `json:"path" yaml:"path"`
+	Root string `json:"root" yaml:"root"`
+}
+
+func (m *StaticMount) parse(s string) error {
+	path, root, ok := strings.Cut(s, "=")
+	if !ok || path == "" || root == "" {
+		return fmt.Errorf("invalid static mount %q, want path=root", s)
+	}
+	m.Path, m.Root = path, root
+	return nil
+}
+
+type MetricsConfig struct {
+	// Enabled serves the router stats in the Prometheus format on Path
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Path    string `json:"path" yaml:"path"`
+	// SLO adds the metrics middleware, its series are served on Path as well
+	SLO bool `json:"slo" yaml:"slo"`
+	// Expvar publishes the stats in expvar under the name
+	Expvar string `json:"expvar" yaml:"expvar"`
+}
+
+// Config is the declarative setup of a router for NewFromConfig. Load it from a
+// file, then apply LoadEnv and the flags of RegisterFlags on top:
+//
+//	cfg, err := prouter.LoadConfigFile("router.yaml")
+//	err = cfg.LoadEnv("PROUTER")
+//	cfg.RegisterFlags(flag.CommandLine, "router.")
+//	flag.Parse()
+type Config struct {
+	// Addr is the listen address of RunConfig
+	Addr   string `json:"addr" yaml:"addr"`
+	Host   string `json:"host" yaml:"host"`
+	Scheme string `json:"scheme" yaml:"scheme"`
+	// Mode is debug, release or test, empty keeps the mode of PROUTER_MODE. It
+	// is set with SetMode, which is process wide, so it applies to every router.
+	Mode           string         `json:"mode" yaml:"mode"`
+	Timeouts       TimeoutConfig  `json:"timeouts" yaml:"timeouts"`
+	MaxConnections int            `json:"maxConnections" yaml:"maxConnections"`
+	TrustedProxies []string       `json:"trustedProxies" yaml:"trustedProxies"`
+	CORS           *CORSConfig    `json:"cors" yaml:"cors"`
+	Session        *SessionConfig `json:"session" yaml:"session"`
+	Static         []StaticMount  `json:"static" yaml:"static"`
+	Metrics        MetricsConfig  `json:"metrics" yaml:"metrics"`
+}
+
+// LoadConfigFile reads a JSON or, for .yaml and .yml files, a YAML config.
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	default:
+		err = json.Unmarshal(data, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("prouter: load config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// configField is a leaf of Config, get allocates the nil sections on the way to it
+type configField struct {
+	path []string
+	typ  reflect.Type
+	get  func() reflect.Value
+}
+
+func walkConfig(t reflect.Type, get func() reflect.Value, path []string, fn func(configField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], name)
+		fieldGet := func() reflect.Value { return get().Field(i) }
+
+		switch {
+		case f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct:
+			elem := f.Type.Elem()
+			walkConfig(elem, func() reflect.Value {
+				p := fieldGet()
+				if p.IsNil() {
+					p.Set(reflect.New(elem))
+				}
+				return p.Elem()
+			}, fieldPath, fn)
+		case f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(Duration(0)):
+			walkConfig(f.Type, fieldGet, fieldPath, fn)
+		default:
+			fn(configField{path: fieldPath, typ: f.Type, get: fieldGet})
+		}
+	}
+}
+
+func (c *Config) fields(fn func(configField)) {
+	walkConfig(reflect.TypeOf(c).Elem(), func() reflect.Value {
+		return reflect.ValueOf(c).Elem()
+	}, nil, fn)
+}
+
+func setConfigValue(v reflect.Value, s string) error {
+	switch p := v.Addr().Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return p.UnmarshalText([]byte(s))
+	case interface{ parse(string) error }:
+		return p.parse(s)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Slice:
+		items := strings.Split(s, ",")
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setConfigValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+// splitCamel splits maxConnections into max and connections
+func splitCamel(s string) []string {
+	var words []string
+	start := 0
+	for i, r := range s {
+		if i > 0 && unicode.IsUpper(r) {
+			words = append(words, strings.ToLower(s[start:i]))
+			start = i
+		}
+	}
+	return append(words, strings.ToLower(s[start:]))
+}
+
+func configName(path []string, sep, pathSep string) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = strings.Join(splitCamel(p), sep)
+	}
+	return strings.Join(parts, pathSep)
+}
+
+// LoadEnv overrides the config with the set env variables named prefix_SECTION_FIELD,
+// e.g. PROUTER_TIMEOUTS_READ_HEADER=5s. Lists are comma separated.
+func (c *Config) LoadEnv(prefix string) error {
+	var err error
+	c.fields(func(f configField) {
+		name := strings.ToUpper(configName(f.path, "_", "_"))
+		if prefix != "" {
+			name = strings.TrimSuffix(prefix, "_") + "_" + name
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := setConfigValue(f.get(), s); setErr != nil {
+			err = fmt.Errorf("prouter: env %s: %w", name, setErr)
+		}
+	})
+	return err
+}
+
+// RegisterFlags registers a flag per field named prefix + section.field, e.g.
+// -router.timeouts.read-header=5s, set flags override the config on parse.
+func (c *Config) RegisterFlags(fs *flag.FlagSet, prefix string) {
+	c.fields(func(f configField) {
+		name := prefix + configName(f.path, "-", ".")
+		set := func(s string) error { return setConfigValue(f.get(), s) }
+
+		if f.typ.Kind() == reflect.Bool {
+			fs.BoolFunc(name, "set "+name, set)
+			return
+		}
+		fs.Func(name, "set "+name, set)
+	})
+}
+
+// Validate reports the first invalid value of the config.
+func (c *Config) Validate() error {
+	if c.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+			return fmt.Errorf("prouter: invalid addr %q: %w", c.Addr, err)
+		}
+	}
+	if c.Mode != "" {
+		if _, err := ParseMode(c.Mode); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.TrustedProxies {
+		if net.ParseIP(p) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(p); err != nil {
+			return fmt.Errorf("prouter: invalid trusted proxy %q: %w", p, err)
+		}
+	}
+	if c.Session != nil && (c.Session.Key == "" || c.Session.Secret == "") {
+		return fmt.Errorf("prouter: session needs key and secret")
+	}
+	for _, m := range c.Static {
+		if m.Path == "" || m.Root == "" {
+			return fmt.Errorf("prouter: static mount needs path and root")
+		}
+	}
+	if c.CORS != nil {
+		if err := c.CORS.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) routerOptions() []RouterOption {
+	opts := []RouterOption{
+		WithReadHeaderTimeout(time.Duration(c.Timeouts.ReadHeader)),
+		WithReadTimeout(time.Duration(c.Timeouts.Read)),
+		WithWriteTimeout(time.Duration(c.Timeouts.Write)),
+		WithIdleTimeout(time.Duration(c.Timeouts.Idle)),
+		WithDrainTimeout(time.Duration(c.Timeouts.Drain)),
+		WithMaxConnections(c.MaxConnections),
+	}
+	if c.Host != "" {
+		opts = append(opts, WithHost(c.Host))
+	}
+	if c.Scheme != "" {
+		opts = append(opts, WithScheme(c.Scheme))
+	}
+	if len(c.TrustedProxies) > 0 {
+		opts = append(opts, WithTrustedProxies(c.TrustedProxies...))
+	}
+	if c.CORS != nil {
+		opts = append(opts, WithCORS(*c.CORS))
+	}
+	return opts
+}
+
+// NewFromConfig sets the mode if given and creates a router as NewProuter does with the
+// config applied, opts are applied after the config. The mode is process wide,
+// see SetMode, routers created before keep running but see the new mode too.
+func NewFromConfig(cfg *Config, opts ...RouterOption) (*Prouter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Mode != "" {
+		mode, _ := ParseMode(cfg.Mode)
+		SetMode(mode)
+	}
+
+	v := NewProuter(append(cfg.routerOptions(), opts...)...)
+
+	if s := cfg.Session; s != nil {
+		store := sessions.NewCookieStore([]byte(s.Secret))
+		if s.MaxAge > 0 {
+			store.MaxAge(s.MaxAge)
+		}
+		store.Options.Secure = s.Secure
+		store.Options.HttpOnly = true
+		v.UseMiddleware(NewSessionMiddleware(s.Key, store))
+	}
+
+	var slo *MetricsMiddleware
+	if cfg.Metrics.SLO {
+		slo = NewMetricsMiddleware()
+		v.UseMiddleware(slo)
+	}
+
+	for _, m := range cfg.Static {
+		v.Static(m.Path, m.Root)
+	}
+
+	if cfg.Metrics.Enabled {
+		path := cfg.Metrics.Path
+		if path == "" {
+			path = defaultMetricsPath
+		}
+		v.GET(path, func(ctx *Context) (Response, error) {
+			ctx.Writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			if err := v.WritePrometheus(ctx.Writer); err != nil {
+				return nil, err
+			}
+			if slo != nil {
+				if err := slo.WritePrometheus(ctx.Writer); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+	}
+	if cfg.Metrics.Expvar != "" {
+		v.PublishExpvar(cfg.Metrics.Expvar)
+	}
+
+	return v, nil
+}
+
+// RunConfig creates the router of cfg, lets setup register the routes on it
+// and serves it on cfg.Addr until the server stops:
+//
+//	err := prouter.RunConfig(cfg, func(v *prouter.Prouter) {
+//		v.GET("/ping", ping)
+//	})
+func RunConfig(cfg *Config, setup func(v *Prouter), opts ...RouterOption) error {
+	if cfg.Addr == "" {
+		return fmt.Errorf("prouter: config has no addr")
+	}
+	v, err := NewFromConfig(cfg, opts...)
+	if err != nil {
+		return err
+	}
+	if setup != nil {
+		setup(v)
+	}
+	return v.Run(cfg.Addr)
+}
@@ -0,0 +1,56 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewFromConfigRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		target string
+		code   int
+	}{
+		{"empty", Config{}, "http://api.example.com/ping", http.StatusOK},
+		{"scheme", Config{Scheme: "http"}, "http://api.example.com/ping", http.StatusOK},
+		{"scheme mismatch", Config{Scheme: "https"}, "http://api.example.com/ping", http.StatusNotFound},
+		{"host", Config{Host: "api.example.com"}, "http://api.example.com/ping", http.StatusOK},
+		{"host mismatch", Config{Host: "api.example.com"}, "http://www.example.com/ping", http.StatusNotFound},
+		{"host and scheme", Config{Host: "api.example.com", Scheme: "http"}, "http://api.example.com/ping", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewFromConfig(&tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.GET("/ping", func(*Context) (Response, error) {
+				return SuccessResponse("pong"), nil
+			})
+
+			rec := httptest.NewRecorder()
+			v.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.code {
+				t.Errorf("GET %s: code = %d, want %d: %s", tt.target, rec.Code, tt.code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRunConfigAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+	}{
+		{""},
+		{"8080"},
+	}
+	for _, tt := range tests {
+		called := false
+		err := RunConfig(&Config{Addr: tt.addr}, func(*Prouter) { called = true })
+		if err == nil || called {
+			t.Errorf("RunConfig(addr %q) = %v, setup called %v, want an error before setup", tt.addr, err, called)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package prouter
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const connRateSweep = time.Minute
+
+// WithConnRateLimit closes the connections of an ip opening more than perSecond
+// new connections per second, burst allows short spikes. It runs in the accept
+// loop before any request is read, so the ip is the peer of the socket, not a
+// client behind a trusted proxy. Combine it with WithMaxConnections to cap the
+// total.
+func WithConnRateLimit(perSecond float64, burst int) RouterOption {
+	return func(v *Prouter) {
+		v.conns.ratePerSecond = perSecond
+		v.conns.rateBurst = max(burst, 1)
+	}
+}
+
+type connBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type connRateListener struct {
+	net.Listener
+	tracker *connTracker
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*connBucket
+	lastSweep time.Time
+}
+
+func newConnRateListener(l net.Listener, t *connTracker, now func() time.Time) *connRateListener {
+	return &connRateListener{
+		Listener:  l,
+		tracker:   t,
+		now:       now,
+		buckets:   make(map[string]*connBucket),
+		lastSweep: now(),
+	}
+}
+
+func (l *connRateListener) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate, burst := l.tracker.ratePerSecond, float64(l.tracker.rateBurst)
+	if now.Sub(l.lastSweep) >= connRateSweep {
+		// a refilled bucket is the same as none
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*rate >= burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &connBucket{tokens: burst, last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *connRateListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := c.RemoteAddr().String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		if l.allow(ip, l.now()) {
+			return c, nil
+		}
+		l.tracker.rateLimited.Add(1)
+		_ = c.Close()
+	}
+}
+
+// RunListener serves on l, with the connection limits of the router applied,
+// e.g. for a listener from socket activation or a unix socket.
+func (v *Prouter) RunListener(l net.Listener) error {
+	l = v.LimitListener(l)
+	v.printBanner(l, false)
+	v.startBackground()
+	return v.trackServer(v.newServer(l.Addr().String())).Serve(l)
+}
@@ -0,0 +1,216 @@
+package prouter
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connTracker follows the connections of the server through their http.ConnState
+type connTracker struct {
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+	counts map[http.ConnState]int64
+
+	accepted   atomic.Uint64
+	closed     atomic.Uint64
+	hijacked   atomic.Uint64
+	limitWaits atomic.Uint64
+	// rateLimited counts the connections closed by WithConnRateLimit
+	rateLimited atomic.Uint64
+
+	max           int
+	ratePerSecond float64
+	rateBurst     int
+	hooks         []func(net.Conn, http.ConnState)
+
+	idleTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+}
+
+// WithMaxConnections caps the open connections of Run and RunTLS, further
+// connections wait in the accept backlog until one is closed instead of piling
+// up sockets.
+func WithMaxConnections(n int) RouterOption {
+	return func(v *Prouter) {
+		v.conns.max = n
+	}
+}
+
+// WithConnStateHook calls fn on every connection state change of the server.
+func WithConnStateHook(fn func(net.Conn, http.ConnState)) RouterOption {
+	return func(v *Prouter) {
+		v.conns.hooks = append(v.conns.hooks, fn)
+	}
+}
+
+// WithIdleTimeout closes keep-alive connections idle for d, shorter timeouts free
+// sockets faster under bursts of short lived clients.
+func WithIdleTimeout(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.conns.idleTimeout = d
+	}
+}
+
+func WithReadHeaderTimeout(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.conns.readHeaderTimeout = d
+	}
+}
+
+func WithReadTimeout(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.conns.readTimeout = d
+	}
+}
+
+// WithWriteTimeout bounds writing the response, streaming routes such as SSE
+// need it disabled.
+func WithWriteTimeout(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.conns.writeTimeout = d
+	}
+}
+
+// ConnState is the http.Server ConnState hook used by Run, set it on a custom
+// server to get the connections into Stats.
+func (v *Prouter) ConnState(c net.Conn, state http.ConnState) {
+	t := &v.conns
+	t.mu.Lock()
+	if t.states == nil {
+		t.states = make(map[net.Conn]http.ConnState)
+		t.counts = make(map[http.ConnState]int64)
+	}
+	if prev, ok := t.states[c]; ok {
+		t.counts[prev]--
+	}
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(t.states, c)
+	default:
+		t.states[c] = state
+		t.counts[state]++
+	}
+	t.mu.Unlock()
+
+	switch state {
+	case http.StateNew:
+		t.accepted.Add(1)
+	case http.StateClosed:
+		t.closed.Add(1)
+	case http.StateHijacked:
+		t.hijacked.Add(1)
+	}
+
+	for _, hook := range t.hooks {
+		hook(c, state)
+	}
+}
+
+// ConnectionStats counts the connections of the server by state.
+type ConnectionStats struct {
+	Open     int64  `json:"open"`
+	New      int64  `json:"new"`
+	Active   int64  `json:"active"`
+	Idle     int64  `json:"idle"`
+	Accepted uint64 `json:"accepted"`
+	Closed   uint64 `json:"closed"`
+	Hijacked uint64 `json:"hijacked"`
+	// LimitWaits counts how often the accept loop blocked at WithMaxConnections
+	LimitWaits uint64 `json:"limit_waits"`
+	// RateLimited counts the connections closed at WithConnRateLimit
+	RateLimited uint64 `json:"rate_limited"`
+	Max         int    `json:"max,omitempty"`
+}
+
+func (t *connTracker) snapshot() ConnectionStats {
+	t.mu.Lock()
+	st := ConnectionStats{
+		Open:   int64(len(t.states)),
+		New:    t.counts[http.StateNew],
+		Active: t.counts[http.StateActive],
+		Idle:   t.counts[http.StateIdle],
+	}
+	t.mu.Unlock()
+
+	st.Accepted = t.accepted.Load()
+	st.Closed = t.closed.Load()
+	st.Hijacked = t.hijacked.Load()
+	st.LimitWaits = t.limitWaits.Load()
+	st.RateLimited = t.rateLimited.Load()
+	st.Max = t.max
+	return st
+}
+
+// LimitListener caps the open connections accepted from l at WithMaxConnections
+// and their rate at WithConnRateLimit, l is returned as is without a limit.
+func (v *Prouter) LimitListener(l net.Listener) net.Listener {
+	if v.conns.ratePerSecond > 0 {
+		l = newConnRateListener(l, &v.conns, v.now)
+	}
+	if v.conns.max <= 0 {
+		return l
+	}
+	return &limitListener{Listener: l, sem: make(chan struct{}, v.conns.max), tracker: &v.conns}
+}
+
+type limitListener struct {
+	net.Listener
+	sem     chan struct{}
+	tracker *connTracker
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		l.tracker.limitWaits.Add(1)
+		l.sem <- struct{}{}
+	}
+
+	c, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitConn{Conn: c, release: func() { <-l.sem }}, nil
+}
+
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+func (v *Prouter) newServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           v,
+		ConnState:         v.ConnState,
+		IdleTimeout:       v.conns.idleTimeout,
+		ReadHeaderTimeout: v.conns.readHeaderTimeout,
+		ReadTimeout:       v.conns.readTimeout,
+		WriteTimeout:      v.conns.writeTimeout,
+	}
+}
+
+func (v *Prouter) listen(addr string) (net.Listener, error) {
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return v.LimitListener(l), nil
+}
@@ -11,12 +11,16 @@ package prouter
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
+	"os"
 	"time"
 
 	"github.com/go-puzzles/puzzles/plog"
+	"github.com/gorilla/mux"
 )
 
 type ContextKeyType int
@@ -29,7 +33,12 @@ const (
 type Context struct {
 	context.Context
 	router *Prouter
+	route  *routeInfo
 	vars   map[string]string
+	// varStore holds the path variables of routes with up to inlineVars of them
+	varStore   varStore
+	varsLoaded bool
+	params     map[string]any
 
 	Request  *http.Request
 	Writer   *ResponseWriter
@@ -37,7 +46,18 @@ type Context struct {
 	ClientIp string
 	Method   string
 
-	session *Session
+	session   *Session
+	affinity  *Affinity
+	querySpec *QuerySpec
+	body      *countingBody
+	bodyBuf   []byte
+	// bodySpool holds a request body over the threshold of WithBodySpool
+	bodySpool     *os.File
+	bodySpoolSize int64
+
+	query    url.Values
+	rawQuery string
+	form     *formResult
 
 	startTime time.Time
 }
@@ -46,8 +66,151 @@ func (c *Context) Ctx() context.Context {
 	return c.Context
 }
 
+const inlineVars = 8
+
+type varStore struct {
+	n      int
+	keys   [inlineVars]string
+	values [inlineVars]string
+}
+
+func (s *varStore) get(key string) string {
+	for i := range s.n {
+		if s.keys[i] == key {
+			return s.values[i]
+		}
+	}
+	return ""
+}
+
+// loadVars extracts the route variables on first use, routes without path,
+// host or query variables skip the lookup in the request
+func (c *Context) loadVars() {
+	if c.varsLoaded {
+		return
+	}
+	c.varsLoaded = true
+	if c.Request == nil || c.route != nil && !c.route.hasVars {
+		return
+	}
+
+	vars := mux.Vars(c.Request)
+	if len(vars) > inlineVars {
+		c.vars = vars
+		return
+	}
+	for k, v := range vars {
+		c.varStore.keys[c.varStore.n] = k
+		c.varStore.values[c.varStore.n] = v
+		c.varStore.n++
+	}
+}
+
 func (c *Context) Var(key string) string {
-	return c.vars[key]
+	c.loadVars()
+	if c.vars != nil {
+		return c.vars[key]
+	}
+	return c.varStore.get(key)
+}
+
+// Vars returns the path variables of the request, an empty map for routes
+// without. Prefer Var, which does not build the map for routes with up to 8
+// variables. The returned map is owned by the router and must not be modified.
+func (c *Context) Vars() map[string]string {
+	c.loadVars()
+	if c.vars == nil {
+		c.vars = make(map[string]string, c.varStore.n)
+		for i := range c.varStore.n {
+			c.vars[c.varStore.keys[i]] = c.varStore.values[i]
+		}
+	}
+	return c.vars
+}
+
+// Param returns the converted value of the path variable key,
+// or its raw string when the route declares no converter for it.
+func (c *Context) Param(key string) any {
+	if val, ok := c.params[key]; ok {
+		return val
+	}
+	return c.Var(key)
+}
+
+// RouteTemplate returns the path pattern of the matched route, e.g. /users/{id}.
+func (c *Context) RouteTemplate() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.template
+}
+
+// RouteName returns the name of the matched route given by WithName.
+func (c *Context) RouteName() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.name
+}
+
+// ParamPath returns the decoded value of a catch-all variable like {filepath:path}.
+func (c *Context) ParamPath(key string) string {
+	val := c.Var(key)
+	if c.router == nil || !c.router.encodedPath {
+		return val
+	}
+
+	decoded, err := url.PathUnescape(val)
+	if err != nil {
+		return val
+	}
+	return decoded
+}
+
+// QueryValues returns the parsed query of the request, it is parsed on first use
+// and again only when a middleware rewrote the raw query. The returned values are
+// shared by the request and must not be modified.
+func (c *Context) QueryValues() url.Values {
+	if c.query == nil || c.rawQuery != c.Request.URL.RawQuery {
+		c.rawQuery = c.Request.URL.RawQuery
+		c.query = c.Request.URL.Query()
+	}
+	return c.query
+}
+
+// Query returns the first value of the query parameter key.
+func (c *Context) Query(key string) string {
+	return c.QueryValues().Get(key)
+}
+
+// QueryAll returns all values of the query parameter key.
+func (c *Context) QueryAll(key string) []string {
+	return c.QueryValues()[key]
+}
+
+type formResult struct {
+	values url.Values
+	err    error
+}
+
+// Form returns the query and the urlencoded or multipart body of the request
+// merged like http.Request.Form, the body is parsed once.
+func (c *Context) Form() (url.Values, error) {
+	if c.form == nil {
+		c.rewindBody()
+		err := c.Request.ParseMultipartForm(defaultMultipartMemory)
+		if errors.Is(err, http.ErrNotMultipart) {
+			err = nil
+		}
+		c.form = &formResult{values: c.Request.Form, err: err}
+	}
+	return c.form.values, c.form.err
+}
+
+// FormValue returns the first value of key in Form, ignoring parse errors.
+func (c *Context) FormValue(key string) string {
+	values, _ := c.Form()
+	return values.Get(key)
 }
 
 func (c *Context) WithValue(key, val any) {
@@ -55,6 +218,9 @@ func (c *Context) WithValue(key, val any) {
 }
 
 func (c *Context) Value(key any) any {
+	if key == (clockKey{}) {
+		return c.router
+	}
 	return c.Context.Value(key)
 }
 
@@ -65,6 +231,11 @@ func (c *Context) Session() *Session {
 	return c.session
 }
 
+// Affinity returns the stickiness set by the AffinityMiddleware, nil if it is not used.
+func (c *Context) Affinity() *Affinity {
+	return c.affinity
+}
+
 func (c *Context) ExecuteTemplateFS(fs embed.FS, resource string, data any) (Response, error) {
 	tmpl, err := template.ParseFS(fs, resource)
 	if err != nil {
@@ -0,0 +1,61 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContextVars(t *testing.T) {
+	var many []string
+	for i := range inlineVars + 1 {
+		many = append(many, fmt.Sprintf("{v%d}", i))
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		opts     []RouteOption
+		target   string
+		vars     map[string]string
+	}{
+		{"no vars", "/health", nil, "/health", map[string]string{}},
+		{"inline", "/users/{id}/posts/{post}", nil, "/users/7/posts/42", map[string]string{"id": "7", "post": "42"}},
+		{"more than inline", "/" + strings.Join(many, "/"), nil, "/0/1/2/3/4/5/6/7/8",
+			map[string]string{"v0": "0", "v1": "1", "v2": "2", "v3": "3", "v4": "4", "v5": "5", "v6": "6", "v7": "7", "v8": "8"}},
+		{"query", "/items", []RouteOption{RequireQuery("page", "{page:[0-9]+}")}, "/items?page=3",
+			map[string]string{"page": "3"}},
+		{"host", "/items", []RouteOption{RequireHost("{sub}.example.com")}, "http://eu.example.com/items",
+			map[string]string{"sub": "eu"}},
+		{"path, host and query", "/items/{id}", []RouteOption{RequireHost("{sub}.example.com"), RequireQuery("page", "{page}")},
+			"http://eu.example.com/items/9?page=2", map[string]string{"sub": "eu", "id": "9", "page": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New()
+			served := false
+			router.GET(tt.template, func(ctx *Context) (Response, error) {
+				served = true
+				for k, want := range tt.vars {
+					if got := ctx.Var(k); got != want {
+						t.Errorf("Var(%s) = %q, want %q", k, got, want)
+					}
+				}
+				if got := ctx.Var("missing"); got != "" {
+					t.Errorf("Var(missing) = %q", got)
+				}
+				vars := ctx.Vars()
+				if vars == nil || len(vars) != len(tt.vars) {
+					t.Errorf("Vars() = %v, want %v", vars, tt.vars)
+				}
+				return nil, nil
+			}, tt.opts...)
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if !served {
+				t.Fatalf("GET %s was not routed to %s", tt.target, tt.template)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead,
+}
+
+// CORSConfig is the cross origin policy of the router. AllowOrigins accepts "*"
+// and a single wildcard per origin, e.g. "https://*.example.com". "*" cannot be
+// combined with AllowCredentials, which would let any site read credentialed
+// responses.
+type CORSConfig struct {
+	AllowOrigins []string `json:"allowOrigins" yaml:"allowOrigins"`
+	// AllowMethods defaults to GET, POST, PUT, PATCH, DELETE and HEAD
+	AllowMethods []string `json:"allowMethods" yaml:"allowMethods"`
+	// AllowHeaders defaults to the headers requested by the preflight
+	AllowHeaders     []string `json:"allowHeaders" yaml:"allowHeaders"`
+	ExposeHeaders    []string `json:"exposeHeaders" yaml:"exposeHeaders"`
+	AllowCredentials bool     `json:"allowCredentials" yaml:"allowCredentials"`
+	MaxAge           Duration `json:"maxAge" yaml:"maxAge"`
+}
+
+var errCORSWildcardCredentials = errors.New(`prouter: cors: AllowOrigins "*" cannot be combined with AllowCredentials`)
+
+// Validate reports a policy allowing credentials for any origin.
+func (c *CORSConfig) Validate() error {
+	if c.AllowCredentials && slices.Contains(c.AllowOrigins, "*") {
+		return errCORSWildcardCredentials
+	}
+	return nil
+}
+
+// WithCORS answers preflight requests before routing and sets the CORS headers
+// of allowed origins, so routes need no OPTIONS handler. It panics on a policy
+// which fails Validate.
+func WithCORS(cfg CORSConfig) RouterOption {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	return func(v *Prouter) {
+		v.cors = &cfg
+	}
+}
+
+// WithRouteCORS overrides the policy of WithCORS for the route, e.g. for a public
+// widget embedded by any site. AllowOrigins and AllowCredentials are taken from
+// cfg, its other empty fields are inherited from the router policy. Preflights
+// are answered by the policy of the route matching their requested method.
+func WithRouteCORS(cfg CORSConfig) RouteOption {
+	return func(c *routeConfig) {
+		c.cors = &cfg
+	}
+}
+
+// merge returns the policy of a route overriding c
+func (c *CORSConfig) merge(route *CORSConfig) (*CORSConfig, error) {
+	if err := route.Validate(); err != nil {
+		return nil, err
+	}
+	ret := *route
+	if c == nil {
+		return &ret, nil
+	}
+	if len(ret.AllowMethods) == 0 {
+		ret.AllowMethods = c.AllowMethods
+	}
+	if len(ret.AllowHeaders) == 0 {
+		ret.AllowHeaders = c.AllowHeaders
+	}
+	if len(ret.ExposeHeaders) == 0 {
+		ret.ExposeHeaders = c.ExposeHeaders
+	}
+	if ret.MaxAge == 0 {
+		ret.MaxAge = c.MaxAge
+	}
+	return &ret, nil
+}
+
+// corsFor returns the policy of the route r is for, the router policy if the
+// route has no override
+func (v *Prouter) corsFor(r *http.Request) *CORSConfig {
+	if !v.routeCORS || r.Header.Get("Origin") == "" {
+		return v.cors
+	}
+
+	req := r
+	if method := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && method != "" {
+		req = new(http.Request)
+		*req = *r
+		req.Method = method
+	}
+	var match mux.RouteMatch
+	if v.router.Match(req, &match) {
+		if slot, ok := match.Handler.(*routeSlot); ok && slot.info.cors != nil {
+			return slot.info.cors
+		}
+	}
+	return v.cors
+}
+
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return strings.EqualFold(pattern, origin)
+	}
+	return len(origin) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) &&
+		strings.HasSuffix(origin, suffix)
+}
+
+func (c *CORSConfig) allowed(origin string) bool {
+	return slices.ContainsFunc(c.AllowOrigins, func(pattern string) bool {
+		return matchOrigin(pattern, origin)
+	})
+}
+
+// handle sets the CORS headers, it reports true when the preflight was answered.
+func (c *CORSConfig) handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	if !c.allowed(origin) {
+		return false
+	}
+
+	if slices.Contains(c.AllowOrigins, "*") {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		if len(c.ExposeHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+		}
+		return false
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	methods := c.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(c.AllowHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowHeaders, ", "))
+	} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(time.Duration(c.MaxAge)/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,71 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSRejectsWildcardWithCredentials(t *testing.T) {
+	wildcard := CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}
+
+	tests := []struct {
+		name string
+		run  func()
+	}{
+		{"WithCORS", func() { WithCORS(wildcard) }},
+		{"WithRouteCORS", func() {
+			router := New(WithCORS(CORSConfig{AllowOrigins: []string{"https://app.example.com"}}))
+			router.GET("/widget", func(*Context) (Response, error) { return nil, nil }, WithRouteCORS(wildcard))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			tt.run()
+		})
+	}
+
+	cfg := &Config{CORS: &wildcard}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Config.Validate accepted a wildcard origin with credentials")
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         CORSConfig
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"wildcard", CORSConfig{AllowOrigins: []string{"*"}}, "https://evil.example", "*", ""},
+		{"credentials", CORSConfig{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+			"https://app.example.com", "https://app.example.com", "true"},
+		{"credentials other origin", CORSConfig{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+			"https://evil.example", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New(WithCORS(tt.cfg))
+			router.GET("/", func(*Context) (Response, error) { return nil, nil })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+// Package prouter is an HTTP router on top of gorilla/mux whose handlers return
+// a Response and an error.
+//
+// # Migrating
+//
+// ServeHandler returns an http.Handler, the router itself, instead of the
+// *mux.Router. Serving the mux router skipped the handling of ServeHTTP such as
+// CORS preflights and the shutdown gate. Callers which need the mux router
+// itself use MuxRouter:
+//
+//	srv := &http.Server{Handler: router.ServeHandler()}
+//	mr := router.MuxRouter()
+//
+// RouteOption is no longer a func(*mux.Route) *mux.Route, wrap such functions
+// with MuxOption. OptRoute returns its options from Options instead of Option.
+package prouter
@@ -0,0 +1,208 @@
+package prouter
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+//go:embed assets/docs
+var docsAssets embed.FS
+
+// docsBundleFS holds the vendored UI bundles, see `make docs-assets`
+var docsBundleFS fs.FS = docsAssets
+
+var docsTemplates = template.Must(template.ParseFS(docsAssets, "assets/docs/*.html"))
+
+type DocsUI string
+
+const (
+	SwaggerUI DocsUI = "swagger"
+	Redoc     DocsUI = "redoc"
+)
+
+// The pinned versions of the UI bundles, keep them in sync with the Makefile.
+const (
+	swaggerUIVersion = "5.17.14"
+	redocVersion     = "2.1.5"
+)
+
+// docsBundle is a UI vendored under assets/docs/vendor/<name>, served by
+// ServeDocs under <prefix>/assets/<name>.
+type docsBundle struct {
+	name  string
+	files []string
+	// cdn serves the same version when the bundle is not vendored
+	cdn string
+}
+
+var docsBundles = map[DocsUI]docsBundle{
+	SwaggerUI: {
+		name:  "swagger-ui-dist@" + swaggerUIVersion,
+		files: []string{"swagger-ui.css", "swagger-ui-bundle.js"},
+		cdn:   "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion,
+	},
+	Redoc: {
+		name:  "redoc@" + redocVersion,
+		files: []string{"redoc.standalone.js"},
+		cdn:   "https://cdn.jsdelivr.net/npm/redoc@" + redocVersion + "/bundles",
+	},
+}
+
+// embedded reports whether every file of the bundle is vendored.
+func (b docsBundle) embedded() bool {
+	for _, f := range b.files {
+		if _, err := fs.Stat(docsBundleFS, b.path(f)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (b docsBundle) path(file string) string {
+	return path.Join("assets/docs/vendor", b.name, file)
+}
+
+type docsConfig struct {
+	ui        DocsUI
+	title     string
+	assetsURL string
+	user      string
+	password  string
+}
+
+type DocsOption func(*docsConfig)
+
+func WithDocsUI(ui DocsUI) DocsOption {
+	return func(c *docsConfig) {
+		c.ui = ui
+	}
+}
+
+func WithDocsTitle(title string) DocsOption {
+	return func(c *docsConfig) {
+		c.title = title
+	}
+}
+
+// WithDocsAssetsURL loads the UI scripts and styles from url instead of the
+// bundles embedded in the binary, e.g. from a CDN of the organization.
+func WithDocsAssetsURL(url string) DocsOption {
+	return func(c *docsConfig) {
+		c.assetsURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithDocsBasicAuth protects the docs and the spec with basic auth.
+func WithDocsBasicAuth(user, password string) DocsOption {
+	return func(c *docsConfig) {
+		c.user = user
+		c.password = password
+	}
+}
+
+// ServeDocs mounts the OpenAPI document spec (JSON or YAML) and a Swagger UI
+// or Redoc page rendering it under prefix. The UI is served from the bundles
+// embedded in the binary under prefix/assets, pinned to a version. A build
+// without the vendored bundles loads the same version from a CDN.
+func (rg *RouterGroup) ServeDocs(prefix string, spec []byte, opts ...DocsOption) {
+	cfg := &docsConfig{
+		ui:    SwaggerUI,
+		title: "API Docs",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	bundle, serveBundle := docsBundles[cfg.ui], false
+	if cfg.assetsURL == "" {
+		if serveBundle = bundle.embedded(); serveBundle {
+			// relative to the page at prefix/
+			cfg.assetsURL = "assets/" + bundle.name
+		} else {
+			plog.Warnf("docs: %s is not vendored, see `make docs-assets`, loading it from %s", bundle.name, bundle.cdn)
+			cfg.assetsURL = bundle.cdn
+		}
+	}
+
+	specName, specType := "openapi.yaml", "application/yaml"
+	if trimmed := bytes.TrimSpace(spec); len(trimmed) > 0 && trimmed[0] == '{' {
+		specName, specType = "openapi.json", "application/json"
+	}
+
+	var middlewares []HandleFunc
+	if cfg.user != "" {
+		middlewares = append(middlewares, docsBasicAuth(cfg.user, cfg.password))
+	}
+
+	prefix = "/" + strings.Trim(prefix, "/")
+	g := rg.Group(prefix, middlewares...)
+
+	rg.GET(prefix, func(ctx *Context) (Response, error) {
+		return ctx.Redirect(http.StatusMovedPermanently, ctx.Request.URL.Path+"/")
+	})
+
+	g.GET("/", func(ctx *Context) (Response, error) {
+		page := &bytes.Buffer{}
+		err := docsTemplates.ExecuteTemplate(page, string(cfg.ui)+".html", map[string]string{
+			"Title":     cfg.title,
+			"AssetsURL": cfg.assetsURL,
+			"SpecURL":   specName,
+		})
+		if err != nil {
+			return nil, NewErr(http.StatusInternalServerError, err).SetComponent(ErrProuter)
+		}
+
+		ctx.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = ctx.Writer.Write(page.Bytes())
+		return nil, nil
+	})
+
+	g.GET("/"+specName, func(ctx *Context) (Response, error) {
+		ctx.Writer.Header().Set("Content-Type", specType)
+		_, _ = ctx.Writer.Write(spec)
+		return nil, nil
+	})
+
+	if serveBundle {
+		for _, file := range bundle.files {
+			g.GET("/assets/"+bundle.name+"/"+file, docsBundleHandler(bundle.path(file)))
+		}
+	}
+}
+
+// docsBundleHandler serves a file of a vendored bundle, the path holds the
+// version so it is cached for good.
+func docsBundleHandler(name string) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		data, err := fs.ReadFile(docsBundleFS, name)
+		if err != nil {
+			return nil, NewErr(http.StatusInternalServerError, err).SetComponent(ErrProuter)
+		}
+
+		ctx.Writer.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		http.ServeContent(ctx.Writer, ctx.Request, name, time.Time{}, bytes.NewReader(data))
+		return nil, nil
+	}
+}
+
+func docsBasicAuth(user, password string) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		u, p, ok := ctx.Request.BasicAuth()
+		if ok &&
+			subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1 {
+			return nil, nil
+		}
+
+		ctx.Writer.Header().Set("WWW-Authenticate", `Basic realm="docs"`)
+		return nil, MsgError(http.StatusUnauthorized, "unauthorized").SetComponent(ErrProuter)
+	}
+}
@@ -0,0 +1,101 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeDocsAssets(t *testing.T) {
+	vendored := fstest.MapFS{
+		"assets/docs/vendor/swagger-ui-dist@" + swaggerUIVersion + "/swagger-ui.css":       {Data: []byte("css")},
+		"assets/docs/vendor/swagger-ui-dist@" + swaggerUIVersion + "/swagger-ui-bundle.js": {Data: []byte("swagger js")},
+		"assets/docs/vendor/redoc@" + redocVersion + "/redoc.standalone.js":                {Data: []byte("redoc js")},
+	}
+	swaggerJS := "assets/swagger-ui-dist@" + swaggerUIVersion + "/swagger-ui-bundle.js"
+
+	tests := []struct {
+		name      string
+		bundles   fstest.MapFS
+		opts      []DocsOption
+		page      string
+		asset     string
+		assetCode int
+		assetBody string
+	}{
+		{"embedded swagger", vendored, nil, `src="` + swaggerJS + `"`, swaggerJS, http.StatusOK, "swagger js"},
+		{"embedded redoc", vendored, []DocsOption{WithDocsUI(Redoc)}, `src="assets/redoc@` + redocVersion + `/redoc.standalone.js"`,
+			"assets/redoc@" + redocVersion + "/redoc.standalone.js", http.StatusOK, "redoc js"},
+		{"not vendored", fstest.MapFS{}, nil, `src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"`,
+			swaggerJS, http.StatusNotFound, ""},
+		{"override", vendored, []DocsOption{WithDocsAssetsURL("https://cdn.example.com/swagger/")}, `src="https://cdn.example.com/swagger/swagger-ui-bundle.js"`,
+			swaggerJS, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := docsBundleFS
+			docsBundleFS = tt.bundles
+			defer func() { docsBundleFS = prev }()
+
+			router := New()
+			router.ServeDocs("/docs", []byte(`{"openapi":"3.0.0"}`), tt.opts...)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.page) {
+				t.Fatalf("page = %d %s, want it to contain %s", rec.Code, rec.Body, tt.page)
+			}
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/"+tt.asset, nil))
+			if rec.Code != tt.assetCode {
+				t.Fatalf("GET %s: code = %d, want %d", tt.asset, rec.Code, tt.assetCode)
+			}
+			if tt.assetCode == http.StatusOK {
+				if rec.Body.String() != tt.assetBody {
+					t.Errorf("GET %s: body = %q, want %q", tt.asset, rec.Body, tt.assetBody)
+				}
+				if cc := rec.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+					t.Errorf("GET %s: Cache-Control = %q", tt.asset, cc)
+				}
+			}
+		})
+	}
+}
+
+func TestServeDocsBasicAuthCoversAssets(t *testing.T) {
+	prev := docsBundleFS
+	docsBundleFS = fstest.MapFS{
+		"assets/docs/vendor/redoc@" + redocVersion + "/redoc.standalone.js": {Data: []byte("redoc js")},
+	}
+	defer func() { docsBundleFS = prev }()
+
+	router := New()
+	router.ServeDocs("/docs", []byte("openapi: 3.0.0"), WithDocsUI(Redoc), WithDocsBasicAuth("admin", "secret"))
+
+	asset := "/docs/assets/redoc@" + redocVersion + "/redoc.standalone.js"
+	tests := []struct {
+		path string
+		auth bool
+		code int
+	}{
+		{"/docs/", false, http.StatusUnauthorized},
+		{"/docs/openapi.yaml", false, http.StatusUnauthorized},
+		{asset, false, http.StatusUnauthorized},
+		{asset, true, http.StatusOK},
+		{"/docs/openapi.yaml", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("GET %s auth %v: code = %d, want %d", tt.path, tt.auth, rec.Code, tt.code)
+		}
+	}
+}
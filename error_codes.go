@@ -0,0 +1,118 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrorCode is a business code of the response envelope registered with
+// RegisterErrorCode.
+type ErrorCode struct {
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	HTTPStatus int    `json:"httpStatus"`
+}
+
+var errorCodes = struct {
+	sync.RWMutex
+	codes map[int]ErrorCode
+}{codes: make(map[int]ErrorCode)}
+
+// RegisterErrorCode registers code with the message of NewCodeError and the
+// HTTP status its responses are written with, usually from the init of the
+// package owning the code:
+//
+//	const ErrUserBanned = 4031
+//
+//	func init() {
+//		prouter.RegisterErrorCode(ErrUserBanned, "user %s is banned", http.StatusForbidden)
+//	}
+//
+// It panics when code is registered already, or is an HTTP status code, those
+// are answered by the router itself.
+func RegisterErrorCode(code int, defaultMessage string, httpStatus int) {
+	if http.StatusText(code) != "" {
+		panic(fmt.Sprintf("prouter: error code %d is an http status code", code))
+	}
+	if http.StatusText(httpStatus) == "" {
+		panic(fmt.Sprintf("prouter: invalid http status %d for error code %d", httpStatus, code))
+	}
+
+	errorCodes.Lock()
+	defer errorCodes.Unlock()
+	if prev, ok := errorCodes.codes[code]; ok {
+		panic(fmt.Sprintf("prouter: error code %d registered twice, already as %q", code, prev.Message))
+	}
+	errorCodes.codes[code] = ErrorCode{Code: code, Message: defaultMessage, HTTPStatus: httpStatus}
+}
+
+// LookupErrorCode returns the registration of code.
+func LookupErrorCode(code int) (ErrorCode, bool) {
+	errorCodes.RLock()
+	defer errorCodes.RUnlock()
+	ec, ok := errorCodes.codes[code]
+	return ec, ok
+}
+
+// ErrorCodes returns the registered codes sorted by code, e.g. to publish them
+// next to the API docs.
+func ErrorCodes() []ErrorCode {
+	errorCodes.RLock()
+	ret := make([]ErrorCode, 0, len(errorCodes.codes))
+	for _, ec := range errorCodes.codes {
+		ret = append(ret, ec)
+	}
+	errorCodes.RUnlock()
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Code < ret[j].Code })
+	return ret
+}
+
+// NewCodeError returns the error of a registered code, its message is the
+// default message formatted with args. An unregistered code answers 500 naming
+// the code, so a typo does not turn into a silent success code.
+func NewCodeError(code int, args ...any) Error {
+	ec, ok := LookupErrorCode(code)
+	if !ok {
+		return MsgError(http.StatusInternalServerError, fmt.Sprintf("unregistered error code %d", code)).
+			SetComponent(ErrProuter).
+			SetResponseType(InternalServerError)
+	}
+
+	msg := ec.Message
+	if len(args) > 0 {
+		msg = fmt.Sprintf(ec.Message, args...)
+	}
+	err := MsgError(code, msg)
+	if rt := statusResponseType(ec.HTTPStatus); rt != "" {
+		err = err.SetResponseType(rt)
+	}
+	return err
+}
+
+func statusResponseType(status int) ResponseErrType {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return AlreadyExists
+	case http.StatusInternalServerError:
+		return InternalServerError
+	}
+	return ""
+}
+
+// statusOfCode is the HTTP status of an envelope code, registered codes take
+// precedence over SetMapCodeToStatusFunc
+func statusOfCode(code int) int {
+	if ec, ok := LookupErrorCode(code); ok {
+		return ec.HTTPStatus
+	}
+	return mapCodeToStatus(code)
+}
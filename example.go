@@ -0,0 +1,104 @@
+package prouter
+
+import (
+	"net/http"
+	"sort"
+)
+
+const (
+	// ExampleHeader selects the example of a route to return instead of running its handler
+	ExampleHeader = "X-Prouter-Example"
+	examplesPath  = "/_prouter/examples"
+)
+
+// Example is a sample request and response attached to a route. In DebugMode a
+// request with the X-Prouter-Example header set to its name is answered with
+// Response without running the handler.
+type Example struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Request     any    `json:"request,omitempty"`
+	Response    any    `json:"response,omitempty"`
+	// Code is the business code of the example response, defaults to 200.
+	Code int `json:"code,omitempty"`
+}
+
+func WithExample(examples ...Example) RouteOption {
+	return func(c *routeConfig) {
+		c.examples = append(c.examples, examples...)
+	}
+}
+
+type exampleHandler struct {
+	examples []Example
+}
+
+func (h *exampleHandler) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		name := ctx.Request.Header.Get(ExampleHeader)
+		if name == "" || prouterMode != DebugMode {
+			return handler.Handle(ctx)
+		}
+
+		for _, ex := range h.examples {
+			if ex.Name != name {
+				continue
+			}
+
+			code := ex.Code
+			if code == 0 {
+				code = http.StatusOK
+			}
+			return SuccessResponse(ex.Response).SetCode(code), nil
+		}
+
+		return nil, MsgError(http.StatusNotFound, "example not found: "+name).
+			SetComponent(ErrProuter).
+			SetResponseType(NotFound)
+	})
+}
+
+type routeExamples struct {
+	Method   string    `json:"method"`
+	Route    string    `json:"route"`
+	Name     string    `json:"name,omitempty"`
+	Examples []Example `json:"examples"`
+}
+
+// mountExamples registers the examples listing once the first route with examples is added.
+func (v *Prouter) mountExamples() {
+	if prouterMode != DebugMode || v.examplesMounted {
+		return
+	}
+	v.examplesMounted = true
+
+	v.RouterGroup.GET(examplesPath, func(ctx *Context) (Response, error) {
+		return SuccessResponse(v.routeSlots.examples()), nil
+	})
+}
+
+func (rr *routeRegistry) examples() []routeExamples {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	ret := make([]routeExamples, 0)
+	for _, s := range rr.slots {
+		if len(s.info.examples) == 0 {
+			continue
+		}
+		ret = append(ret, routeExamples{
+			Method:   s.info.method,
+			Route:    s.info.template,
+			Name:     s.info.name,
+			Examples: s.info.examples,
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Route != ret[j].Route {
+			return ret[i].Route < ret[j].Route
+		}
+		return ret[i].Method < ret[j].Method
+	})
+	return ret
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/go-puzzles/prouter"
 	"github.com/go-puzzles/puzzles/plog"
-	"github.com/gorilla/mux"
 )
 
 type routers []prouter.Route
@@ -117,9 +116,7 @@ func main() {
 	router.UseMiddleware(prouter.NewSessionMiddleware("testsession"))
 
 	router.HandleRoute(http.MethodGet, "/noheader/hello/{name}", helloHandler)
-	router.HandleRoute(http.MethodGet, "/header/hello/{name}", helloHandler, func(route *mux.Route) *mux.Route {
-		return route.Headers("X-Requested-With", "XMLHttpRequest")
-	})
+	router.HandleRoute(http.MethodGet, "/header/hello/{name}", helloHandler, prouter.RequireHeader("X-Requested-With", "XMLHttpRequest"))
 	router.POST("/hello/parse", prouter.BodyParser(bodyParseTestHandler))
 	router.GET("/hello/{name}", prouter.BodyParser(parseUriHandler))
 	router.GET("/panic", panicRouterTest)
@@ -36,7 +36,8 @@ func main() {
 	redisConf.SetDefault()
 
 	client := redisConf.DialRedisClient()
-	redisStore := sessionstore.NewRedisStoreWithClient(client, "sesionprefix")
+	redisStore, err := sessionstore.NewRedisStoreWithClient(client, "sesionprefix")
+	plog.PanicError(err)
 
 	router := prouter.NewProuter()
 	router.UseMiddleware(prouter.NewSessionMiddleware("testsession", redisStore))
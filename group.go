@@ -7,12 +7,15 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 
 	"github.com/go-puzzles/puzzles/plog"
 	"github.com/gorilla/mux"
 )
 
+const staticPathVar = "filepath"
+
 type RouterGroup struct {
 	// prouter is the root instance
 	prouter *Prouter
@@ -21,6 +24,19 @@ type RouterGroup struct {
 	routes      []iRoute
 	middlewares []Middleware
 	root        bool
+	// prefix is the path prefix of the group joined with its parents
+	prefix string
+	// values are injected into the Context of every route in the group
+	values []contextValue
+	stats  *groupStats
+	// slots and groups are the routes and sub groups the chains of late
+	// middlewares are composed again for
+	slots  []*routeSlot
+	groups []*RouterGroup
+}
+
+type contextValue struct {
+	key, val any
 }
 
 func newGroupWithRouter(router *mux.Router) RouterGroup {
@@ -36,28 +52,56 @@ func (rg *RouterGroup) Use(middlewares ...HandleFunc) {
 	for _, m := range middlewares {
 		ms = append(ms, m)
 	}
-	rg.middlewares = append(rg.middlewares, ms...)
+	rg.UseMiddleware(ms...)
 }
 
+// UseMiddleware appends m to the chain of the group. Routes registered before
+// get m as well, and sub groups get it where the chain of the group ends in
+// theirs, before their own middlewares, as if m had been added before they
+// were created. Each chain is composed again.
 func (rg *RouterGroup) UseMiddleware(m ...Middleware) {
-	rg.middlewares = append(rg.middlewares, m...)
+	rg.insertMiddleware(len(rg.middlewares), m)
+}
+
+// insertMiddleware inserts m at index at of the chain of the group and of its
+// sub groups, whose chains start with the one of the group.
+func (rg *RouterGroup) insertMiddleware(at int, m []Middleware) {
+	if len(m) == 0 {
+		return
+	}
+	rg.middlewares = slices.Insert(slices.Clip(rg.middlewares), at, m...)
+	for _, slot := range rg.slots {
+		slot.recompose(rg.prouter, func(r *iRoute) {
+			r.middleware = rg.middlewares
+		})
+	}
+	for _, g := range rg.groups {
+		g.insertMiddleware(at, m)
+	}
+}
+
+// WithValue injects the static value into the Context of every route registered
+// in the group afterwards, including its sub groups.
+func (rg *RouterGroup) WithValue(key, value any) {
+	rg.values = append(rg.values, contextValue{key: key, val: value})
 }
 
 func (rg *RouterGroup) HandleRouter(routers ...Router) {
 	wrapRoutes := func(routes []Route) {
 		for _, r := range routes {
-			var opt RouteOption
+			var opts []RouteOption
 			switch tr := r.(type) {
 			case OptRoute:
-				opt = tr.Option
+				opts = tr.Options()
 			default:
 			}
 
 			rg.initRouter(iRoute{
-				Route:       r,
-				router:      rg.router,
-				middleware:  rg.middlewares,
-				routeOption: opt,
+				Route:        r,
+				router:       rg.router,
+				middleware:   rg.middlewares,
+				values:       rg.values,
+				routeOptions: opts,
 			})
 		}
 	}
@@ -68,26 +112,13 @@ func (rg *RouterGroup) HandleRouter(routers ...Router) {
 }
 
 func (rg *RouterGroup) handleRoute(method, path string, handler handlerFunc, opts ...RouteOption) {
-	routeOpt := func(r *mux.Route) *mux.Route {
-
-		if opts == nil {
-			return r
-		}
-
-		next := r
-		for _, opt := range opts {
-			next = opt(next)
-		}
-
-		return next
-	}
-
 	r := iRoute{
-		Route:       newHandlerFuncRoute(method, path, handler),
-		router:      rg.router,
-		routeOption: routeOpt,
+		Route:        newHandlerFuncRoute(method, path, handler),
+		router:       rg.router,
+		routeOptions: opts,
 	}
 	r.middleware = rg.middlewares
+	r.values = rg.values
 
 	rg.initRouter(r)
 
@@ -98,21 +129,83 @@ func (rg *RouterGroup) HandleRoute(method, path string, handler HandleFunc, opts
 }
 
 func (rg *RouterGroup) initRouter(r iRoute) {
-	f := rg.prouter.makeHttpHandler(r)
+	path, params := parseParamTypes(r.Path())
 
-	vr := r.router.Path(r.Path())
+	vr := r.router.Path(path)
 	if r.Method() != "" {
 		vr = vr.Methods(r.Method())
 	}
 
-	if r.routeOption != nil {
-		vr = r.routeOption(vr)
+	cfg := &routeConfig{route: vr, params: params}
+	for _, opt := range r.routeOptions {
+		opt(cfg)
+	}
+	vr = cfg.route
+	if cfg.err != nil {
+		panic(fmt.Errorf("%w (route %s)", cfg.err, routeKey(r.Method(), strings.TrimRight(rg.prefix, "/")+r.Path())))
 	}
 
-	mr := vr.Handler(f)
+	info := &routeInfo{
+		method:         r.Method(),
+		template:       strings.TrimRight(rg.prefix, "/") + r.Path(),
+		hasVars:        routeHasVars(vr),
+		name:           vr.GetName(),
+		examples:       cfg.examples,
+		compression:    cfg.compression,
+		slo:            cfg.slo,
+		queryAllowlist: cfg.queryAllowlist,
+		traffic:        new(routeTraffic),
+		surrogateKeys:  cfg.surrogateKeys,
+		preconditions:  cfg.preconditions,
+		payloadLimit:   cfg.payloadLimit,
+		schemaURL:      cfg.schemaURL,
+		capabilities:   cfg.capabilities,
+		discoverable:   cfg.discoverable,
+		unknownFields:  cfg.unknownFields,
+		responseModel:  cfg.responseModel,
+		maxBodySize:    cfg.maxBodySize,
+		spoolThreshold: cfg.spoolThreshold,
+		warmup:         cfg.warmup,
+	}
+	if cfg.cors != nil {
+		cors, err := rg.prouter.cors.merge(cfg.cors)
+		if err != nil {
+			panic(fmt.Errorf("%w (route %s)", err, routeKey(info.method, info.template)))
+		}
+		info.cors = cors
+		rg.prouter.routeCORS = true
+	}
+	if cfg.disabled {
+		vr.BuildOnly()
+		rg.debugPrintRouteAction("disabled", info, r.Handler().Name())
+		return
+	}
+
+	if info.warmup != nil {
+		rg.prouter.registerWarmup()
+	}
+
+	f := rg.prouter.makeHttpHandler(r, info, params)
+	slot := newRouteSlot(r, info, params, f)
+	slot.group = rg.stats
+	rg.prouter.routeSlots.add(slot)
+	rg.slots = append(rg.slots, slot)
+
+	mr := vr.MatcherFunc(slot.match).Handler(slot)
+	rg.routes = append(rg.routes, r)
+	if len(info.examples) > 0 {
+		rg.prouter.mountExamples()
+	}
 	rg.debugPrintRoute(r.Method(), mr, r.Handler())
 }
 
+// routeHasVars reports whether the mux route declares variables, in the path,
+// the host or the query, once every option ran
+func routeHasVars(route *mux.Route) bool {
+	names, err := route.GetVarNames()
+	return err != nil || len(names) > 0
+}
+
 func (rg *RouterGroup) debugPrintRoute(method string, route *mux.Route, handler handlerFunc) {
 	if prouterMode != DebugMode {
 		return
@@ -138,33 +231,35 @@ func (rg *RouterGroup) Group(prefix string, middlewares ...HandleFunc) *RouterGr
 	router := rg.router.PathPrefix(prefix).Subrouter()
 	g := newGroupWithRouter(router)
 	g.middlewares = append(g.middlewares, rg.middlewares...)
+	g.values = slices.Clone(rg.values)
 	g.prouter = rg.prouter
+	g.prefix = strings.TrimRight(rg.prefix, "/") + prefix
+	g.stats = rg.prouter.stats.group(g.prefix, rg.stats)
 
 	g.Use(middlewares...)
+	rg.groups = append(rg.groups, &g)
 
 	return &g
 }
 
-func (rg *RouterGroup) staticHandler(prefix string, fs http.FileSystem) HandleFunc {
+func (rg *RouterGroup) staticHandler(fs http.FileSystem) HandleFunc {
+	fileServer := http.FileServer(fs)
+
 	return func(ctx *Context) (Response, error) {
 		r := ctx.Request
-		w := ctx.Writer
-
-		p := strings.TrimPrefix(r.URL.Path, prefix)
-		rp := strings.TrimPrefix(r.URL.RawPath, prefix)
-
-		if len(p) < len(r.URL.Path) && (r.URL.RawPath == "" || len(rp) < len(r.URL.RawPath)) {
-			r2 := new(http.Request)
-			*r2 = *r
-			r2.URL = new(url.URL)
-			*r2.URL = *r.URL
-			r2.URL.Path = p
-			r2.URL.RawPath = rp
-
-			http.FileServer(fs).ServeHTTP(w, r2)
-		} else {
-			return nil, MsgError(http.StatusNotFound, fmt.Sprintf("%v static file not found", p))
+		p := "/" + ctx.ParamPath(staticPathVar)
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = p
+		r2.URL.RawPath = ""
+
+		if servePrecompressed(ctx.Writer, r2, fs, p) {
+			return nil, nil
 		}
+		fileServer.ServeHTTP(ctx.Writer, r2)
 		return nil, nil
 	}
 }
@@ -179,7 +274,7 @@ func (rg *RouterGroup) StaticFsEmbed(path, fileRelativePath string, fsEmbed embe
 		panic(err)
 	}
 
-	rg.StaticFS(path, http.FS(subFs))
+	rg.StaticFS(path, http.FS(subFs), opts...)
 }
 
 func (rg *RouterGroup) StaticFS(relativePath string, fs http.FileSystem, opts ...RouteOption) {
@@ -187,10 +282,10 @@ func (rg *RouterGroup) StaticFS(relativePath string, fs http.FileSystem, opts ..
 		relativePath = "/" + relativePath
 	}
 
-	urlPattern := path.Join(relativePath, "{filepath}")
+	urlPattern := path.Join(relativePath, "{"+staticPathVar+":path}")
 	handler := &wrapHandler{
 		name:    "StaticFSHandler",
-		handler: rg.staticHandler(relativePath, fs),
+		handler: rg.staticHandler(fs),
 	}
 
 	rg.handleRoute(http.MethodGet, urlPattern, handler, opts...)
@@ -0,0 +1,117 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLateMiddlewareRecomposesRegisteredRoutes(t *testing.T) {
+	tag := func(name string) HandleFunc {
+		return func(ctx *Context) (Response, error) {
+			ctx.Writer.Header().Add("X-Chain", name)
+			return nil, nil
+		}
+	}
+	noop := func(*Context) (Response, error) { return nil, nil }
+
+	router := New()
+	router.Use(tag("root"))
+	router.GET("/early", noop)
+	api := router.Group("/api", tag("api"))
+	api.GET("/early", noop)
+	router.GET("/replaced", noop)
+
+	router.Use(tag("late"))
+	router.GET("/later", noop)
+	api.GET("/later", noop)
+	if err := router.ReplaceRoute(http.MethodGet, "/replaced", noop); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		chain string
+	}{
+		{"/early", "root,late"},
+		{"/later", "root,late"},
+		{"/api/early", "root,late,api"},
+		{"/api/later", "root,late,api"},
+		{"/replaced", "root,late"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := strings.Join(rec.Header().Values("X-Chain"), ","); got != tt.chain {
+			t.Errorf("%s: chain = %s, want %s", tt.path, got, tt.chain)
+		}
+	}
+}
+
+func TestLateMiddlewareKeepsTheGroupNesting(t *testing.T) {
+	tag := func(name string) HandleFunc {
+		return func(ctx *Context) (Response, error) {
+			ctx.Writer.Header().Add("X-Chain", name)
+			return nil, nil
+		}
+	}
+	noop := func(*Context) (Response, error) { return nil, nil }
+
+	router := New()
+	api := router.Group("/api", tag("api"))
+	v1 := api.Group("/v1", tag("v1"))
+	v1.GET("/users", noop)
+	api.GET("/status", noop)
+
+	// added after the sub groups, in the order of the groups they belong to
+	v1.Use(tag("v1-late"))
+	router.Use(tag("root-late"))
+	api.Use(tag("api-late"))
+	v1.GET("/later", noop)
+	admin := api.Group("/admin", tag("admin"))
+	admin.GET("", noop)
+
+	tests := []struct {
+		path  string
+		chain string
+	}{
+		{"/api/v1/users", "root-late,api,api-late,v1,v1-late"},
+		{"/api/v1/later", "root-late,api,api-late,v1,v1-late"},
+		{"/api/status", "root-late,api,api-late"},
+		{"/api/admin", "root-late,api,api-late,admin"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := strings.Join(rec.Header().Values("X-Chain"), ","); got != tt.chain {
+			t.Errorf("%s: chain = %s, want %s", tt.path, got, tt.chain)
+		}
+	}
+}
+
+func TestLateAuthMiddlewareRunsBeforeTheSubGroup(t *testing.T) {
+	router := New()
+	api := router.Group("/api")
+	var loaded bool
+	api.Group("/me", func(*Context) (Response, error) {
+		loaded = true
+		return nil, nil
+	}).GET("", func(*Context) (Response, error) { return nil, nil })
+
+	router.Use(func(ctx *Context) (Response, error) {
+		if ctx.Request.Header.Get("Authorization") == "" {
+			return nil, MsgError(http.StatusUnauthorized, "unauthorized").SetComponent(ErrProuter)
+		}
+		return nil, nil
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/me", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("code = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if loaded {
+		t.Error("the middleware of the sub group ran before the late auth middleware")
+	}
+}
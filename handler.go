@@ -74,45 +74,8 @@ func (h bodyParseHandlerFn[RequestT, ResponseT]) Name() string {
 
 func (h bodyParseHandlerFn[RequestT, ResponseT]) Handle(ctx *Context) (resp Response, err error) {
 	requestPtr := new(RequestT)
-	r := ctx.Request
-
-	var errMsg string
-	func() {
-		ct := contentType(r)
-		binder := binding.Default(r.Method, ct)
-		if err = binder.Bind(r, requestPtr); err != nil {
-			errMsg = "parse request data failed"
-		}
-		if len(ctx.vars) > 0 {
-			m := make(map[string][]string)
-			for k, v := range ctx.vars {
-				m[k] = []string{v}
-			}
-			if err = binding.Uri.BindUri(m, requestPtr); err != nil {
-				errMsg = "parse request data failed"
-				return
-			}
-		}
-
-		if len(r.Header) > 0 {
-			if err = binding.Header.Bind(r, requestPtr); err != nil {
-				errMsg = "parse request header data failed"
-				return
-			}
-		}
-
-		if len(r.URL.Query()) > 0 {
-			if err = binding.Query.Bind(r, requestPtr); err != nil {
-				errMsg = "parse request query data failed"
-				return
-			}
-		}
-	}()
-
-	if errMsg != "" {
-		return nil, NewErr(http.StatusBadRequest, err, errMsg).
-			SetComponent(ErrProuter).
-			SetResponseType(BadRequest)
+	if err := bindRequest(ctx, requestPtr); err != nil {
+		return nil, err
 	}
 
 	handleResp, err := h(ctx, requestPtr)
@@ -137,6 +100,44 @@ func (h bodyParseHandlerFn[RequestT, ResponseT]) Handle(ctx *Context) (resp Resp
 	return ret, nil
 }
 
+// bindRequest decodes the body, path variables, headers and query of the
+// request into requestPtr and validates it once all are decoded
+func bindRequest(ctx *Context, requestPtr any) error {
+	fail := func(msg string, err error) error {
+		return NewErr(http.StatusBadRequest, validationCause(requestPtr, err), msg).
+			SetComponent(ErrProuter).
+			SetResponseType(BadRequest)
+	}
+
+	if err := ctx.decodeBody(requestPtr); err != nil {
+		return fail("parse request data failed", err)
+	}
+	if vars := ctx.Vars(); len(vars) > 0 {
+		m := make(map[string][]string)
+		for k, v := range vars {
+			m[k] = []string{v}
+		}
+		if err := binding.MapFormWithTag(requestPtr, m, "uri"); err != nil {
+			return fail("parse request data failed", err)
+		}
+	}
+	if len(ctx.Request.Header) > 0 {
+		if err := mapHeader(ctx.Request, requestPtr); err != nil {
+			return fail("parse request header data failed", err)
+		}
+	}
+	if query := ctx.QueryValues(); len(query) > 0 {
+		if err := binding.MapFormWithTag(requestPtr, query, "form"); err != nil {
+			return fail("parse request query data failed", err)
+		}
+	}
+
+	if err := ctx.validate(requestPtr); err != nil {
+		return fail("parse request data failed", err)
+	}
+	return nil
+}
+
 func contentType(r *http.Request) string {
 	return filterFlags(requestHeader(r, "Content-Type"))
 }
@@ -0,0 +1,146 @@
+package prouter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultMaxHeaderCount = 100
+	defaultMaxHeaderSize  = 32 << 10
+)
+
+// HardeningMiddleware rejects requests whose framing or headers a proxy in front
+// could read differently than the router, the usual request smuggling vectors,
+// as defense in depth. Rejections are counted in Stats under "hardening.<reason>".
+type HardeningMiddleware struct {
+	maxHeaderCount   int
+	maxHeaderSize    int
+	rejectUnderscore bool
+}
+
+type HardeningOption func(*HardeningMiddleware)
+
+// WithMaxHeaderCount caps the number of header lines, 100 by default.
+func WithMaxHeaderCount(n int) HardeningOption {
+	return func(m *HardeningMiddleware) {
+		m.maxHeaderCount = n
+	}
+}
+
+// WithMaxHeaderSize caps the size of all headers in bytes, 32KB by default.
+func WithMaxHeaderSize(n int) HardeningOption {
+	return func(m *HardeningMiddleware) {
+		m.maxHeaderSize = n
+	}
+}
+
+// WithRejectUnderscoreHeaders rejects header names with underscores, proxies
+// like nginx drop or rewrite them, so X_User and X-User can end up as one header.
+func WithRejectUnderscoreHeaders() HardeningOption {
+	return func(m *HardeningMiddleware) {
+		m.rejectUnderscore = true
+	}
+}
+
+func NewHardeningMiddleware(opts ...HardeningOption) *HardeningMiddleware {
+	m := &HardeningMiddleware{
+		maxHeaderCount: defaultMaxHeaderCount,
+		maxHeaderSize:  defaultMaxHeaderSize,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+type hardeningViolation struct {
+	reason string
+	code   int
+	msg    string
+}
+
+func isTokenChar(c byte) bool {
+	if c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+func checkFraming(r *http.Request) string {
+	te := r.TransferEncoding
+	if len(te) == 0 {
+		te = r.Header.Values("Transfer-Encoding")
+	}
+	cl := r.Header.Values("Content-Length")
+
+	if len(te) > 0 && len(cl) > 0 {
+		return "both Transfer-Encoding and Content-Length"
+	}
+	for i, coding := range te {
+		// only a single chunked is decoded, anything else is framed differently somewhere
+		if !strings.EqualFold(strings.TrimSpace(coding), "chunked") || i > 0 {
+			return "unsupported Transfer-Encoding"
+		}
+	}
+	for _, v := range cl {
+		if _, err := strconv.ParseUint(v, 10, 63); err != nil || v != cl[0] {
+			return "invalid Content-Length"
+		}
+	}
+	return ""
+}
+
+func (m *HardeningMiddleware) check(r *http.Request) *hardeningViolation {
+	if msg := checkFraming(r); msg != "" {
+		return &hardeningViolation{reason: "framing", code: http.StatusBadRequest, msg: msg}
+	}
+
+	count := 0
+	for name, values := range r.Header {
+		count += len(values)
+		for i := 0; i < len(name); i++ {
+			if !isTokenChar(name[i]) || m.rejectUnderscore && name[i] == '_' {
+				return &hardeningViolation{reason: "header_name", code: http.StatusBadRequest, msg: "invalid header name"}
+			}
+		}
+		for _, v := range values {
+			for i := 0; i < len(v); i++ {
+				if c := v[i]; c < 0x20 && c != '\t' || c == 0x7f {
+					return &hardeningViolation{reason: "header_value", code: http.StatusBadRequest, msg: "invalid header value of " + name}
+				}
+			}
+		}
+	}
+
+	if m.maxHeaderCount > 0 && count > m.maxHeaderCount {
+		return &hardeningViolation{reason: "header_count", code: http.StatusRequestHeaderFieldsTooLarge, msg: "too many request headers"}
+	}
+	if m.maxHeaderSize > 0 && headerBytes(r.Header) > m.maxHeaderSize {
+		return &hardeningViolation{reason: "header_size", code: http.StatusRequestHeaderFieldsTooLarge, msg: "request headers too large"}
+	}
+	return nil
+}
+
+func (m *HardeningMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		violation := m.check(ctx.Request)
+		if violation == nil {
+			return handler.Handle(ctx)
+		}
+
+		if ctx.router != nil {
+			ctx.router.RecordRejection("hardening." + violation.reason)
+		}
+		// the body is not trusted to be framed right, do not reuse the connection
+		ctx.Writer.Header().Set("Connection", "close")
+		err := MsgError(violation.code, violation.msg).SetComponent(ErrProuter)
+		if violation.code == http.StatusBadRequest {
+			err = err.SetResponseType(BadRequest)
+		}
+		return nil, err
+	})
+}
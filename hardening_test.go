@@ -0,0 +1,87 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHardeningMiddleware(t *testing.T) {
+	router := New()
+	router.UseMiddleware(NewHardeningMiddleware(
+		WithMaxHeaderCount(10),
+		WithMaxHeaderSize(1024),
+		WithRejectUnderscoreHeaders(),
+	))
+	router.POST("/", func(*Context) (Response, error) { return nil, nil })
+
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		code   int
+		reason string
+	}{
+		{"plain", func(r *http.Request) {}, http.StatusOK, ""},
+		{"chunked", func(r *http.Request) { r.TransferEncoding = []string{"chunked"} }, http.StatusOK, ""},
+		{"duplicate equal content length", func(r *http.Request) {
+			r.Header["Content-Length"] = []string{"3", "3"}
+		}, http.StatusOK, ""},
+		{"chunked and content length", func(r *http.Request) {
+			r.TransferEncoding = []string{"chunked"}
+			r.Header.Set("Content-Length", "3")
+		}, http.StatusBadRequest, "framing"},
+		{"gzip transfer encoding", func(r *http.Request) { r.Header.Set("Transfer-Encoding", "gzip") }, http.StatusBadRequest, "framing"},
+		{"chunked twice", func(r *http.Request) { r.TransferEncoding = []string{"chunked", "chunked"} }, http.StatusBadRequest, "framing"},
+		{"conflicting content length", func(r *http.Request) {
+			r.Header["Content-Length"] = []string{"3", "4"}
+		}, http.StatusBadRequest, "framing"},
+		{"signed content length", func(r *http.Request) { r.Header.Set("Content-Length", "+3") }, http.StatusBadRequest, "framing"},
+		{"space in header name", func(r *http.Request) { r.Header["X User"] = []string{"a"} }, http.StatusBadRequest, "header_name"},
+		{"underscore in header name", func(r *http.Request) { r.Header["X_User"] = []string{"a"} }, http.StatusBadRequest, "header_name"},
+		{"control in header value", func(r *http.Request) { r.Header.Set("X-User", "a\x00b") }, http.StatusBadRequest, "header_value"},
+		{"tab in header value", func(r *http.Request) { r.Header.Set("X-User", "a\tb") }, http.StatusOK, ""},
+		{"header count", func(r *http.Request) {
+			for i := 0; i < 11; i++ {
+				r.Header.Add("X-H"+strconv.Itoa(i), "v")
+			}
+		}, http.StatusRequestHeaderFieldsTooLarge, "header_count"},
+		{"header size", func(r *http.Request) { r.Header.Set("X-Big", strings.Repeat("a", 1024)) }, http.StatusRequestHeaderFieldsTooLarge, "header_size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := router.Stats().Rejections["hardening."+tt.reason]
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abc"))
+			tt.modify(req)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Fatalf("code = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if tt.reason == "" {
+				return
+			}
+			if got := router.Stats().Rejections["hardening."+tt.reason]; got != before+1 {
+				t.Errorf("rejections of %s = %d, want %d", tt.reason, got, before+1)
+			}
+			if got := rec.Header().Get("Connection"); got != "close" {
+				t.Errorf("Connection = %q, want close", got)
+			}
+		})
+	}
+}
+
+func TestHardeningAllowsUnderscoreByDefault(t *testing.T) {
+	router := New()
+	router.UseMiddleware(NewHardeningMiddleware())
+	router.GET("/", func(*Context) (Response, error) { return nil, nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header["X_User"] = []string{"a"}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("code = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,283 @@
+package prouter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HMACAlgorithm      = "PROUTER-HMAC-SHA256"
+	HMACDateHeader     = "X-Date"
+	HMACBodyHashHeader = "X-Content-Sha256"
+	// HMACNonceHeader makes the signatures of identical requests sent within
+	// the same second differ, the client Signer signs a random one
+	HMACNonceHeader = "X-Nonce"
+	// HMACDateFormat is the format of the X-Date header
+	HMACDateFormat = "20060102T150405Z"
+
+	defaultHMACClockSkew = 5 * time.Minute
+	defaultHMACMaxBody   = 10 << 20
+)
+
+var ErrUnknownHMACKey = errors.New("unknown hmac key")
+
+// HMACKeyStore resolves the shared secret of a key id.
+type HMACKeyStore interface {
+	Secret(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// HMACKeys is an in-memory HMACKeyStore of key id to secret.
+type HMACKeys map[string][]byte
+
+func (k HMACKeys) Secret(_ context.Context, keyID string) ([]byte, error) {
+	secret, ok := k[keyID]
+	if !ok {
+		return nil, ErrUnknownHMACKey
+	}
+	return secret, nil
+}
+
+// HashBody returns the hex SHA-256 of body as sent in X-Content-Sha256.
+func HashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := slices.Clone(query[k])
+		slices.Sort(values)
+		for _, v := range values {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func canonicalHeader(r *http.Request, name string) string {
+	if name == "host" {
+		return r.Host
+	}
+	return strings.TrimSpace(strings.Join(r.Header.Values(name), ","))
+}
+
+// CanonicalRequest is the string the signature covers: the method, escaped path,
+// sorted query, the signedHeaders in lower case and the body hash on one line each.
+func CanonicalRequest(r *http.Request, signedHeaders []string, bodyHash string) string {
+	var b strings.Builder
+	b.WriteString(r.Method + "\n")
+	b.WriteString(r.URL.EscapedPath() + "\n")
+	b.WriteString(canonicalQuery(r.URL.Query()) + "\n")
+	for _, h := range signedHeaders {
+		b.WriteString(h + ":" + canonicalHeader(r, h) + "\n")
+	}
+	b.WriteString(strings.Join(signedHeaders, ";") + "\n")
+	b.WriteString(bodyHash)
+	return b.String()
+}
+
+// HMACSignature signs the canonical request made at date with secret.
+func HMACSignature(secret []byte, date, canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(HMACAlgorithm + "\n" + date + "\n" + hex.EncodeToString(sum[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type hmacAuthorization struct {
+	keyID         string
+	signedHeaders []string
+	signature     string
+}
+
+// parseHMACAuthorization parses "PROUTER-HMAC-SHA256 KeyId=..., SignedHeaders=host;x-date, Signature=..."
+func parseHMACAuthorization(header string) (hmacAuthorization, bool) {
+	params, found := strings.CutPrefix(header, HMACAlgorithm+" ")
+	if !found {
+		return hmacAuthorization{}, false
+	}
+
+	var auth hmacAuthorization
+	for _, part := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "KeyId":
+			auth.keyID = v
+		case "SignedHeaders":
+			auth.signedHeaders = strings.Split(v, ";")
+		case "Signature":
+			auth.signature = v
+		}
+	}
+	ok := auth.keyID != "" && auth.signature != "" && slices.Contains(auth.signedHeaders, "x-date")
+	return auth, ok
+}
+
+type hmacKeyIDKey struct{}
+
+// HMACKeyID returns the key id of a request verified by HMACAuthMiddleware, it
+// fits WithPrincipalFunc of the authz middleware.
+func HMACKeyID(ctx *Context) string {
+	id, _ := ctx.Value(hmacKeyIDKey{}).(string)
+	return id
+}
+
+// HMACAuthMiddleware authenticates service to service calls signed over the
+// canonical request, see the client package for the signer. Requests dated
+// outside the clock skew are rejected, a signature seen within it is rejected
+// as a replay. The seen signatures are kept in memory, so a replay to another
+// instance of the service is not detected.
+type HMACAuthMiddleware struct {
+	keys      HMACKeyStore
+	clockSkew time.Duration
+	maxBody   int64
+	seen      hmacReplayCache
+}
+
+// hmacReplayCache holds the verified signatures until their date leaves the
+// clock skew, only authenticated callers add to it
+type hmacReplayCache struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	nextSweep time.Time
+}
+
+// add records signature valid until expires, it reports false for a replay.
+func (c *hmacReplayCache) add(signature string, expires, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]time.Time)
+	}
+	if now.After(c.nextSweep) {
+		for k, exp := range c.entries {
+			if now.After(exp) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(time.Minute)
+	}
+
+	if exp, ok := c.entries[signature]; ok && !now.After(exp) {
+		return false
+	}
+	c.entries[signature] = expires
+	return true
+}
+
+type HMACAuthOption func(*HMACAuthMiddleware)
+
+func WithClockSkew(d time.Duration) HMACAuthOption {
+	return func(m *HMACAuthMiddleware) {
+		m.clockSkew = d
+	}
+}
+
+// WithMaxSignedBody limits the body read to verify its hash, 10MB by default.
+func WithMaxSignedBody(n int64) HMACAuthOption {
+	return func(m *HMACAuthMiddleware) {
+		m.maxBody = n
+	}
+}
+
+func NewHMACAuthMiddleware(keys HMACKeyStore, opts ...HMACAuthOption) *HMACAuthMiddleware {
+	m := &HMACAuthMiddleware{
+		keys:      keys,
+		clockSkew: defaultHMACClockSkew,
+		maxBody:   defaultHMACMaxBody,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func hmacUnauthorized(msg string) error {
+	return MsgError(http.StatusUnauthorized, msg).SetComponent(ErrProuter)
+}
+
+func (m *HMACAuthMiddleware) verify(ctx *Context) error {
+	r := ctx.Request
+	auth, ok := parseHMACAuthorization(r.Header.Get("Authorization"))
+	if !ok {
+		return hmacUnauthorized("missing or malformed signature")
+	}
+
+	date := r.Header.Get(HMACDateHeader)
+	t, err := time.Parse(HMACDateFormat, date)
+	if err != nil {
+		return hmacUnauthorized("invalid " + HMACDateHeader)
+	}
+	if skew := ctx.Now().Sub(t); skew > m.clockSkew || skew < -m.clockSkew {
+		return hmacUnauthorized("request date outside of the allowed clock skew")
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err = io.ReadAll(io.LimitReader(r.Body, m.maxBody+1))
+		if err != nil {
+			return NewErr(http.StatusBadRequest, err, "read body failed").
+				SetComponent(ErrProuter).
+				SetResponseType(BadRequest)
+		}
+		if int64(len(body)) > m.maxBody {
+			return MsgError(http.StatusRequestEntityTooLarge, "signed body too large").SetComponent(ErrProuter)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	bodyHash := HashBody(body)
+	if h := r.Header.Get(HMACBodyHashHeader); h != "" && h != bodyHash {
+		return hmacUnauthorized("body hash mismatch")
+	}
+
+	secret, err := m.keys.Secret(ctx, auth.keyID)
+	if errors.Is(err, ErrUnknownHMACKey) {
+		return hmacUnauthorized("unknown key")
+	}
+	if err != nil {
+		return NewErr(http.StatusInternalServerError, err, "resolve hmac key failed").
+			SetComponent(ErrProuter).
+			SetResponseType(InternalServerError)
+	}
+
+	expected := HMACSignature(secret, date, CanonicalRequest(r, auth.signedHeaders, bodyHash))
+	if !hmac.Equal([]byte(expected), []byte(auth.signature)) {
+		return hmacUnauthorized("signature mismatch")
+	}
+	if !m.seen.add(auth.keyID+":"+auth.signature, t.Add(m.clockSkew), ctx.Now()) {
+		return hmacUnauthorized("replayed signature")
+	}
+
+	ctx.WithValue(hmacKeyIDKey{}, auth.keyID)
+	return nil
+}
+
+func (m *HMACAuthMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		if err := m.verify(ctx); err != nil {
+			return nil, err
+		}
+		return handler.Handle(ctx)
+	})
+}
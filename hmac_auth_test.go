@@ -0,0 +1,97 @@
+package prouter_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/prouter/client"
+)
+
+func TestHMACAuthMiddleware(t *testing.T) {
+	keys := prouter.HMACKeys{"svc": []byte("secret")}
+
+	signed := func(t *testing.T, signer *client.Signer, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/orders?b=2&a=1", strings.NewReader(body))
+		if err := signer.Sign(req); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	// resend copies the signed request, as a replaying attacker would
+	resend := func(req *http.Request, body string) *http.Request {
+		again := httptest.NewRequest(req.Method, req.URL.String(), bytes.NewBufferString(body))
+		again.Header = req.Header.Clone()
+		return again
+	}
+
+	tests := []struct {
+		name string
+		// requests are served in order, the last one is checked
+		requests func(t *testing.T) []*http.Request
+		advance  time.Duration
+		code     int
+		msg      string
+	}{
+		{"round trip", func(t *testing.T) []*http.Request {
+			return []*http.Request{signed(t, client.NewSigner("svc", []byte("secret")), `{"id":1}`)}
+		}, 0, http.StatusOK, "svc"},
+		{"identical requests get distinct signatures", func(t *testing.T) []*http.Request {
+			signer := client.NewSigner("svc", []byte("secret"))
+			return []*http.Request{signed(t, signer, `{"id":1}`), signed(t, signer, `{"id":1}`)}
+		}, 0, http.StatusOK, "svc"},
+		{"replayed signature", func(t *testing.T) []*http.Request {
+			req := signed(t, client.NewSigner("svc", []byte("secret")), `{"id":1}`)
+			return []*http.Request{req, resend(req, `{"id":1}`)}
+		}, 0, http.StatusUnauthorized, "replayed signature"},
+		{"clock skew ahead", func(t *testing.T) []*http.Request {
+			return []*http.Request{signed(t, client.NewSigner("svc", []byte("secret")), "")}
+		}, 6 * time.Minute, http.StatusUnauthorized, "clock skew"},
+		{"clock skew behind", func(t *testing.T) []*http.Request {
+			return []*http.Request{signed(t, client.NewSigner("svc", []byte("secret")), "")}
+		}, -6 * time.Minute, http.StatusUnauthorized, "clock skew"},
+		{"body hash mismatch", func(t *testing.T) []*http.Request {
+			req := signed(t, client.NewSigner("svc", []byte("secret")), `{"id":1}`)
+			return []*http.Request{resend(req, `{"id":2}`)}
+		}, 0, http.StatusUnauthorized, "body hash mismatch"},
+		{"unknown key id", func(t *testing.T) []*http.Request {
+			return []*http.Request{signed(t, client.NewSigner("other", []byte("secret")), "")}
+		}, 0, http.StatusUnauthorized, "unknown key"},
+		{"wrong secret", func(t *testing.T) []*http.Request {
+			return []*http.Request{signed(t, client.NewSigner("svc", []byte("guess")), "")}
+		}, 0, http.StatusUnauthorized, "signature mismatch"},
+		{"tampered query", func(t *testing.T) []*http.Request {
+			req := signed(t, client.NewSigner("svc", []byte("secret")), "")
+			req.URL.RawQuery = "a=1&b=3"
+			return []*http.Request{req}
+		}, 0, http.StatusUnauthorized, "signature mismatch"},
+		{"unsigned", func(t *testing.T) []*http.Request {
+			return []*http.Request{httptest.NewRequest(http.MethodPost, "/orders", nil)}
+		}, 0, http.StatusUnauthorized, "missing or malformed signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := prouter.NewFakeClock(time.Now())
+			router := prouter.New(prouter.WithClock(clock))
+			router.UseMiddleware(prouter.NewHMACAuthMiddleware(keys))
+			router.POST("/orders", func(ctx *prouter.Context) (prouter.Response, error) {
+				return prouter.SuccessResponse(prouter.HMACKeyID(ctx)), nil
+			})
+
+			requests := tt.requests(t)
+			clock.Advance(tt.advance)
+			var rec *httptest.ResponseRecorder
+			for _, req := range requests {
+				rec = httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+			}
+			if rec.Code != tt.code || !strings.Contains(rec.Body.String(), tt.msg) {
+				t.Errorf("code = %d %s, want %d with %q", rec.Code, rec.Body, tt.code, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package prouter
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// DenyChecker reports whether ip is currently denied, e.g. banned by the ban package.
+type DenyChecker interface {
+	Denied(ctx context.Context, ip string) (bool, error)
+}
+
+// IPFilterMiddleware answers 403 to clients outside the allowed networks, inside
+// the denied networks or denied by a DenyChecker. Rejections are counted in
+// Stats under "ipfilter".
+type IPFilterMiddleware struct {
+	allow    []*net.IPNet
+	deny     []*net.IPNet
+	checkers []DenyChecker
+}
+
+type IPFilterOption func(*IPFilterMiddleware)
+
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	ret := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		if ip := net.ParseIP(c); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ret = append(ret, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			plog.PanicError(err)
+		}
+		ret = append(ret, n)
+	}
+	return ret
+}
+
+// WithAllowCIDRs only lets clients of cidrs in, plain IPs are accepted as well.
+func WithAllowCIDRs(cidrs ...string) IPFilterOption {
+	return func(m *IPFilterMiddleware) {
+		m.allow = append(m.allow, parseCIDRs(cidrs)...)
+	}
+}
+
+func WithDenyCIDRs(cidrs ...string) IPFilterOption {
+	return func(m *IPFilterMiddleware) {
+		m.deny = append(m.deny, parseCIDRs(cidrs)...)
+	}
+}
+
+// WithDenyChecker consults checker for every request, a failing checker lets the request in.
+func WithDenyChecker(checker DenyChecker) IPFilterOption {
+	return func(m *IPFilterMiddleware) {
+		m.checkers = append(m.checkers, checker)
+	}
+}
+
+func NewIPFilterMiddleware(opts ...IPFilterOption) *IPFilterMiddleware {
+	m := &IPFilterMiddleware{}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *IPFilterMiddleware) denied(ctx *Context) bool {
+	ip := net.ParseIP(ctx.ClientIp)
+	if ip == nil {
+		return len(m.allow) > 0
+	}
+	if len(m.allow) > 0 && !containsIP(m.allow, ip) {
+		return true
+	}
+	if containsIP(m.deny, ip) {
+		return true
+	}
+	// bans apply to bypass tokens as well, a leaked token must not lift them
+	for _, c := range m.checkers {
+		denied, err := c.Denied(ctx, ctx.ClientIp)
+		if err != nil {
+			plog.Errorc(ctx, "ip filter check %s error: %v", ctx.ClientIp, err)
+			continue
+		}
+		if denied {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *IPFilterMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		if m.denied(ctx) {
+			if ctx.router != nil {
+				ctx.router.RecordRejection("ipfilter")
+			}
+			return nil, MsgError(http.StatusForbidden, http.StatusText(http.StatusForbidden)).
+				SetComponent(ErrProuter).
+				SetResponseType(Forbidden)
+		}
+		return handler.Handle(ctx)
+	})
+}
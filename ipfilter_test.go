@@ -0,0 +1,57 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type denyList map[string]bool
+
+func (d denyList) Denied(_ context.Context, ip string) (bool, error) {
+	return d[ip], nil
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	router := New(WithBypassKey([]byte("bypass-key")))
+	router.UseMiddleware(NewIPFilterMiddleware(
+		WithDenyCIDRs("10.0.0.0/8"),
+		WithDenyChecker(denyList{"192.0.2.1": true}),
+	))
+	router.GET("/", func(*Context) (Response, error) { return nil, nil })
+
+	token, err := router.BypassToken("batch", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		ip     string
+		bypass bool
+		code   int
+	}{
+		{"allowed", "192.0.2.2", false, http.StatusOK},
+		{"denied network", "10.1.2.3", false, http.StatusForbidden},
+		{"banned", "192.0.2.1", false, http.StatusForbidden},
+		{"banned with bypass token", "192.0.2.1", true, http.StatusForbidden},
+		{"denied network with bypass token", "10.1.2.3", true, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.ip + ":1234"
+			if tt.bypass {
+				req.Header.Set(ThrottleBypassHeader, token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("code = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func jobError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return prouter.MsgError(http.StatusNotFound, err.Error()).
+			SetComponent(prouter.ErrProuter).
+			SetResponseType(prouter.NotFound)
+	case errors.Is(err, ErrQueueFull):
+		return prouter.MsgError(http.StatusServiceUnavailable, err.Error()).
+			SetComponent(prouter.ErrProuter)
+	}
+	return prouter.NewErr(http.StatusInternalServerError, err).
+		SetComponent(prouter.ErrProuter).
+		SetResponseType(prouter.InternalServerError)
+}
+
+// SubmitHandler submits the JSON body of the request as a job of kind and
+// answers 202 with the job. The status URL of Mount is set as Location and as
+// the status link.
+func (m *Manager) SubmitHandler(kind string) prouter.HandleFunc {
+	return func(ctx *prouter.Context) (prouter.Response, error) {
+		body, err := ctx.BodyBytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			body = nil
+		} else if !json.Valid(body) {
+			return nil, prouter.MsgError(http.StatusBadRequest, "invalid json payload").
+				SetComponent(prouter.ErrProuter).
+				SetResponseType(prouter.BadRequest)
+		}
+
+		j, err := m.Submit(ctx, kind, body)
+		if err != nil {
+			return nil, jobError(err)
+		}
+
+		links := ctx.Links()
+		statusURL, err := ctx.URLFor(m.routeName, "id", j.ID)
+		if err == nil {
+			links.Add("status", statusURL)
+		}
+		resp, err := links.Response(j)
+		if err != nil {
+			return nil, err
+		}
+		resp.SetCode(http.StatusAccepted)
+		if statusURL == "" {
+			return resp, nil
+		}
+		return prouter.ResultOf(resp).WithHeader("Location", statusURL), nil
+	}
+}
+
+// Mount registers the status route GET /{id} in rg, named for the Location of
+// SubmitHandler.
+func (m *Manager) Mount(rg *prouter.RouterGroup) {
+	rg.GET("/{id}", func(ctx *prouter.Context) (prouter.Response, error) {
+		j, err := m.Job(ctx, ctx.Var("id"))
+		if err != nil {
+			return nil, jobError(err)
+		}
+		return prouter.SuccessResponse(j), nil
+	}, prouter.WithName(m.routeName))
+}
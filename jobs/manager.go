@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/prouter/webhook"
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWorkers   = 4
+	defaultRouteName = "jobs.status"
+
+	EventSucceeded = "job.succeeded"
+	EventFailed    = "job.failed"
+)
+
+// Func runs a job of its kind, the result is encoded as JSON into Job.Result.
+type Func func(ctx context.Context, payload json.RawMessage) (any, error)
+
+// Manager runs submitted jobs in a pool of workers:
+//
+//	m := jobs.New(jobs.NewMemoryStore(time.Hour), jobs.NewMemoryQueue(100))
+//	m.Handle("report", buildReport)
+//	router.POST("/reports", m.SubmitHandler("report"))
+//	m.Mount(router.Group("/jobs"))
+//	router.Background("jobs", m.Run)
+type Manager struct {
+	store Store
+	queue Queue
+
+	workers   int
+	timeout   time.Duration
+	notifier  *webhook.Sender
+	routeName string
+
+	mu    sync.RWMutex
+	kinds map[string]Func
+}
+
+type Option func(*Manager)
+
+// WithWorkers sets the jobs run concurrently, 4 by default.
+func WithWorkers(n int) Option {
+	return func(m *Manager) {
+		m.workers = n
+	}
+}
+
+// WithJobTimeout cancels the ctx of a job running longer than d.
+func WithJobTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.timeout = d
+	}
+}
+
+// WithCompletionWebhook sends EventSucceeded and EventFailed with the job to the
+// endpoints of sender subscribed to them.
+func WithCompletionWebhook(sender *webhook.Sender) Option {
+	return func(m *Manager) {
+		m.notifier = sender
+	}
+}
+
+// WithRouteName names the status route of Mount, "jobs.status" by default. Set
+// it when mounting more than one manager.
+func WithRouteName(name string) Option {
+	return func(m *Manager) {
+		m.routeName = name
+	}
+}
+
+func New(store Store, queue Queue, opts ...Option) *Manager {
+	m := &Manager{
+		store:     store,
+		queue:     queue,
+		workers:   defaultWorkers,
+		routeName: defaultRouteName,
+		kinds:     make(map[string]Func),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Handle registers fn as the runner of the jobs of kind.
+func (m *Manager) Handle(kind string, fn Func) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kinds[kind] = fn
+}
+
+func (m *Manager) runner(kind string) (Func, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fn, ok := m.kinds[kind]
+	return fn, ok
+}
+
+// Submit saves a queued job of kind and hands it to the queue.
+func (m *Manager) Submit(ctx context.Context, kind string, payload json.RawMessage) (*Job, error) {
+	if _, ok := m.runner(kind); !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKind, kind)
+	}
+
+	j := &Job{
+		ID:        uuid.NewString(),
+		Kind:      kind,
+		Status:    StatusQueued,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+	if err := m.store.Save(ctx, j); err != nil {
+		return nil, err
+	}
+	if err := m.queue.Enqueue(ctx, j.ID); err != nil {
+		m.finish(ctx, j, nil, err)
+		return nil, err
+	}
+	return j, nil
+}
+
+// Job returns the job id from the store.
+func (m *Manager) Job(ctx context.Context, id string) (*Job, error) {
+	return m.store.Job(ctx, id)
+}
+
+// Run runs the workers until ctx is done and waits for the running jobs.
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range max(m.workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (m *Manager) work(ctx context.Context) {
+	for {
+		id, err := m.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			plog.Errorc(ctx, "jobs dequeue error: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		j, err := m.store.Job(ctx, id)
+		if err != nil {
+			plog.Errorc(ctx, "jobs load %s error: %v", id, err)
+			continue
+		}
+		m.run(ctx, j)
+	}
+}
+
+func (m *Manager) run(ctx context.Context, j *Job) {
+	fn, ok := m.runner(j.Kind)
+	if !ok {
+		m.finish(ctx, j, nil, fmt.Errorf("%w %q", ErrUnknownKind, j.Kind))
+		return
+	}
+
+	now := time.Now()
+	j.Status = StatusRunning
+	j.StartedAt = &now
+	if err := m.store.Save(ctx, j); err != nil {
+		plog.Errorc(ctx, "jobs save %s error: %v", j.ID, err)
+	}
+
+	jobCtx := ctx
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	result, err := func() (result any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panic: %v", r)
+			}
+		}()
+		return fn(jobCtx, j.Payload)
+	}()
+	m.finish(ctx, j, result, err)
+}
+
+func (m *Manager) finish(ctx context.Context, j *Job, result any, err error) {
+	now := time.Now()
+	j.FinishedAt = &now
+	j.Status = StatusSucceeded
+	if err == nil && result != nil {
+		j.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		j.Status = StatusFailed
+		j.Error = err.Error()
+	}
+
+	// the job finished even if the router is stopping, record it
+	ctx = context.WithoutCancel(ctx)
+	if err := m.store.Save(ctx, j); err != nil {
+		plog.Errorc(ctx, "jobs save %s error: %v", j.ID, err)
+	}
+	if m.notifier == nil {
+		return
+	}
+
+	event := EventSucceeded
+	if j.Status == StatusFailed {
+		event = EventFailed
+	}
+	if _, err := m.notifier.Send(ctx, event, j); err != nil && !errors.Is(err, context.Canceled) {
+		plog.Errorc(ctx, "jobs notify %s error: %v", j.ID, err)
+	}
+}
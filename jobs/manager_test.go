@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func waitJob(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j, err := m.Job(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Status.Done() {
+			return j
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still %s", id, j.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	m := New(NewMemoryStore(time.Hour), NewMemoryQueue(10), WithJobTimeout(50*time.Millisecond))
+	m.Handle("echo", func(_ context.Context, payload json.RawMessage) (any, error) {
+		return payload, nil
+	})
+	m.Handle("fail", func(context.Context, json.RawMessage) (any, error) {
+		return nil, errors.New("boom")
+	})
+	m.Handle("panic", func(context.Context, json.RawMessage) (any, error) {
+		panic("bad job")
+	})
+	m.Handle("slow", func(ctx context.Context, _ json.RawMessage) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	tests := []struct {
+		kind    string
+		payload string
+		status  Status
+		result  string
+		err     string
+	}{
+		{"echo", `{"n":1}`, StatusSucceeded, `{"n":1}`, ""},
+		{"fail", "", StatusFailed, "", "boom"},
+		{"panic", "", StatusFailed, "", "job panic: bad job"},
+		{"slow", "", StatusFailed, "", context.DeadlineExceeded.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			j, err := m.Submit(context.Background(), tt.kind, json.RawMessage(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if j.Status != StatusQueued {
+				t.Errorf("submitted status = %s", j.Status)
+			}
+			j = waitJob(t, m, j.ID)
+			if j.Status != tt.status || string(j.Result) != tt.result || j.Error != tt.err {
+				t.Errorf("job = %s %q %q, want %s %q %q", j.Status, j.Result, j.Error, tt.status, tt.result, tt.err)
+			}
+			if j.StartedAt == nil || j.FinishedAt == nil {
+				t.Errorf("StartedAt = %v, FinishedAt = %v", j.StartedAt, j.FinishedAt)
+			}
+		})
+	}
+
+	if _, err := m.Submit(context.Background(), "unknown", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("Submit unknown kind = %v, want %v", err, ErrUnknownKind)
+	}
+}
+
+func TestManagerWorkers(t *testing.T) {
+	const workers = 2
+	var (
+		running, peak atomic.Int32
+		release       = make(chan struct{})
+		started       sync.WaitGroup
+	)
+	m := New(NewMemoryStore(0), NewMemoryQueue(10), WithWorkers(workers))
+	m.Handle("block", func(context.Context, json.RawMessage) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		started.Done()
+		<-release
+		return nil, nil
+	})
+
+	var ids []string
+	for range 5 {
+		j, err := m.Submit(context.Background(), "block", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, j.ID)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	started.Add(workers)
+	started.Wait()
+	// Run waits for the running jobs after ctx is done
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("Run returned with jobs running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-done
+
+	if p := peak.Load(); p != workers {
+		t.Errorf("peak concurrency = %d, want %d", p, workers)
+	}
+	// the queued jobs are left for the next Run
+	finished := 0
+	for _, id := range ids {
+		if j, _ := m.Job(context.Background(), id); j.Status.Done() {
+			finished++
+		}
+	}
+	if finished != workers {
+		t.Errorf("finished = %d, want %d", finished, workers)
+	}
+
+	started.Add(len(ids) - workers)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+	for _, id := range ids {
+		waitJob(t, m, id)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	m := New(NewMemoryStore(time.Hour), NewMemoryQueue(1))
+	m.Handle("report", func(context.Context, json.RawMessage) (any, error) {
+		return "done", nil
+	})
+
+	router := prouter.New()
+	router.POST("/reports", m.SubmitHandler("report"))
+	m.Mount(router.Group("/jobs"))
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := submit("{bad"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid payload: code = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec := submit(`{"month":"2024-01"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("submit: code = %d: %s", rec.Code, rec.Body)
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/jobs/") {
+		t.Fatalf("Location = %q", location)
+	}
+
+	// nothing consumes the queue of size 1
+	if rec := submit(`{}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("full queue: code = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+	waitJob(t, m, strings.TrimPrefix(location, "/jobs/"))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+	var body struct {
+		Data Job `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Status != StatusSucceeded || string(body.Data.Result) != `"done"` {
+		t.Errorf("status = %s, result = %s", body.Data.Status, body.Data.Result)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing job: code = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("jobs: not found")
+	ErrQueueFull   = errors.New("jobs: queue is full")
+	ErrUnknownKind = errors.New("jobs: unknown kind")
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Done reports whether the job finished, successfully or not.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+type Job struct {
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	Status     Status          `json:"status"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Store persists the jobs, it is read by the status route and written by the workers.
+type Store interface {
+	Save(ctx context.Context, j *Job) error
+	Job(ctx context.Context, id string) (*Job, error)
+}
+
+// Queue hands the ids of submitted jobs to the workers. Dequeue blocks until a
+// job is queued or ctx is done.
+type Queue interface {
+	Enqueue(ctx context.Context, id string) error
+	Dequeue(ctx context.Context) (string, error)
+}
+
+// MemoryStore keeps the jobs in memory, for tests and single instance setups.
+// Finished jobs are kept for retention, or forever if it is not positive.
+type MemoryStore struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	retention time.Duration
+	lastSweep time.Time
+}
+
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job), retention: retention}
+}
+
+func cloneJob(j *Job) *Job {
+	c := *j
+	return &c
+}
+
+func (s *MemoryStore) Save(_ context.Context, j *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); s.retention > 0 && now.Sub(s.lastSweep) >= time.Minute {
+		s.lastSweep = now
+		for id, old := range s.jobs {
+			if old.FinishedAt != nil && now.Sub(*old.FinishedAt) > s.retention {
+				delete(s.jobs, id)
+			}
+		}
+	}
+	s.jobs[j.ID] = cloneJob(j)
+	return nil
+}
+
+func (s *MemoryStore) Job(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneJob(j), nil
+}
+
+// MemoryQueue is a buffered channel of job ids, jobs queued in it are lost on restart.
+type MemoryQueue struct {
+	ids chan string
+}
+
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{ids: make(chan string, size)}
+}
+
+func (q *MemoryQueue) Enqueue(_ context.Context, id string) error {
+	select {
+	case q.ids <- id:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *MemoryQueue) Dequeue(ctx context.Context) (string, error) {
+	// a worker told to stop must not take a job it will not run
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	select {
+	case id := <-q.ids:
+		return id, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,48 @@
+package prouter
+
+import (
+	"errors"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// ErrWriteAfterResponse is returned by the writes of ResponseWriter once the
+// request completed, e.g. from a goroutine of the handler or an OnFinish
+// callback, the bytes are dropped instead of being appended to the envelope.
+var ErrWriteAfterResponse = errors.New("write after the response was written")
+
+// completedResponse records which route finished the response, for the
+// diagnostic of a late write
+type completedResponse struct {
+	route    string
+	handler  string
+	reported atomic.Bool
+}
+
+// complete marks the response written, later writes are rejected
+func (w *ResponseWriter) complete(route, handler string) {
+	w.completed.Store(&completedResponse{route: route, handler: handler})
+}
+
+// lateWrite reports whether the response completed, it logs the first late
+// write of a response, with its stack in DebugMode
+func (w *ResponseWriter) lateWrite(op string) bool {
+	c := w.completed.Load()
+	if c == nil {
+		return false
+	}
+	if !c.reported.CompareAndSwap(false, true) {
+		return true
+	}
+
+	if prouterMode == DebugMode {
+		plog.Errorf("handler %s of %s called %s after the response was written, the write is dropped:\n%s",
+			c.handler, c.route, op, debug.Stack())
+	} else {
+		plog.Errorf("handler %s of %s called %s after the response was written, the write is dropped",
+			c.handler, c.route, op)
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package prouter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const (
+	leakLabel = "prouter_request"
+	leakGrace = 100 * time.Millisecond
+)
+
+var leakRequestID atomic.Uint64
+
+// WithLeakDetection warns in DebugMode about handlers which return without
+// reading the request body or leave goroutines running they started, e.g. a
+// loop on a ticker which is never stopped. Goroutines are followed through a
+// pprof label and looked up shortly after the request, which is too costly for
+// production and has no effect in ReleaseMode.
+func WithLeakDetection() RouterOption {
+	return func(v *Prouter) {
+		v.leakDetection = true
+	}
+}
+
+type leakDetector struct {
+	handler handlerFunc
+}
+
+func (d *leakDetector) Name() string {
+	return d.handler.Name()
+}
+
+func (d *leakDetector) Handle(ctx *Context) (resp Response, err error) {
+	if prouterMode != DebugMode {
+		return d.handler.Handle(ctx)
+	}
+
+	id := strconv.FormatUint(leakRequestID.Add(1), 10)
+	pprof.Do(ctx.Context, pprof.Labels(leakLabel, id), func(context.Context) {
+		resp, err = d.handler.Handle(ctx)
+	})
+
+	if ctx.body != nil && !ctx.body.eof.Load() &&
+		(ctx.Request.ContentLength < 0 || ctx.body.n.Load() < ctx.Request.ContentLength) {
+		plog.Warnc(ctx, "handler %s returned without reading the request body of %s", d.handler.Name(), ctx.RouteTemplate())
+	}
+
+	route, name := ctx.RouteTemplate(), d.handler.Name()
+	time.AfterFunc(leakGrace, func() {
+		if n, stack := labeledGoroutines(leakLabel, id); n > 0 {
+			plog.Warnf("handler %s of %s leaked %d goroutine(s) still running %v after the request:\n%s",
+				name, route, n, leakGrace, stack)
+		}
+	})
+	return resp, err
+}
+
+// labeledGoroutines counts the goroutines labeled key=value and returns the stack of the first
+func labeledGoroutines(key, value string) (int, string) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return 0, ""
+	}
+
+	// the profile lists "N @ addrs", "# labels: {...}" and the frames per stack, separated by blank lines
+	label := strconv.Quote(key) + ":" + strconv.Quote(value)
+	var (
+		total int
+		first string
+	)
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	var block []string
+	flush := func() {
+		if len(block) > 1 && strings.HasPrefix(block[1], "# labels:") && strings.Contains(block[1], label) {
+			count, _, _ := strings.Cut(block[0], " ")
+			n, _ := strconv.Atoi(count)
+			total += n
+			if first == "" {
+				first = strings.Join(block[2:], "\n")
+			}
+		}
+		block = block[:0]
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+	return total, first
+}
@@ -0,0 +1,122 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Links is the hypermedia section of a response, keyed by relation like self, next or prev.
+type Links map[string]string
+
+// LinksResponse is implemented by response templates with a links section, templates
+// without one drop the links.
+type LinksResponse interface {
+	SetLinks(Links) Response
+	GetLinks() Links
+}
+
+// URLFor builds the path of the route registered WithName(name), pairs fill its
+// variables: URLFor("user", "id", "42").
+func (v *Prouter) URLFor(name string, pairs ...string) (string, error) {
+	r := v.router.Get(name)
+	if r == nil {
+		return "", errors.New("prouter: no route named " + name)
+	}
+
+	u, err := r.URLPath(pairs...)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (c *Context) URLFor(name string, pairs ...string) (string, error) {
+	if c.router == nil {
+		return "", errors.New("prouter: context has no router")
+	}
+	return c.router.URLFor(name, pairs...)
+}
+
+// LinkBuilder collects the links of a response, the first failure is returned by Build.
+type LinkBuilder struct {
+	ctx   *Context
+	links Links
+	err   error
+}
+
+// Links starts the links of the current response:
+//
+//	return ctx.Links().Self().Page(spec.Offset, spec.Limit, total).Related("owner", "user", "id", uid).Response(items)
+func (c *Context) Links() *LinkBuilder {
+	return &LinkBuilder{ctx: c, links: make(Links)}
+}
+
+func (b *LinkBuilder) Add(rel, href string) *LinkBuilder {
+	b.links[rel] = href
+	return b
+}
+
+// Self links the requested URL.
+func (b *LinkBuilder) Self() *LinkBuilder {
+	return b.Add("self", b.ctx.Request.URL.RequestURI())
+}
+
+// Related links the named route under rel.
+func (b *LinkBuilder) Related(rel, name string, pairs ...string) *LinkBuilder {
+	href, err := b.ctx.URLFor(name, pairs...)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.Add(rel, href)
+}
+
+func (b *LinkBuilder) pageURL(offset, limit int) string {
+	u := *b.ctx.Request.URL
+	query := u.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// Page adds next and prev to the requested URL with offset and limit moved by one
+// page, a negative total means the total is unknown and next is always linked.
+func (b *LinkBuilder) Page(offset, limit, total int) *LinkBuilder {
+	if limit <= 0 {
+		return b
+	}
+	if total < 0 || offset+limit < total {
+		b.Add("next", b.pageURL(offset+limit, limit))
+	}
+	if offset > 0 {
+		b.Add("prev", b.pageURL(max(offset-limit, 0), limit))
+	}
+	return b
+}
+
+func (b *LinkBuilder) Build() (Links, error) {
+	if b.err != nil {
+		return nil, NewErr(http.StatusInternalServerError, b.err, "build links failed").
+			SetComponent(ErrProuter).
+			SetResponseType(InternalServerError)
+	}
+	return b.links, nil
+}
+
+// Response returns a success response of data with the links.
+func (b *LinkBuilder) Response(data any) (Response, error) {
+	links, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := SuccessResponse(data)
+	if lr, ok := resp.(LinksResponse); ok && len(links) > 0 {
+		lr.SetLinks(links)
+	}
+	return resp, nil
+}
@@ -0,0 +1,93 @@
+package prouter
+
+import (
+	"strings"
+)
+
+// LocalizeMiddleware translates the string fields of the response data tagged
+// `i18n:"key"` with the Localizer of the router, see WithLocalizer. A key ending
+// in ".*" takes the field value in place of the "*" which suits enums:
+//
+//	type Order struct {
+//		Title  string `i18n:"order.title"`
+//		Status string `i18n:"order.status.*"`
+//	}
+//
+// Fields without a translation keep their value.
+type LocalizeMiddleware struct {
+	localeFunc func(ctx *Context) string
+}
+
+type LocalizeOption func(*LocalizeMiddleware)
+
+// WithLocaleFunc resolves the locale of the request, e.g. from the user profile,
+// an empty locale falls back to Accept-Language.
+func WithLocaleFunc(fn func(ctx *Context) string) LocalizeOption {
+	return func(m *LocalizeMiddleware) {
+		m.localeFunc = fn
+	}
+}
+
+func NewLocalizeMiddleware(opts ...LocalizeOption) *LocalizeMiddleware {
+	m := &LocalizeMiddleware{}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *LocalizeMiddleware) languages(ctx *Context) []string {
+	langs := acceptLanguages(ctx.Request)
+	if m.localeFunc != nil {
+		if locale := m.localeFunc(ctx); locale != "" {
+			langs = append([]string{locale}, langs...)
+		}
+	}
+	return langs
+}
+
+func (m *LocalizeMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		resp, err := handler.Handle(ctx)
+		if err != nil || resp == nil || resp.GetData() == nil || ctx.router == nil || ctx.router.localizer == nil {
+			return resp, err
+		}
+
+		langs := m.languages(ctx)
+		if len(langs) == 0 {
+			return resp, err
+		}
+
+		resp.SetData(rewriteTagged(resp.GetData(), "i18n", func(key, value string) string {
+			if prefix, found := strings.CutSuffix(key, ".*"); found {
+				key = prefix + "." + value
+			}
+			if msg, ok := ctx.router.localize(langs, MessageKey(key)); ok {
+				return msg
+			}
+			return value
+		}))
+		return resp, err
+	})
+}
+
+// localize looks key up in langs, falling back from a region tag like zh-CN to zh
+func (v *Prouter) localize(langs []string, key MessageKey) (string, bool) {
+	if v.localizer == nil {
+		return "", false
+	}
+
+	for _, lang := range langs {
+		if msg, ok := v.localizer.Localize(lang, key); ok {
+			return msg, true
+		}
+		if base, _, found := strings.Cut(lang, "-"); found {
+			if msg, ok := v.localizer.Localize(base, key); ok {
+				return msg, true
+			}
+		}
+	}
+	return "", false
+}
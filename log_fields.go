@@ -0,0 +1,53 @@
+package prouter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// LogField extracts a key and value added to the access log of a request, a
+// nil value or an empty string leaves the key out. It runs once the response
+// is written, so it sees the values set by the middlewares and the handler.
+type LogField func(ctx *Context) (string, any)
+
+// WithLogFields adds fields to every access log line, e.g. to trace the requests
+// of a user:
+//
+//	prouter.NewLogMiddleware(prouter.WithLogFields(prouter.SessionIDField(), prouter.PrincipalField("userId")))
+func WithLogFields(fields ...LogField) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.fields = append(lm.fields, fields...)
+	}
+}
+
+// SessionIDField logs the session id as "sessionId", hashed so the log does not
+// hold a usable session. Stores keeping the session in the cookie have no id.
+func SessionIDField() LogField {
+	return func(ctx *Context) (string, any) {
+		id := ctx.session.ID()
+		if id == "" {
+			return "sessionId", nil
+		}
+		sum := sha256.Sum256([]byte(id))
+		return "sessionId", hex.EncodeToString(sum[:8])
+	}
+}
+
+// PrincipalField logs the principal authorized by the authz middleware under key.
+func PrincipalField(key string) LogField {
+	return func(ctx *Context) (string, any) {
+		return key, Principal(ctx)
+	}
+}
+
+func (lm *LogMiddleware) fieldArgs(ctx *Context) []any {
+	var args []any
+	for _, field := range lm.fields {
+		key, value := field(ctx)
+		if value == nil || value == "" {
+			continue
+		}
+		args = append(args, key, value)
+	}
+	return args
+}
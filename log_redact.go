@@ -0,0 +1,183 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// logRedactor scrubs headers, JSON body fields and pattern matches before a request is logged
+type logRedactor struct {
+	headers   map[string]bool
+	jsonPaths [][]string
+	patterns  []*regexp.Regexp
+}
+
+func newLogRedactor() *logRedactor {
+	r := &logRedactor{headers: make(map[string]bool)}
+	for _, h := range defaultRedactHeaders {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	return r
+}
+
+// WithRequestDump logs the headers, query and up to maxBody bytes of the request
+// body along with every request, they are redacted by the WithRedact options first.
+func WithRequestDump(maxBody int) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.dumpBody = maxBody
+		lm.dump = true
+	}
+}
+
+// WithRedactHeaders adds header names whose values are never logged,
+// Authorization, Cookie and the like are redacted by default.
+func WithRedactHeaders(names ...string) LogOption {
+	return func(lm *LogMiddleware) {
+		for _, name := range names {
+			lm.redactor.headers[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// WithRedactJSONPaths redacts fields of JSON bodies by dotted path, "*" matches
+// any key or array index: "password", "user.token", "cards.*.number". Dumped
+// bodies which are truncated or not JSON are not logged then.
+func WithRedactJSONPaths(paths ...string) LogOption {
+	return func(lm *LogMiddleware) {
+		for _, p := range paths {
+			lm.redactor.jsonPaths = append(lm.redactor.jsonPaths, strings.Split(p, "."))
+		}
+	}
+}
+
+// WithRedactPatterns replaces the matches of patterns in the query, header values
+// and body, e.g. regexp.MustCompile(`\d{13,19}`) for card numbers.
+func WithRedactPatterns(patterns ...*regexp.Regexp) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.redactor.patterns = append(lm.redactor.patterns, patterns...)
+	}
+}
+
+func (r *logRedactor) text(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+func (r *logRedactor) header(h http.Header) map[string]string {
+	ret := make(map[string]string, len(h))
+	for k, v := range h {
+		if r.headers[k] {
+			ret[k] = redacted
+			continue
+		}
+		ret[k] = r.text(strings.Join(v, ", "))
+	}
+	return ret
+}
+
+func redactPath(v any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	last := len(path) == 1
+
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			if last {
+				val[k] = redacted
+			} else {
+				redactPath(child, path[1:])
+			}
+		}
+	case []any:
+		for i, child := range val {
+			if path[0] != "*" {
+				// a key applies to every element as well, "items.token" equals "items.*.token"
+				redactPath(child, path)
+				continue
+			}
+			if last {
+				val[i] = redacted
+			} else {
+				redactPath(child, path[1:])
+			}
+		}
+	}
+}
+
+// body redacts the JSON paths of a complete JSON body, a body they cannot be
+// applied to, truncated or not JSON, is replaced by the redaction marker. Without
+// JSON paths only the patterns are applied.
+func (r *logRedactor) body(contentType string, body []byte, truncated bool) string {
+	if len(r.jsonPaths) == 0 {
+		return r.text(string(body))
+	}
+	if truncated || !strings.HasSuffix(contentType, "json") {
+		return redacted
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redacted
+	}
+	for _, p := range r.jsonPaths {
+		redactPath(v, p)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return redacted
+	}
+	return r.text(string(b))
+}
+
+// peekBody reads up to n bytes of the request body and puts them back for the handler
+func peekBody(req *http.Request, n int) ([]byte, bool) {
+	if n <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil, false
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(req.Body, int64(n)+1))
+	truncated := len(buf) > n
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+	if err != nil {
+		return nil, false
+	}
+	if truncated {
+		buf = buf[:n]
+	}
+	return buf, truncated
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (lm *LogMiddleware) dumpArgs(ctx *Context, body []byte, truncated bool) []any {
+	r := ctx.Request
+	args := []any{"headers", lm.redactor.header(r.Header)}
+	if r.URL.RawQuery != "" {
+		args = append(args, "query", lm.redactor.text(r.URL.RawQuery))
+	}
+	if len(body) > 0 {
+		args = append(args, "body", lm.redactor.body(contentType(r), body, truncated))
+		if truncated {
+			args = append(args, "bodyTruncated", true)
+		}
+	}
+	return args
+}
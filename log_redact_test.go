@@ -0,0 +1,43 @@
+package prouter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogRedactorBody(t *testing.T) {
+	withPaths := newLogRedactor()
+	withPaths.jsonPaths = [][]string{{"password"}, {"cards", "*", "number"}}
+	withPaths.patterns = []*regexp.Regexp{regexp.MustCompile(`\d{13,19}`)}
+
+	patternsOnly := newLogRedactor()
+	patternsOnly.patterns = withPaths.patterns
+
+	tests := []struct {
+		name        string
+		redactor    *logRedactor
+		contentType string
+		body        string
+		truncated   bool
+		want        string
+	}{
+		{"json", withPaths, "application/json", `{"password":"hunter2","cards":[{"number":"1"}],"user":"bob"}`, false,
+			`{"cards":[{"number":"[REDACTED]"}],"password":"[REDACTED]","user":"bob"}`},
+		{"truncated json", withPaths, "application/json", `{"password":"hunter2","us`, true, redacted},
+		{"invalid json", withPaths, "application/json", `{"password":"hunter2"`, false, redacted},
+		{"form", withPaths, "application/x-www-form-urlencoded", `password=hunter2`, false, redacted},
+		{"patterns only", patternsOnly, "text/plain", `card 4111111111111111`, false, "card " + redacted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.redactor.body(tt.contentType, []byte(tt.body), tt.truncated)
+			if got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+			if strings.Contains(got, "hunter2") {
+				t.Errorf("password leaked: %s", got)
+			}
+		})
+	}
+}
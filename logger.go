@@ -5,7 +5,6 @@ import (
 	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/go-puzzles/puzzles/plog"
 )
@@ -25,6 +24,11 @@ const (
 
 type LogMiddleware struct {
 	logger plog.Logger
+
+	dump     bool
+	dumpBody int
+	redactor *logRedactor
+	fields   []LogField
 }
 
 type LogOption func(*LogMiddleware)
@@ -37,7 +41,8 @@ func WithLogger(l plog.Logger) LogOption {
 
 func NewLogMiddleware(opts ...LogOption) *LogMiddleware {
 	lm := &LogMiddleware{
-		logger: plog.GetLogger(),
+		logger:   plog.GetLogger(),
+		redactor: newLogRedactor(),
 	}
 
 	for _, opt := range opts {
@@ -64,8 +69,8 @@ func (lm *LogMiddleware) ClientIP(r *http.Request) string {
 	return remoteIP.String()
 }
 
-func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
-	spendTime := time.Since(ctx.startTime)
+func (lm *LogMiddleware) log(ctx *Context, resp Response, err error, dump []any) {
+	spendTime := ctx.Now().Sub(ctx.startTime)
 
 	statusCode := ctx.Writer.StatusCode()
 	if err != nil && statusCode == http.StatusOK {
@@ -74,7 +79,7 @@ func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
 
 	var logFunc func(ctx context.Context, msg string, v ...any)
 	switch {
-	case statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices:
+	case statusCode == http.StatusSwitchingProtocols, statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices:
 		logFunc = lm.logger.Infoc
 	case statusCode >= http.StatusMultipleChoices && statusCode < http.StatusBadRequest:
 		logFunc = lm.logger.Warnc
@@ -85,11 +90,19 @@ func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
 	}
 
 	args := []any{
-		ctx.Path,
+		lm.redactor.text(ctx.Path),
+		"route", ctx.RouteTemplate(),
 		"statusCode", statusCode,
 		"duration", spendTime,
 		"clientIp", ctx.ClientIp,
 		"method", ctx.Method,
+		"bytesIn", ctx.RequestSize(),
+		"bytesOut", ctx.Writer.Size(),
+	}
+
+	args = append(args, lm.fieldArgs(ctx)...)
+	if id := ctx.TraceID(); id != "" {
+		args = append(args, "traceId", id)
 	}
 
 	if err != nil {
@@ -99,6 +112,8 @@ func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
 		}
 	}
 
+	args = append(args, dump...)
+
 	logFunc(ctx, "handle path: %v.", args...)
 }
 
@@ -107,10 +122,20 @@ func (lm *LogMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 		var (
 			resp Response
 			err  error
+			dump []any
 		)
-		defer func() {
-			lm.log(ctx, resp, err)
-		}()
+		switch {
+		case lm.dump:
+			body, truncated := peekBody(ctx.Request, lm.dumpBody)
+			dump = lm.dumpArgs(ctx, body, truncated)
+		case ctx.Verbose():
+			body, truncated := peekBody(ctx.Request, verboseDumpBody)
+			dump = lm.dumpArgs(ctx, body, truncated)
+		}
+		// logged once the response is written to include its size
+		ctx.Writer.OnFinish(func() {
+			lm.log(ctx, resp, err, dump)
+		})
 
 		resp, err = handler.Handle(ctx)
 
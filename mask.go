@@ -0,0 +1,150 @@
+package prouter
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// MaskFunc redacts a sensitive value.
+type MaskFunc func(value string) string
+
+// MaskMiddleware redacts the string and *string fields of the response data tagged with
+// `mask:"strategy"`, so PII handling does not depend on every handler. Roles
+// listed after the strategy see the field in clear as do the roles given by
+// WithUnmaskedRoles:
+//
+//	type User struct {
+//		Email string `mask:"email"`
+//		Card  string `mask:"pan,billing"`
+//	}
+//
+// The built-in strategies are email, pan, last4 and full, an unknown strategy masks fully.
+type MaskMiddleware struct {
+	strategies map[string]MaskFunc
+	roles      func(ctx *Context) []string
+	unmasked   []string
+}
+
+type MaskOption func(*MaskMiddleware)
+
+// WithMaskStrategy adds or overrides the strategy name.
+func WithMaskStrategy(name string, fn MaskFunc) MaskOption {
+	return func(m *MaskMiddleware) {
+		m.strategies[name] = fn
+	}
+}
+
+// WithRoleFunc returns the roles of the caller, without it every field is masked.
+func WithRoleFunc(fn func(ctx *Context) []string) MaskOption {
+	return func(m *MaskMiddleware) {
+		m.roles = fn
+	}
+}
+
+// WithUnmaskedRoles lets roles see every masked field in clear, e.g. an admin.
+func WithUnmaskedRoles(roles ...string) MaskOption {
+	return func(m *MaskMiddleware) {
+		m.unmasked = append(m.unmasked, roles...)
+	}
+}
+
+func NewMaskMiddleware(opts ...MaskOption) *MaskMiddleware {
+	m := &MaskMiddleware{
+		strategies: map[string]MaskFunc{
+			"email": MaskEmail,
+			"pan":   MaskPAN,
+			"last4": MaskLast4,
+			"full":  MaskFull,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *MaskMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		resp, err := handler.Handle(ctx)
+		if resp == nil || resp.GetData() == nil {
+			return resp, err
+		}
+
+		var roles []string
+		if m.roles != nil {
+			roles = m.roles(ctx)
+		}
+		for _, role := range roles {
+			if slices.Contains(m.unmasked, role) {
+				return resp, err
+			}
+		}
+
+		resp.SetData(rewriteTagged(resp.GetData(), "mask", func(tag, value string) string {
+			strategy, allowed, _ := strings.Cut(tag, ",")
+			for _, role := range strings.Split(allowed, ",") {
+				if role != "" && slices.Contains(roles, role) {
+					return value
+				}
+			}
+
+			fn, ok := m.strategies[strategy]
+			if !ok {
+				fn = MaskFull
+			}
+			return fn(value)
+		}))
+		return resp, err
+	})
+}
+
+func MaskFull(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "****"
+}
+
+// MaskEmail keeps the first letter and the domain: j***@example.com.
+func MaskEmail(value string) string {
+	local, domain, found := strings.Cut(value, "@")
+	if !found || local == "" {
+		return MaskFull(value)
+	}
+	r := []rune(local)
+	return string(r[0]) + "***@" + domain
+}
+
+// MaskPAN keeps the last four digits of a card number and its separators: **** **** **** 4242.
+func MaskPAN(value string) string {
+	digits := 0
+	for _, r := range value {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range value {
+		if unicode.IsDigit(r) {
+			if digits > 4 {
+				r = '*'
+			}
+			digits--
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// MaskLast4 keeps the last four characters, e.g. of a phone number.
+func MaskLast4(value string) string {
+	r := []rune(value)
+	if len(r) <= 4 {
+		return MaskFull(value)
+	}
+	return strings.Repeat("*", len(r)-4) + string(r[len(r)-4:])
+}
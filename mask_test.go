@@ -0,0 +1,98 @@
+package prouter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type maskedContact struct {
+	Email string `json:"email" mask:"email"`
+}
+
+type maskedUser struct {
+	maskedContact
+	Phone *string `json:"phone" mask:"full"`
+	Card  string  `json:"card" mask:"pan,billing"`
+}
+
+type maskedNode struct {
+	Secret string      `json:"secret" mask:"full"`
+	Next   *maskedNode `json:"next,omitempty"`
+}
+
+func maskedChain(n int) *maskedNode {
+	var head *maskedNode
+	for range n {
+		head = &maskedNode{Secret: "s3cr3t", Next: head}
+	}
+	return head
+}
+
+func TestMaskMiddleware(t *testing.T) {
+	phone := "+15550100"
+	user := maskedUser{
+		maskedContact: maskedContact{Email: "jane@example.com"},
+		Phone:         &phone,
+		Card:          "4111111111111111",
+	}
+
+	tests := []struct {
+		name  string
+		data  any
+		roles []string
+		leaks []string
+		want  []string
+	}{
+		{
+			name:  "unexported embedded struct and *string",
+			data:  user,
+			leaks: []string{"jane@", "+15550100", "4111111111111111"},
+			want:  []string{"j***@example.com"},
+		},
+		{
+			name:  "allowed role",
+			data:  &user,
+			roles: []string{"billing"},
+			leaks: []string{"jane@", "+15550100"},
+			want:  []string{"4111111111111111"},
+		},
+		{
+			name:  "deeper than the rewrite limit",
+			data:  maskedChain(2 * maxRewriteDepth),
+			leaks: []string{"s3cr3t"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles := tt.roles
+			mw := NewMaskMiddleware(WithRoleFunc(func(*Context) []string { return roles }))
+			handler := mw.WrapHandler(HandleFunc(func(*Context) (Response, error) {
+				return SuccessResponse(tt.data), nil
+			}))
+
+			resp, err := handler.Handle(&Context{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, leak := range tt.leaks {
+				if strings.Contains(string(body), leak) {
+					t.Errorf("%s leaked in %s", leak, body)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("%s missing in %s", want, body)
+				}
+			}
+		})
+	}
+
+	if phone != "+15550100" || user.Email != "jane@example.com" {
+		t.Errorf("masking changed the handler values")
+	}
+}
@@ -0,0 +1,114 @@
+package prouter
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type MessageKey string
+
+const (
+	MsgPageNotFound        MessageKey = "prouter.page_not_found"
+	MsgMethodNotAllowed    MessageKey = "prouter.method_not_allowed"
+	MsgInternalServerError MessageKey = "prouter.internal_server_error"
+	MsgShuttingDown        MessageKey = "prouter.shutting_down"
+)
+
+var defaultMessages = map[MessageKey]string{
+	MsgPageNotFound:        "page not found",
+	MsgMethodNotAllowed:    "method not allowed",
+	MsgInternalServerError: "internal server error",
+	MsgShuttingDown:        "server is shutting down",
+}
+
+// Localizer resolves a message key for the given language tag, e.g. "zh-CN".
+type Localizer interface {
+	Localize(lang string, key MessageKey) (string, bool)
+}
+
+// MessageCatalog is a simple in-memory Localizer indexed by language tag.
+type MessageCatalog map[string]map[MessageKey]string
+
+func (c MessageCatalog) Localize(lang string, key MessageKey) (string, bool) {
+	msgs, ok := c[lang]
+	if !ok {
+		return "", false
+	}
+	msg, ok := msgs[key]
+	return msg, ok
+}
+
+func WithLocalizer(l Localizer) RouterOption {
+	return func(v *Prouter) {
+		v.localizer = l
+	}
+}
+
+// WithMessage overrides the fallback message of key which is used
+// when no localized message matches the request.
+func WithMessage(key MessageKey, msg string) RouterOption {
+	return func(v *Prouter) {
+		if v.messages == nil {
+			v.messages = make(map[MessageKey]string)
+		}
+		v.messages[key] = msg
+	}
+}
+
+func (v *Prouter) message(r *http.Request, key MessageKey) (string, bool) {
+	if msg, ok := v.localize(acceptLanguages(r), key); ok {
+		return msg, true
+	}
+
+	if msg, ok := v.messages[key]; ok {
+		return msg, true
+	}
+
+	msg, ok := defaultMessages[key]
+	return msg, ok
+}
+
+// acceptLanguages returns the languages of the Accept-Language header ordered by quality.
+func acceptLanguages(r *http.Request) []string {
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return nil
+	}
+
+	type langQ struct {
+		lang string
+		q    float64
+	}
+
+	var langs []langQ
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		langs = append(langs, langQ{lang: tag, q: q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	ret := make([]string, 0, len(langs))
+	for _, l := range langs {
+		ret = append(ret, l.lang)
+	}
+	return ret
+}
@@ -0,0 +1,36 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDefaultMessages(t *testing.T) {
+	router := NewProuter()
+	router.GET("/users", func(*Context) (Response, error) { return nil, nil })
+	router.GET("/panic", func(*Context) (Response, error) { panic("secret detail") })
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound, "page not found"},
+		{http.MethodPost, "/users", http.StatusMethodNotAllowed, "method not allowed"},
+		{http.MethodGet, "/panic", http.StatusInternalServerError, "internal server error"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.code || !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s %s = %d %s, want %d with %q", tt.method, tt.path, rec.Code, rec.Body, tt.code, tt.body)
+		}
+		if strings.Contains(rec.Body.String(), "secret detail") {
+			t.Errorf("%s %s leaks the panic: %s", tt.method, tt.path, rec.Body)
+		}
+	}
+}
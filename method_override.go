@@ -0,0 +1,106 @@
+package prouter
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultMethodOverrideHeader = "X-HTTP-Method-Override"
+	defaultMethodOverrideField  = "_method"
+)
+
+// MethodOverride rewrites the method of POST requests to the one given by the
+// override header or form field. It runs before route matching, so the
+// request is dispatched to the PUT/PATCH/DELETE route directly.
+type MethodOverride struct {
+	header    string
+	formField string
+	methods   []string
+}
+
+type MethodOverrideOption func(*MethodOverride)
+
+// WithOverrideHeader sets the header name to read the method from, an empty name disables it.
+func WithOverrideHeader(header string) MethodOverrideOption {
+	return func(m *MethodOverride) {
+		m.header = header
+	}
+}
+
+// WithOverrideFormField sets the form field to read the method from, an empty name disables it.
+func WithOverrideFormField(field string) MethodOverrideOption {
+	return func(m *MethodOverride) {
+		m.formField = field
+	}
+}
+
+// WithOverrideMethods sets the methods which a POST request is allowed to be overridden to.
+func WithOverrideMethods(methods ...string) MethodOverrideOption {
+	return func(m *MethodOverride) {
+		m.methods = m.methods[:0]
+		for _, method := range methods {
+			m.methods = append(m.methods, strings.ToUpper(method))
+		}
+	}
+}
+
+func NewMethodOverride(opts ...MethodOverrideOption) *MethodOverride {
+	m := &MethodOverride{
+		header:    defaultMethodOverrideHeader,
+		formField: defaultMethodOverrideField,
+		methods:   []string{http.MethodPut, http.MethodPatch, http.MethodDelete},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func WithMethodOverride(opts ...MethodOverrideOption) RouterOption {
+	return func(v *Prouter) {
+		v.methodOverride = NewMethodOverride(opts...)
+	}
+}
+
+func (m *MethodOverride) method(r *http.Request) string {
+	if m.header != "" {
+		if method := r.Header.Get(m.header); method != "" {
+			return strings.ToUpper(method)
+		}
+	}
+
+	if m.formField != "" {
+		ct := contentType(r)
+		if ct == "application/x-www-form-urlencoded" || ct == "multipart/form-data" {
+			return strings.ToUpper(r.PostFormValue(m.formField))
+		}
+	}
+
+	return ""
+}
+
+// Override returns the request with its method replaced when an allowed override is present.
+func (m *MethodOverride) Override(r *http.Request) *http.Request {
+	if r.Method != http.MethodPost {
+		return r
+	}
+
+	method := m.method(r)
+	if method == "" || !slices.Contains(m.methods, method) {
+		return r
+	}
+
+	r.Method = method
+	return r
+}
+
+// Handler wraps next with the method override, for use outside of Prouter.
+func (m *MethodOverride) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, m.Override(r))
+	})
+}
@@ -0,0 +1,311 @@
+package prouter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sloBuckets = 12
+	// minSLOWindow keeps the buckets of the window at least 1ms wide
+	minSLOWindow = sloBuckets * time.Millisecond
+	// latencyBudget is the share of requests allowed to exceed the p99 target
+	latencyBudget = 0.01
+)
+
+// SLO is the objective of a route, a request is bad if it fails with a 5xx or
+// is slower than LatencyP99.
+type SLO struct {
+	LatencyP99 time.Duration
+	// ErrorBudget is the share of requests allowed to fail, e.g. 0.001 for 99.9%
+	ErrorBudget float64
+}
+
+// WithSLO declares the objective of the route for the MetricsMiddleware.
+func WithSLO(latencyP99 time.Duration, errorBudget float64) RouteOption {
+	return func(c *routeConfig) {
+		c.slo = &SLO{LatencyP99: latencyP99, ErrorBudget: errorBudget}
+	}
+}
+
+// SLOStatus is the state of a route over the SLO window
+type SLOStatus struct {
+	Route    string
+	SLO      *SLO
+	Requests uint64
+	Errors   uint64
+	Slow     uint64
+	// BurnRate is how fast the budget is consumed, 1 spends it exactly over the window
+	BurnRate float64
+}
+
+type SLOAlertFunc func(status SLOStatus)
+
+type sliBucket struct {
+	idx                 int64
+	total, errors, slow uint64
+}
+
+type routeSLI struct {
+	mu  sync.Mutex
+	slo *SLO
+
+	total, errors, slow uint64
+	buckets             [sloBuckets]sliBucket
+	lastAlert           time.Time
+}
+
+// MetricsMiddleware records the SLI series of every route and the burn rate of
+// the routes declared WithSLO.
+type MetricsMiddleware struct {
+	window    time.Duration
+	threshold float64
+	alert     SLOAlertFunc
+
+	clock Clock
+	// router is the last router a request was served by, its clock is the
+	// default of the SLO window
+	router atomic.Pointer[Prouter]
+
+	mu     sync.RWMutex
+	routes map[string]*routeSLI
+}
+
+type MetricsOption func(*MetricsMiddleware)
+
+// WithSLOWindow sets the rolling window the burn rate is computed over, one hour
+// by default, windows shorter than 12ms are raised to 12ms.
+func WithSLOWindow(window time.Duration) MetricsOption {
+	return func(m *MetricsMiddleware) {
+		m.window = max(window, minSLOWindow)
+	}
+}
+
+// WithSLOAlert calls fn when the burn rate of a route exceeds threshold,
+// at most once per window for each route.
+func WithSLOAlert(threshold float64, fn SLOAlertFunc) MetricsOption {
+	return func(m *MetricsMiddleware) {
+		m.threshold = threshold
+		m.alert = fn
+	}
+}
+
+// WithMetricsClock replaces the clock of the SLO window, by default it is the
+// clock of the router serving the requests, see WithClock. The latency of a
+// request is always measured by the clock of the router.
+func WithMetricsClock(c Clock) MetricsOption {
+	return func(m *MetricsMiddleware) {
+		m.clock = c
+	}
+}
+
+func NewMetricsMiddleware(opts ...MetricsOption) *MetricsMiddleware {
+	m := &MetricsMiddleware{
+		window:    time.Hour,
+		threshold: 1,
+		routes:    make(map[string]*routeSLI),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *MetricsMiddleware) sli(route string, slo *SLO) *routeSLI {
+	m.mu.RLock()
+	s, ok := m.routes[route]
+	m.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok = m.routes[route]; !ok {
+		s = &routeSLI{slo: slo}
+		m.routes[route] = s
+	}
+	return s
+}
+
+func (m *MetricsMiddleware) bucketIndex(now time.Time) int64 {
+	return now.UnixNano() / int64(m.window/sloBuckets)
+}
+
+// status sums the buckets inside the window, it must be called with s.mu held.
+func (m *MetricsMiddleware) status(route string, s *routeSLI, now time.Time) SLOStatus {
+	st := SLOStatus{Route: route, SLO: s.slo}
+
+	cur := m.bucketIndex(now)
+	for _, b := range s.buckets {
+		if b.idx > cur-sloBuckets {
+			st.Requests += b.total
+			st.Errors += b.errors
+			st.Slow += b.slow
+		}
+	}
+
+	if s.slo == nil || st.Requests == 0 {
+		return st
+	}
+	if s.slo.ErrorBudget > 0 {
+		st.BurnRate = float64(st.Errors) / float64(st.Requests) / s.slo.ErrorBudget
+	}
+	if s.slo.LatencyP99 > 0 {
+		st.BurnRate = max(st.BurnRate, float64(st.Slow)/float64(st.Requests)/latencyBudget)
+	}
+	return st
+}
+
+// now is the time of the SLO window outside of a request.
+func (m *MetricsMiddleware) now() time.Time {
+	if m.clock != nil {
+		return m.clock.Now()
+	}
+	if v := m.router.Load(); v != nil {
+		return v.now()
+	}
+	return time.Now()
+}
+
+// record counts a request which finished at now, the time of the router clock.
+func (m *MetricsMiddleware) record(route string, slo *SLO, statusCode int, duration time.Duration, now time.Time) {
+	s := m.sli(route, slo)
+	if m.clock != nil {
+		now = m.clock.Now()
+	}
+
+	failed := statusCode >= http.StatusInternalServerError
+	slow := slo != nil && slo.LatencyP99 > 0 && duration > slo.LatencyP99
+
+	s.mu.Lock()
+	idx := m.bucketIndex(now)
+	b := &s.buckets[idx%sloBuckets]
+	if b.idx != idx {
+		*b = sliBucket{idx: idx}
+	}
+
+	b.total++
+	s.total++
+	if failed {
+		b.errors++
+		s.errors++
+	}
+	if slow {
+		b.slow++
+		s.slow++
+	}
+
+	var (
+		alert bool
+		st    SLOStatus
+	)
+	if m.alert != nil && slo != nil && now.Sub(s.lastAlert) >= m.window {
+		st = m.status(route, s, now)
+		if alert = st.BurnRate > m.threshold; alert {
+			s.lastAlert = now
+		}
+	}
+	s.mu.Unlock()
+
+	if alert {
+		m.alert(st)
+	}
+}
+
+func (m *MetricsMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		route := routeKey(ctx.Request.Method, ctx.RouteTemplate())
+		var slo *SLO
+		if ctx.route != nil {
+			slo = ctx.route.slo
+		}
+		if ctx.router != nil && m.router.Load() != ctx.router {
+			m.router.Store(ctx.router)
+		}
+
+		// the status is final only after the response was written
+		ctx.Writer.OnFinish(func() {
+			end := ctx.Now()
+			m.record(route, slo, ctx.Writer.StatusCode(), end.Sub(ctx.startTime), end)
+		})
+
+		return handler.Handle(ctx)
+	})
+}
+
+// Snapshot returns the window status of every route seen so far.
+func (m *MetricsMiddleware) Snapshot() []SLOStatus {
+	m.mu.RLock()
+	routes := make(map[string]*routeSLI, len(m.routes))
+	for k, s := range m.routes {
+		routes[k] = s
+	}
+	m.mu.RUnlock()
+
+	now := m.now()
+	ret := make([]SLOStatus, 0, len(routes))
+	for _, route := range sortedKeys(routes) {
+		s := routes[route]
+		s.mu.Lock()
+		ret = append(ret, m.status(route, s, now))
+		s.mu.Unlock()
+	}
+	return ret
+}
+
+// WritePrometheus writes the lifetime SLI counters and the window burn rates
+// in the Prometheus text exposition format.
+func (m *MetricsMiddleware) WritePrometheus(w io.Writer) error {
+	m.mu.RLock()
+	keys := sortedKeys(m.routes)
+	routes := make([]*routeSLI, 0, len(keys))
+	for _, k := range keys {
+		routes = append(routes, m.routes[k])
+	}
+	m.mu.RUnlock()
+
+	type series struct {
+		name, typ, help string
+		lines           []string
+	}
+	all := []*series{
+		{name: "prouter_sli_requests_total", typ: "counter", help: "Requests served per route."},
+		{name: "prouter_sli_errors_total", typ: "counter", help: "Requests failed with a 5xx per route."},
+		{name: "prouter_sli_slow_requests_total", typ: "counter", help: "Requests slower than the route SLO latency."},
+		{name: "prouter_slo_burn_rate", typ: "gauge", help: "Error budget burn rate over the SLO window."},
+	}
+
+	now := m.now()
+	for i, s := range routes {
+		label := fmt.Sprintf("{route=\"%s\"}", promLabelReplacer.Replace(keys[i]))
+
+		s.mu.Lock()
+		all[0].lines = append(all[0].lines, fmt.Sprintf("%s%s %d", all[0].name, label, s.total))
+		all[1].lines = append(all[1].lines, fmt.Sprintf("%s%s %d", all[1].name, label, s.errors))
+		if s.slo != nil {
+			all[2].lines = append(all[2].lines, fmt.Sprintf("%s%s %d", all[2].name, label, s.slow))
+			all[3].lines = append(all[3].lines, fmt.Sprintf("%s%s %g", all[3].name, label, m.status(keys[i], s, now).BurnRate))
+		}
+		s.mu.Unlock()
+	}
+
+	var b strings.Builder
+	for _, s := range all {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", s.name, s.help, s.name, s.typ)
+		for _, line := range s.lines {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
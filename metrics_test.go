@@ -0,0 +1,30 @@
+package prouter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMetricsSLOWindow(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   time.Duration
+	}{
+		{0, minSLOWindow},
+		{-time.Second, minSLOWindow},
+		{time.Nanosecond, minSLOWindow},
+		{time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		m := NewMetricsMiddleware(WithSLOWindow(tt.window))
+		if m.window != tt.want {
+			t.Errorf("WithSLOWindow(%v): window = %v, want %v", tt.window, m.window, tt.want)
+		}
+		slo := &SLO{LatencyP99: time.Second, ErrorBudget: 0.01}
+		m.record("GET /", slo, http.StatusInternalServerError, time.Millisecond, time.Now())
+		if st := m.Snapshot(); len(st) != 1 || st[0].Errors != 1 {
+			t.Errorf("WithSLOWindow(%v): snapshot = %+v", tt.window, st)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package prouter
+
+import "sync/atomic"
+
+// MiddlewareHandle switches a middleware registered with UseToggleable on and off
+// at runtime. Both chains are composed at registration, a toggle only flips an
+// atomic flag read on every request.
+type MiddlewareHandle struct {
+	middleware Middleware
+	enabled    atomic.Bool
+}
+
+func (h *MiddlewareHandle) Enable() {
+	h.enabled.Store(true)
+}
+
+func (h *MiddlewareHandle) Disable() {
+	h.enabled.Store(false)
+}
+
+func (h *MiddlewareHandle) Enabled() bool {
+	return h.enabled.Load()
+}
+
+func (h *MiddlewareHandle) WrapHandler(handler handlerFunc) handlerFunc {
+	wrapped := h.middleware.WrapHandler(handler)
+
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		if h.enabled.Load() {
+			return wrapped.Handle(ctx)
+		}
+		return handler.Handle(ctx)
+	})
+}
+
+// UseToggleable registers the enabled middleware and returns the handle to toggle it.
+func (rg *RouterGroup) UseToggleable(m Middleware) *MiddlewareHandle {
+	h := &MiddlewareHandle{middleware: m}
+	h.enabled.Store(true)
+	rg.UseMiddleware(h)
+	return h
+}
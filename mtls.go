@@ -0,0 +1,171 @@
+package prouter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// TLSOption configures the tls.Config of RunTLS.
+type TLSOption func(*tls.Config) error
+
+// WithClientCAs requires a client certificate signed by one of pool.
+func WithClientCAs(pool *x509.CertPool) TLSOption {
+	return func(c *tls.Config) error {
+		c.ClientCAs = pool
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		return nil
+	}
+}
+
+// WithClientCAFile requires a client certificate signed by a CA of the PEM file.
+func WithClientCAFile(file string) TLSOption {
+	return func(c *tls.Config) error {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("prouter: no certificate in " + file)
+		}
+		return WithClientCAs(pool)(c)
+	}
+}
+
+// WithClientAuth overrides the client certificate policy, e.g. tls.VerifyClientCertIfGiven
+// to serve public routes next to the ones guarded by ClientCertMiddleware.
+func WithClientAuth(auth tls.ClientAuthType) TLSOption {
+	return func(c *tls.Config) error {
+		c.ClientAuth = auth
+		return nil
+	}
+}
+
+func WithMinTLSVersion(version uint16) TLSOption {
+	return func(c *tls.Config) error {
+		c.MinVersion = version
+		return nil
+	}
+}
+
+func (v *Prouter) RunTLS(addr, certFile, keyFile string, opts ...TLSOption) error {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return err
+		}
+	}
+
+	if err := v.serveCertificate(cfg, certFile, keyFile); err != nil {
+		return err
+	}
+
+	if addr == "" {
+		addr = ":https"
+	}
+	l, err := v.listen(addr)
+	if err != nil {
+		return err
+	}
+
+	v.printBanner(l, true)
+	v.startBackground()
+	srv := v.trackServer(v.newServer(addr))
+	srv.TLSConfig = cfg
+	return srv.ServeTLS(l, "", "")
+}
+
+// ClientCert returns the verified client certificate of a mTLS connection.
+func (c *Context) ClientCert() *x509.Certificate {
+	if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return c.Request.TLS.PeerCertificates[0]
+}
+
+// CertRule decides whether a client certificate may access the route.
+type CertRule func(ctx *Context, cert *x509.Certificate) bool
+
+// CertSubject allows certificates with one of the subject common names.
+func CertSubject(commonNames ...string) CertRule {
+	return func(_ *Context, cert *x509.Certificate) bool {
+		return slices.Contains(commonNames, cert.Subject.CommonName)
+	}
+}
+
+// CertDNSName allows certificates with one of the DNS SANs.
+func CertDNSName(names ...string) CertRule {
+	return func(_ *Context, cert *x509.Certificate) bool {
+		for _, n := range cert.DNSNames {
+			if slices.Contains(names, n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// CertURI allows certificates with one of the URI SANs, e.g. a SPIFFE ID.
+func CertURI(uris ...string) CertRule {
+	return func(_ *Context, cert *x509.Certificate) bool {
+		for _, u := range cert.URIs {
+			if slices.Contains(uris, u.String()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ClientCertMiddleware answers 401 to requests without a verified client
+// certificate and 403 when it matches none of the rules, no rules allow any
+// verified certificate.
+type ClientCertMiddleware struct {
+	rules []CertRule
+}
+
+func NewClientCertMiddleware(rules ...CertRule) *ClientCertMiddleware {
+	return &ClientCertMiddleware{rules: rules}
+}
+
+// CertCommonName returns the subject common name of the client certificate, it
+// fits WithPrincipalFunc of the authz middleware.
+func CertCommonName(ctx *Context) string {
+	cert := ctx.ClientCert()
+	if cert == nil {
+		return ""
+	}
+	return cert.Subject.CommonName
+}
+
+func (m *ClientCertMiddleware) allowed(ctx *Context, cert *x509.Certificate) bool {
+	if len(m.rules) == 0 {
+		return true
+	}
+	for _, rule := range m.rules {
+		if rule(ctx, cert) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ClientCertMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		cert := ctx.ClientCert()
+		if cert == nil || len(ctx.Request.TLS.VerifiedChains) == 0 {
+			return nil, MsgError(http.StatusUnauthorized, "client certificate required").SetComponent(ErrProuter)
+		}
+		if !m.allowed(ctx, cert) {
+			return nil, MsgError(http.StatusForbidden, http.StatusText(http.StatusForbidden)).
+				SetComponent(ErrProuter).
+				SetResponseType(Forbidden)
+		}
+		return handler.Handle(ctx)
+	})
+}
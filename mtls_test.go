@@ -0,0 +1,184 @@
+package prouter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (ca *testCA) issue(t *testing.T, tmpl *x509.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientCertMiddleware(t *testing.T) {
+	ca := newTestCA(t)
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+
+	router := New()
+	router.GET("/public", func(*Context) (Response, error) {
+		return SuccessResponse("public"), nil
+	})
+	verified := router.Group("/any")
+	verified.UseMiddleware(NewClientCertMiddleware())
+	verified.GET("", func(ctx *Context) (Response, error) {
+		return SuccessResponse(CertCommonName(ctx)), nil
+	})
+	billing := router.Group("/billing")
+	billing.UseMiddleware(NewClientCertMiddleware(
+		CertSubject("billing"),
+		CertDNSName("billing.internal"),
+		CertURI(spiffe.String()),
+	))
+	billing.GET("", func(ctx *Context) (Response, error) {
+		return SuccessResponse(CertCommonName(ctx)), nil
+	})
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, ca.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(router)
+	srv.TLS = &tls.Config{}
+	for _, opt := range []TLSOption{WithClientCAFile(caFile), WithClientAuth(tls.VerifyClientCertIfGiven)} {
+		if err := opt(srv.TLS); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := func(certs ...tls.Certificate) *http.Client {
+		roots := x509.NewCertPool()
+		roots.AddCert(srv.Certificate())
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+	}
+	tests := []struct {
+		name   string
+		client *http.Client
+		path   string
+		code   int
+	}{
+		{"public without cert", client(), "/public", http.StatusOK},
+		{"without cert", client(), "/any", http.StatusUnauthorized},
+		{"any cert", client(ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "reports"}})), "/any", http.StatusOK},
+		{"common name", client(ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}})), "/billing", http.StatusOK},
+		{"dns name", client(ca.issue(t, &x509.Certificate{DNSNames: []string{"billing.internal"}})), "/billing", http.StatusOK},
+		{"uri", client(ca.issue(t, &x509.Certificate{URIs: []*url.URL{spiffe}})), "/billing", http.StatusOK},
+		{"no rule matches", client(ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "reports"}})), "/billing", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.code {
+				t.Errorf("code = %d, want %d", resp.StatusCode, tt.code)
+			}
+		})
+	}
+
+	// a certificate of another CA fails the handshake
+	other := newTestCA(t).issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}})
+	if resp, err := client(other).Get(srv.URL + "/billing"); err == nil {
+		resp.Body.Close()
+		t.Errorf("certificate of another CA: code = %d, want handshake error", resp.StatusCode)
+	}
+}
+
+func TestClientCertMiddlewareUnverified(t *testing.T) {
+	ca := newTestCA(t)
+	cert := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}})
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := New()
+	billing := router.Group("/billing")
+	billing.UseMiddleware(NewClientCertMiddleware(CertSubject("billing")))
+	billing.GET("", func(*Context) (Response, error) { return nil, nil })
+
+	// tls.RequestClientCert hands over certificates without verifying them
+	req := httptest.NewRequest(http.MethodGet, "/billing", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("code = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestWithClientCAFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("no certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{filepath.Join(dir, "missing.pem"), empty} {
+		if err := WithClientCAFile(file)(&tls.Config{}); err == nil {
+			t.Errorf("WithClientCAFile(%s): want error", filepath.Base(file))
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package prouter
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodePolicy is how Normalizer treats non ASCII path segments.
+type UnicodePolicy int
+
+const (
+	// UnicodeAsIs leaves the path as sent
+	UnicodeAsIs UnicodePolicy = iota + 1
+	// UnicodeNFC composes the path, so "é" as e + U+0301 and as U+00E9 match
+	// the same route. Paths which are not valid UTF-8 are rejected.
+	UnicodeNFC
+	// UnicodeNFKC composes the path and folds compatibility characters as
+	// well, e.g. fullwidth "／" to "/". A folded "/" is kept inside its
+	// segment as %2F, a folded "%" is checked by the PercentPolicy.
+	UnicodeNFKC
+)
+
+// PercentPolicy is how Normalizer treats escapes in the path, the path is
+// always decoded exactly once, by net/http.
+type PercentPolicy int
+
+const (
+	// PercentDecodeOnce accepts any valid escape
+	PercentDecodeOnce PercentPolicy = iota + 1
+	// PercentRejectDoubleEncoded rejects paths which still hold an escape once
+	// decoded, e.g. %252e%252e, which a second decoder behind the router
+	// would turn into "..".
+	PercentRejectDoubleEncoded
+	// PercentRejectEncodedDelimiters rejects double escapes and the escaped
+	// delimiters %2F, %5C and %2E as well.
+	PercentRejectEncodedDelimiters
+)
+
+// DuplicateQueryPolicy is how Normalizer treats a query key given more than once.
+type DuplicateQueryPolicy int
+
+const (
+	// QueryKeepAll leaves the query as sent
+	QueryKeepAll DuplicateQueryPolicy = iota + 1
+	// QueryKeepFirst drops the later values of a key
+	QueryKeepFirst
+	// QueryKeepLast drops the earlier values of a key
+	QueryKeepLast
+	// QueryRejectDuplicates answers 400
+	QueryRejectDuplicates
+)
+
+// Normalizer canonicalizes the path and query of requests before route
+// matching, so handlers, middlewares and the proxies behind the router all see
+// one spelling of the input. The steps run in a fixed order:
+//
+//  1. the unicode form of each path segment, see UnicodePolicy
+//  2. the escapes of the normalized path, see PercentPolicy
+//  3. the duplicate query keys, see DuplicateQueryPolicy
+//
+// Path and Query are pure functions of their input and idempotent, a
+// normalized value normalizes to itself, so they can be fuzzed directly.
+// Rejected requests are answered with 400 and counted in Stats under
+// "normalize.<reason>".
+type Normalizer struct {
+	unicode    UnicodePolicy
+	percent    PercentPolicy
+	duplicates DuplicateQueryPolicy
+}
+
+type NormalizeOption func(*Normalizer)
+
+// WithUnicodePolicy sets the unicode form of paths, UnicodeNFC by default.
+func WithUnicodePolicy(p UnicodePolicy) NormalizeOption {
+	return func(n *Normalizer) {
+		n.unicode = p
+	}
+}
+
+// WithPercentPolicy sets the escapes allowed in paths, PercentRejectDoubleEncoded by default.
+func WithPercentPolicy(p PercentPolicy) NormalizeOption {
+	return func(n *Normalizer) {
+		n.percent = p
+	}
+}
+
+// WithDuplicateQueryPolicy sets the treatment of repeated query keys, QueryKeepAll by default.
+func WithDuplicateQueryPolicy(p DuplicateQueryPolicy) NormalizeOption {
+	return func(n *Normalizer) {
+		n.duplicates = p
+	}
+}
+
+func NewNormalizer(opts ...NormalizeOption) *Normalizer {
+	n := &Normalizer{
+		unicode:    UnicodeNFC,
+		percent:    PercentRejectDoubleEncoded,
+		duplicates: QueryKeepAll,
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+// WithNormalization normalizes every request before it is routed, see Normalizer.
+func WithNormalization(opts ...NormalizeOption) RouterOption {
+	return func(v *Prouter) {
+		v.normalizer = NewNormalizer(opts...)
+	}
+}
+
+type normalizeError struct {
+	reason string
+	msg    string
+}
+
+func (e *normalizeError) Error() string {
+	return e.msg
+}
+
+// Path normalizes the escaped path, as r.URL.EscapedPath returns it, and
+// returns the escaped result.
+func (n *Normalizer) Path(escaped string) (string, error) {
+	decoded, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", &normalizeError{reason: "escape", msg: "invalid escape in path"}
+	}
+
+	if n.unicode == UnicodeNFC || n.unicode == UnicodeNFKC {
+		if !utf8.ValidString(decoded) {
+			return "", &normalizeError{reason: "utf8", msg: "path is not valid utf-8"}
+		}
+		escaped = n.normalizeSegments(escaped)
+		decoded, _ = url.PathUnescape(escaped)
+	}
+
+	if n.percent == PercentRejectDoubleEncoded || n.percent == PercentRejectEncodedDelimiters {
+		if hasEscape(decoded) {
+			return "", &normalizeError{reason: "double_encoded", msg: "double encoded path"}
+		}
+	}
+	if n.percent == PercentRejectEncodedDelimiters {
+		upper := strings.ToUpper(escaped)
+		for _, delim := range []string{"%2F", "%5C", "%2E"} {
+			if strings.Contains(upper, delim) {
+				return "", &normalizeError{reason: "encoded_delimiter", msg: "encoded delimiter in path"}
+			}
+		}
+	}
+	return escaped, nil
+}
+
+// normalizeSegments rewrites only the segments the form changes, so the
+// escapes of the others are kept as sent
+func (n *Normalizer) normalizeSegments(escaped string) string {
+	form := norm.NFC
+	if n.unicode == UnicodeNFKC {
+		form = norm.NFKC
+	}
+
+	segments := strings.Split(escaped, "/")
+	for i, seg := range segments {
+		decoded, err := url.PathUnescape(seg)
+		if err != nil || form.IsNormalString(decoded) {
+			continue
+		}
+		segments[i] = url.PathEscape(form.String(decoded))
+	}
+	return strings.Join(segments, "/")
+}
+
+func hasEscape(s string) bool {
+	for i := strings.IndexByte(s, '%'); i >= 0; i = strings.IndexByte(s, '%') {
+		if i+2 < len(s) && ishex(s[i+1]) && ishex(s[i+2]) {
+			return true
+		}
+		s = s[i+1:]
+	}
+	return false
+}
+
+func ishex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// Query applies the duplicate key policy to the raw query. Unless the query is
+// kept as sent it is encoded again, sorted by key.
+func (n *Normalizer) Query(rawQuery string) (string, error) {
+	if n.duplicates != QueryKeepFirst && n.duplicates != QueryKeepLast && n.duplicates != QueryRejectDuplicates {
+		return rawQuery, nil
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", &normalizeError{reason: "query", msg: "invalid query"}
+	}
+	for key, vs := range values {
+		if len(vs) < 2 {
+			continue
+		}
+		switch n.duplicates {
+		case QueryRejectDuplicates:
+			return "", &normalizeError{reason: "duplicate_query", msg: "duplicate query parameter " + key}
+		case QueryKeepFirst:
+			values[key] = vs[:1]
+		case QueryKeepLast:
+			values[key] = vs[len(vs)-1:]
+		}
+	}
+	return values.Encode(), nil
+}
+
+// Normalize rewrites the path and query of r in place.
+func (n *Normalizer) Normalize(r *http.Request) error {
+	escaped := r.URL.EscapedPath()
+	path, err := n.Path(escaped)
+	if err != nil {
+		return err
+	}
+	if path != escaped {
+		r.URL.Path, _ = url.PathUnescape(path)
+		r.URL.RawPath = path
+	}
+
+	query, err := n.Query(r.URL.RawQuery)
+	if err != nil {
+		return err
+	}
+	r.URL.RawQuery = query
+	return nil
+}
+
+// Handler wraps next with the normalizer, for use outside of Prouter.
+func (n *Normalizer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := n.Normalize(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (v *Prouter) normalize(w http.ResponseWriter, r *http.Request) bool {
+	err := v.normalizer.Normalize(r)
+	if err == nil {
+		return false
+	}
+
+	reason := "invalid"
+	if ne, ok := err.(*normalizeError); ok {
+		reason = ne.reason
+	}
+	v.RecordRejection("normalize." + reason)
+	_ = v.writeJSON(w, http.StatusBadRequest, ErrorResponse(http.StatusBadRequest, err.Error()))
+	return true
+}
@@ -0,0 +1,143 @@
+package prouter
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestNormalizerPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []NormalizeOption
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"ascii", nil, "/users/42", "/users/42", ""},
+		{"nfc composes", nil, "/caf" + url.PathEscape("é"), "/caf" + url.PathEscape("é"), ""},
+		{"nfc keeps escapes of other segments", nil, "/a%20b/e%CC%81", "/a%20b/%C3%A9", ""},
+		{"invalid utf-8", nil, "/%ff", "", "utf8"},
+		{"invalid escape", nil, "/%zz", "", "escape"},
+		{"double encoded", nil, "/%252e%252e/etc", "", "double_encoded"},
+		{"double encoded allowed", []NormalizeOption{WithPercentPolicy(PercentDecodeOnce)}, "/%252e", "/%252e", ""},
+		{"encoded slash allowed", nil, "/a%2Fb", "/a%2Fb", ""},
+		{"encoded slash rejected", []NormalizeOption{WithPercentPolicy(PercentRejectEncodedDelimiters)}, "/a%2fb", "", "encoded_delimiter"},
+		{"encoded dot rejected", []NormalizeOption{WithPercentPolicy(PercentRejectEncodedDelimiters)}, "/%2E%2E/etc", "", "encoded_delimiter"},
+		{"nfkc folds fullwidth slash into its segment", []NormalizeOption{WithUnicodePolicy(UnicodeNFKC)}, "/a／b", "/a%2Fb", ""},
+		{"nfkc folded slash rejected", []NormalizeOption{WithUnicodePolicy(UnicodeNFKC), WithPercentPolicy(PercentRejectEncodedDelimiters)}, "/a／b", "", "encoded_delimiter"},
+		{"as is", []NormalizeOption{WithUnicodePolicy(UnicodeAsIs)}, "/%ff", "/%ff", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNormalizer(tt.opts...).Path(tt.in)
+			if tt.wantErr != "" {
+				ne, ok := err.(*normalizeError)
+				if !ok || ne.reason != tt.wantErr {
+					t.Fatalf("Path(%q) error = %v, want %s", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Path(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizerQuery(t *testing.T) {
+	tests := []struct {
+		policy  DuplicateQueryPolicy
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{QueryKeepAll, "b=2&a=1&a=3", "b=2&a=1&a=3", false},
+		{QueryKeepFirst, "b=2&a=1&a=3", "a=1&b=2", false},
+		{QueryKeepLast, "b=2&a=1&a=3", "a=3&b=2", false},
+		{QueryRejectDuplicates, "b=2&a=1&a=3", "", true},
+		{QueryRejectDuplicates, "b=2&a=1", "a=1&b=2", false},
+		{QueryKeepFirst, "a=%zz", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NewNormalizer(WithDuplicateQueryPolicy(tt.policy)).Query(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("policy %d: Query(%q) = %q, %v, want %q", tt.policy, tt.in, got, err, tt.want)
+		}
+	}
+}
+
+var fuzzNormalizers = []*Normalizer{
+	NewNormalizer(),
+	NewNormalizer(WithUnicodePolicy(UnicodeNFKC)),
+	NewNormalizer(WithUnicodePolicy(UnicodeNFKC), WithPercentPolicy(PercentDecodeOnce)),
+	NewNormalizer(WithUnicodePolicy(UnicodeNFKC), WithPercentPolicy(PercentRejectEncodedDelimiters)),
+	NewNormalizer(WithUnicodePolicy(UnicodeAsIs), WithPercentPolicy(PercentRejectEncodedDelimiters)),
+}
+
+// FuzzNormalizerPath checks that a normalized path normalizes to itself and
+// holds none of the input the policies reject.
+func FuzzNormalizerPath(f *testing.F) {
+	for _, seed := range []string{"/users/42", "/caf%65%CC%81", "/%252e%252e/etc", "/a%2Fb", "/a／b", "/％２ｅ", "/%ff"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		for i, n := range fuzzNormalizers {
+			out, err := n.Path(in)
+			if err != nil {
+				continue
+			}
+			again, err := n.Path(out)
+			if err != nil || again != out {
+				t.Fatalf("normalizer %d: Path(%q) = %q, normalized again %q, %v", i, in, out, again, err)
+			}
+
+			decoded, err := url.PathUnescape(out)
+			if err != nil {
+				t.Fatalf("normalizer %d: Path(%q) = %q, not unescapable: %v", i, in, out, err)
+			}
+			if n.unicode == UnicodeNFKC && !norm.NFKC.IsNormalString(decoded) {
+				t.Errorf("normalizer %d: Path(%q) = %q, not in NFKC", i, in, out)
+			}
+			if n.percent != PercentDecodeOnce && hasEscape(decoded) {
+				t.Errorf("normalizer %d: Path(%q) = %q, still holds an escape", i, in, out)
+			}
+			if n.percent == PercentRejectEncodedDelimiters {
+				upper := strings.ToUpper(out)
+				if strings.Contains(upper, "%2F") || strings.Contains(upper, "%5C") || strings.Contains(upper, "%2E") {
+					t.Errorf("normalizer %d: Path(%q) = %q, holds an encoded delimiter", i, in, out)
+				}
+			}
+		}
+	})
+}
+
+// FuzzNormalizerQuery checks that a normalized query normalizes to itself and
+// keeps at most one value per key.
+func FuzzNormalizerQuery(f *testing.F) {
+	for _, seed := range []string{"a=1&a=2", "b=2&a=1", "a=%zz", "a;b=1", "=&&a"} {
+		f.Add(seed)
+	}
+	policies := []DuplicateQueryPolicy{QueryKeepFirst, QueryKeepLast, QueryRejectDuplicates}
+	f.Fuzz(func(t *testing.T, in string) {
+		for _, p := range policies {
+			n := NewNormalizer(WithDuplicateQueryPolicy(p))
+			out, err := n.Query(in)
+			if err != nil {
+				continue
+			}
+			again, err := n.Query(out)
+			if err != nil || again != out {
+				t.Fatalf("policy %d: Query(%q) = %q, normalized again %q, %v", p, in, out, again, err)
+			}
+			values, _ := url.ParseQuery(out)
+			for key, vs := range values {
+				if len(vs) > 1 {
+					t.Errorf("policy %d: Query(%q) = %q, key %q holds %d values", p, in, out, key, len(vs))
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package prouter
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// fingerprintFrames is the number of top frames which identify a panic
+const fingerprintFrames = 5
+
+// PanicReport describes a recovered panic.
+type PanicReport struct {
+	// Fingerprint groups identical crashes, it is derived from the panic
+	// value type and the top frames of the panicking goroutine.
+	Fingerprint string
+	Value       any
+	Frames      []string
+	Stack       []byte
+	// Request is the dumped request line and headers with credentials masked
+	Request string
+	Route   string
+	Time    time.Time
+	// Suppressed is the number of reports with the same fingerprint dropped
+	// by the rate limit since the previous report.
+	Suppressed int
+}
+
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, report *PanicReport)
+}
+
+type PanicReporterFunc func(ctx context.Context, report *PanicReport)
+
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, report *PanicReport) {
+	f(ctx, report)
+}
+
+type logPanicReporter struct{}
+
+func (logPanicReporter) ReportPanic(ctx context.Context, report *PanicReport) {
+	plog.Errorc(ctx, "panic recovered: %v\n%s", report.Value, report.Stack,
+		"fingerprint", report.Fingerprint,
+		"suppressed", report.Suppressed,
+	)
+}
+
+// panicFrames returns the function names of the frames which raised the panic,
+// it must be called from the deferred function which recovered it.
+func panicFrames(max int) []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var (
+		ret        []string
+		afterPanic bool
+	)
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			afterPanic = true
+		case afterPanic && !strings.HasPrefix(frame.Function, "runtime."):
+			ret = append(ret, frame.Function)
+		}
+		if !more || len(ret) >= max {
+			break
+		}
+	}
+	return ret
+}
+
+func panicFingerprint(value any, frames []string) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%T\n", value)
+	for _, f := range frames {
+		fmt.Fprintln(h, f)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+type panicBucket struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// panicLimiter allows limit reports per fingerprint within each window.
+type panicLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*panicBucket
+}
+
+func newPanicLimiter(limit int, window time.Duration) *panicLimiter {
+	return &panicLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*panicBucket),
+	}
+}
+
+// allow reports whether fingerprint may be reported now and how many reports
+// of it were suppressed before.
+func (l *panicLimiter) allow(fingerprint string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[fingerprint]
+	if !ok || now.Sub(b.start) >= l.window {
+		if len(l.buckets) > 1024 {
+			l.expire(now)
+		}
+		var suppressed int
+		if ok {
+			suppressed = b.suppressed
+		}
+		l.buckets[fingerprint] = &panicBucket{start: now, count: 1}
+		return true, suppressed
+	}
+
+	if b.count >= l.limit {
+		b.suppressed++
+		return false, 0
+	}
+
+	b.count++
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return true, suppressed
+}
+
+func (l *panicLimiter) expire(now time.Time) {
+	for fp, b := range l.buckets {
+		if now.Sub(b.start) >= l.window {
+			delete(l.buckets, fp)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package prouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// AbortPanic is raised by Abort, the recovery middleware answers it with its
+// code and message and does not report it as a crash.
+type AbortPanic struct {
+	Code    int
+	Message string
+}
+
+func (a *AbortPanic) Error() string {
+	return fmt.Sprintf("aborted with %d: %s", a.Code, a.Message)
+}
+
+// Abort stops the request from deep inside a call stack, e.g. a helper without
+// an error return, and answers it with code and msg.
+func Abort(code int, msg string) {
+	panic(&AbortPanic{Code: code, Message: msg})
+}
+
+// PanicConverter turns a known panic value into the error answered to the
+// request, it reports false for values it does not handle. Converted panics
+// are not reported.
+type PanicConverter func(ctx *Context, value any) (error, bool)
+
+// WithPanicConverter adds converters tried in order before a panic is treated
+// as a crash, AbortPanic is always converted.
+func WithPanicConverter(converters ...PanicConverter) RecoveryOption {
+	return func(m *RecoveryMiddleware) {
+		m.converters = append(m.converters, converters...)
+	}
+}
+
+// PanicDetails is the data of a crash response WithPanicDetails.
+type PanicDetails struct {
+	Panic  string   `json:"panic"`
+	Frames []string `json:"frames"`
+	Stack  string   `json:"stack"`
+}
+
+// WithPanicDetails answers crashes with the panic value and stack in data, for
+// internal groups. The router recovers with a generic response, a group gets
+// its own behavior with a recovery middleware of its own:
+//
+//	internal := router.Group("/internal")
+//	internal.UseMiddleware(prouter.NewRecoveryMiddleware(prouter.WithPanicDetails()))
+func WithPanicDetails() RecoveryOption {
+	return func(m *RecoveryMiddleware) {
+		m.details = true
+	}
+}
+
+func (m *RecoveryMiddleware) convert(ctx *Context, value any) (error, bool) {
+	var abort *AbortPanic
+	if err, ok := value.(error); ok && errors.As(err, &abort) {
+		code := abort.Code
+		if code == 0 {
+			code = http.StatusInternalServerError
+		}
+		return MsgError(code, abort.Message).SetComponent(ErrProuter), true
+	}
+
+	for _, convert := range m.converters {
+		if err, ok := convert(ctx, value); ok {
+			return err, true
+		}
+	}
+	return nil, false
+}
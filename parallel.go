@@ -0,0 +1,72 @@
+package prouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Parallel runs fns concurrently with a context bound to the request, the first
+// failure cancels the others. It waits for all of them and returns their
+// failures joined, cancellations caused by the first failure are left out. A
+// panicking fn is recovered into an error as it can not be caught by the
+// recovery middleware. The result can be returned as is:
+//
+//	err := ctx.Parallel(
+//		func(c context.Context) (err error) { user, err = users.Get(c, id); return },
+//		func(c context.Context) (err error) { orders, err = orders.List(c, id); return },
+//	)
+//	if err != nil {
+//		return nil, err
+//	}
+func (c *Context) Parallel(fns ...func(ctx context.Context) error) error {
+	pctx, cancel := context.WithCancelCause(c.Context)
+	defer cancel(nil)
+
+	var (
+		wg    sync.WaitGroup
+		errs  = make([]error, len(fns))
+		first = errors.New("parallel task failed")
+		once  sync.Once
+	)
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = NewErr(http.StatusInternalServerError, fmt.Errorf("panic recovered in parallel task: %v", r)).
+						SetComponent(ErrRecovery)
+				}
+				if errs[i] != nil {
+					once.Do(func() { cancel(first) })
+				}
+			}()
+			errs[i] = fn(pctx)
+		}()
+	}
+	wg.Wait()
+
+	failed := errors.Is(context.Cause(pctx), first)
+	var ret []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if failed && errors.Is(err, context.Canceled) && c.Context.Err() == nil {
+			continue
+		}
+		ret = append(ret, err)
+	}
+	if len(ret) == 0 && failed {
+		// every failure was a cancellation of its own
+		for _, err := range errs {
+			if err != nil {
+				ret = append(ret, err)
+			}
+		}
+	}
+	return errors.Join(ret...)
+}
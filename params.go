@@ -0,0 +1,207 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ParamConverter converts the raw value of a path variable, a failed
+// conversion is answered with 400 before the handler runs.
+type ParamConverter func(string) (any, error)
+
+type paramType struct {
+	pattern   string
+	converter ParamConverter
+}
+
+var (
+	paramTypesMu sync.RWMutex
+	paramTypes   = map[string]paramType{
+		"int": {
+			pattern: `-?[0-9]+`,
+			converter: func(s string) (any, error) {
+				return strconv.Atoi(s)
+			},
+		},
+		"uint": {
+			pattern: `[0-9]+`,
+			converter: func(s string) (any, error) {
+				i, err := strconv.ParseUint(s, 10, 0)
+				return uint(i), err
+			},
+		},
+		"float": {
+			pattern: `-?[0-9]+(?:\.[0-9]+)?`,
+			converter: func(s string) (any, error) {
+				return strconv.ParseFloat(s, 64)
+			},
+		},
+		"uuid": {
+			pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+			converter: func(s string) (any, error) {
+				return uuid.Parse(s)
+			},
+		},
+		"alpha": {
+			pattern: `[a-zA-Z]+`,
+		},
+		// path matches the remainder of the url including slashes, use ctx.ParamPath to read it
+		"path": {
+			pattern: `.*`,
+		},
+	}
+)
+
+// RegisterParamType registers a type name which can be used in route patterns
+// like /users/{id:name}. The converter may be nil to only constrain the value.
+func RegisterParamType(name, pattern string, converter ParamConverter) {
+	paramTypesMu.Lock()
+	defer paramTypesMu.Unlock()
+
+	paramTypes[name] = paramType{pattern: pattern, converter: converter}
+}
+
+func lookupParamType(name string) (paramType, bool) {
+	paramTypesMu.RLock()
+	defer paramTypesMu.RUnlock()
+
+	pt, ok := paramTypes[name]
+	return pt, ok
+}
+
+type routeParams struct {
+	patterns   map[string]*regexp.Regexp
+	converters map[string]ParamConverter
+}
+
+func (p *routeParams) empty() bool {
+	return p == nil || (len(p.patterns) == 0 && len(p.converters) == 0)
+}
+
+func (p *routeParams) setPattern(name string, pattern *regexp.Regexp) {
+	if p.patterns == nil {
+		p.patterns = make(map[string]*regexp.Regexp)
+	}
+	p.patterns[name] = pattern
+}
+
+func (p *routeParams) setConverter(name string, converter ParamConverter) {
+	if p.converters == nil {
+		p.converters = make(map[string]ParamConverter)
+	}
+	p.converters[name] = converter
+}
+
+func (p *routeParams) merge(o *routeParams) {
+	if o == nil {
+		return
+	}
+	for name, pattern := range o.patterns {
+		p.setPattern(name, pattern)
+	}
+	for name, converter := range o.converters {
+		p.setConverter(name, converter)
+	}
+}
+
+// WithParamPattern constrains the path variable name to match pattern,
+// requests with a mismatched value are answered with 404. An invalid pattern
+// fails the registration of the route.
+func WithParamPattern(name, pattern string) RouteOption {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	return func(c *routeConfig) {
+		if err != nil {
+			c.fail(fmt.Errorf("invalid pattern of path param %s: %w", name, err))
+			return
+		}
+		c.params.setPattern(name, re)
+	}
+}
+
+// WithParamConverter converts the path variable name with fn, the result
+// can be read with ctx.Param.
+func WithParamConverter[T any](name string, fn func(string) (T, error)) RouteOption {
+	return func(c *routeConfig) {
+		c.params.setConverter(name, func(s string) (any, error) {
+			return fn(s)
+		})
+	}
+}
+
+// parseParamTypes expands typed variables like {id:int} into the mux regexp
+// form and collects the converters of the types.
+func parseParamTypes(path string) (string, *routeParams) {
+	params := new(routeParams)
+
+	var (
+		b            strings.Builder
+		level, start int
+		last         int
+	)
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			if level++; level == 1 {
+				start = i
+			}
+		case '}':
+			if level--; level != 0 {
+				continue
+			}
+
+			name, typ, found := strings.Cut(path[start+1:i], ":")
+			if !found {
+				continue
+			}
+			pt, ok := lookupParamType(strings.TrimSpace(typ))
+			if !ok {
+				continue
+			}
+
+			name = strings.TrimSpace(name)
+			b.WriteString(path[last:start])
+			b.WriteString("{" + name + ":" + pt.pattern + "}")
+			last = i + 1
+			if pt.converter != nil {
+				params.setConverter(name, pt.converter)
+			}
+		}
+	}
+	b.WriteString(path[last:])
+
+	return b.String(), params
+}
+
+func (p *routeParams) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		for name, pattern := range p.patterns {
+			if !pattern.MatchString(ctx.Var(name)) {
+				msg, _ := ctx.router.message(ctx.Request, MsgPageNotFound)
+				return nil, MsgError(http.StatusNotFound, msg).
+					SetComponent(ErrProuter).
+					SetResponseType(NotFound)
+			}
+		}
+
+		if len(p.converters) > 0 {
+			ctx.params = make(map[string]any, len(p.converters))
+		}
+		for name, converter := range p.converters {
+			val, err := converter(ctx.Var(name))
+			if err != nil {
+				return nil, NewErr(http.StatusBadRequest, err, fmt.Sprintf("invalid path param: %s", name)).
+					SetComponent(ErrProuter).
+					SetResponseType(BadRequest)
+			}
+			ctx.params[name] = val
+		}
+
+		return handler.Handle(ctx)
+	})
+}
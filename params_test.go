@@ -0,0 +1,49 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithParamPattern(t *testing.T) {
+	router := NewProuter()
+	noop := func(*Context) (Response, error) { return nil, nil }
+	router.GET("/orders/{id}", noop, WithParamPattern("id", `[0-9]{3}`))
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/orders/123", http.StatusOK},
+		{"/orders/12", http.StatusNotFound},
+		{"/orders/1234", http.StatusNotFound},
+		{"/orders/abc", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.code {
+			t.Errorf("GET %s: code = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+	}
+}
+
+func TestWithParamPatternInvalid(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("registration with an invalid pattern did not fail")
+		}
+		msg := fmt.Sprint(r)
+		if !strings.Contains(msg, "path param id") || !strings.Contains(msg, "GET /orders/{id}") {
+			t.Errorf("error = %q, want the param and the route", msg)
+		}
+	}()
+
+	router := NewProuter()
+	router.GET("/orders/{id}", func(*Context) (Response, error) { return nil, nil }, WithParamPattern("id", `[0-9`))
+}
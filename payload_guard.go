@@ -0,0 +1,137 @@
+package prouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// HeaderPayloadTruncated carries the item count of a list truncated by the payload guard.
+const HeaderPayloadTruncated = "X-Payload-Truncated"
+
+// PayloadAction is what the payload guard does with oversized data.
+type PayloadAction int
+
+const (
+	// PayloadReject answers 500, the message names the route and sizes in DebugMode
+	PayloadReject PayloadAction = iota
+	// PayloadTruncate cuts list data to the items fitting the limit and links the
+	// next page, data which is not a list is rejected
+	PayloadTruncate
+)
+
+type payloadGuard struct {
+	maxBytes int
+	action   PayloadAction
+}
+
+// WithPayloadGuard checks the encoded size of the data of every response against
+// maxBytes, catching endpoints which return unbounded lists by accident. The
+// data is encoded an extra time to measure it.
+func WithPayloadGuard(maxBytes int, action PayloadAction) RouterOption {
+	return func(v *Prouter) {
+		v.payloadGuard = &payloadGuard{maxBytes: maxBytes, action: action}
+	}
+}
+
+// WithPayloadLimit overrides the limit of WithPayloadGuard for the route, a
+// negative limit disables the guard, e.g. for exports.
+func WithPayloadLimit(maxBytes int) RouteOption {
+	return func(c *routeConfig) {
+		c.payloadLimit = maxBytes
+	}
+}
+
+func (g *payloadGuard) limit(info *routeInfo) int {
+	if info != nil && info.payloadLimit != 0 {
+		return info.payloadLimit
+	}
+	return g.maxBytes
+}
+
+func (g *payloadGuard) check(ctx *Context, resp Response, err error) (Response, error) {
+	if resp == nil || err != nil {
+		return resp, err
+	}
+	limit := g.limit(ctx.route)
+	if limit <= 0 {
+		return resp, nil
+	}
+
+	data := resp.GetData()
+	body, mErr := json.Marshal(data)
+	if mErr != nil || len(body) <= limit {
+		// encoding errors are reported when the response is written
+		return resp, nil
+	}
+
+	if g.action == PayloadTruncate {
+		rv := reflect.ValueOf(data)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			if n, ok := fittingItems(rv, limit); ok {
+				return truncatePayload(ctx, resp, rv, n), nil
+			}
+		}
+	}
+
+	plog.Errorc(ctx, "response data of %s is %d bytes, over the payload limit of %d bytes", ctx.RouteTemplate(), len(body), limit)
+	msg := "response too large"
+	if prouterMode == DebugMode {
+		msg = fmt.Sprintf("response data of %s %s is %d bytes, over the payload limit of %d bytes: paginate the endpoint or raise its limit with WithPayloadLimit",
+			ctx.Request.Method, ctx.RouteTemplate(), len(body), limit)
+	}
+	return nil, MsgError(http.StatusInternalServerError, msg).
+		SetComponent(ErrProuter).
+		SetResponseType(InternalServerError)
+}
+
+// fittingItems counts the leading items of the list whose encoding fits limit
+func fittingItems(rv reflect.Value, limit int) (int, bool) {
+	// the brackets of the list
+	size := 2
+	for i := 0; i < rv.Len(); i++ {
+		item, err := json.Marshal(rv.Index(i).Interface())
+		if err != nil {
+			return 0, false
+		}
+		size += len(item)
+		if i > 0 {
+			size++
+		}
+		if size > limit {
+			return i, true
+		}
+	}
+	return rv.Len(), true
+}
+
+func truncatePayload(ctx *Context, resp Response, rv reflect.Value, n int) Response {
+	total := rv.Len()
+	plog.Warnc(ctx, "response data of %s truncated from %d to %d items by the payload limit", ctx.RouteTemplate(), total, n)
+	if rv.Kind() == reflect.Array {
+		rv = rv.Slice3(0, n, n)
+	}
+	resp.SetData(rv.Slice(0, n).Interface())
+	ctx.Writer.Header().Set(HeaderPayloadTruncated, strconv.Itoa(total))
+
+	lr, ok := resp.(LinksResponse)
+	if !ok || n == 0 {
+		return resp
+	}
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	links, _ := ctx.Links().Page(max(offset, 0), n, -1).Build()
+	merged := lr.GetLinks()
+	if merged == nil {
+		merged = make(Links, len(links))
+	}
+	// the page moved by the items which fit replaces the links of the handler
+	for rel, href := range links {
+		merged[rel] = href
+	}
+	lr.SetLinks(merged)
+	return resp
+}
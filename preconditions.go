@@ -0,0 +1,98 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Preconditions are checked before the handler runs, a request failing one is
+// answered with 400, 411, 413 or 431 naming what is wrong.
+type Preconditions struct {
+	// Headers must be present and not empty
+	Headers []string
+	// Query parameters must be present
+	Query []string
+	// RequireContentLength rejects requests without Content-Length, e.g. chunked uploads, with 411
+	RequireContentLength bool
+	MinContentLength     int64
+	// MaxContentLength rejects larger bodies with 413, 0 means no limit
+	MaxContentLength int64
+	// MaxHeaderBytes rejects requests with larger headers with 431, 0 means no limit
+	MaxHeaderBytes int
+}
+
+// WithPreconditions declares the preconditions of the route.
+func WithPreconditions(p Preconditions) RouteOption {
+	return func(c *routeConfig) {
+		c.preconditions = &p
+	}
+}
+
+func preconditionError(code int, format string, args ...any) error {
+	err := MsgError(code, fmt.Sprintf(format, args...)).SetComponent(ErrProuter)
+	if code == http.StatusBadRequest {
+		err = err.SetResponseType(BadRequest)
+	}
+	return err
+}
+
+func headerBytes(h http.Header) int {
+	n := 0
+	for k, values := range h {
+		for _, v := range values {
+			// "Key: value\r\n"
+			n += len(k) + len(v) + 4
+		}
+	}
+	return n
+}
+
+func (p *Preconditions) check(r *http.Request) error {
+	if p.MaxHeaderBytes > 0 && headerBytes(r.Header) > p.MaxHeaderBytes {
+		return preconditionError(http.StatusRequestHeaderFieldsTooLarge, "request headers exceed %d bytes", p.MaxHeaderBytes)
+	}
+
+	var missing []string
+	for _, h := range p.Headers {
+		if r.Header.Get(h) == "" {
+			missing = append(missing, h)
+		}
+	}
+	if len(missing) > 0 {
+		return preconditionError(http.StatusBadRequest, "missing required header(s): %s", strings.Join(missing, ", "))
+	}
+
+	query := r.URL.Query()
+	for _, q := range p.Query {
+		if !query.Has(q) {
+			missing = append(missing, q)
+		}
+	}
+	if len(missing) > 0 {
+		return preconditionError(http.StatusBadRequest, "missing required query parameter(s): %s", strings.Join(missing, ", "))
+	}
+
+	if r.ContentLength < 0 {
+		if p.RequireContentLength {
+			return preconditionError(http.StatusLengthRequired, "Content-Length required")
+		}
+		return nil
+	}
+	if r.ContentLength < p.MinContentLength {
+		return preconditionError(http.StatusBadRequest, "request body must be at least %d bytes", p.MinContentLength)
+	}
+	if p.MaxContentLength > 0 && r.ContentLength > p.MaxContentLength {
+		return preconditionError(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", p.MaxContentLength)
+	}
+	return nil
+}
+
+func (p *Preconditions) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		if err := p.check(ctx.Request); err != nil {
+			return nil, err
+		}
+		return handler.Handle(ctx)
+	})
+}
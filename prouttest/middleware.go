@@ -0,0 +1,61 @@
+package prouttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-puzzles/prouter"
+)
+
+// Result is what a middleware did with a request in RunMiddleware.
+type Result struct {
+	// Response and Err are returned by the middleware, Err is nil and
+	// Response the one of next when it passed the request on
+	Response prouter.Response
+	Err      error
+	// NextCalled reports whether the middleware called the next handler
+	NextCalled bool
+
+	// StatusCode, Header and Body are the response written to the client
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// RunMiddleware serves req by mw in front of next, without the log and
+// recovery middlewares of prouter.NewProuter, and returns what happened:
+//
+//	res := prouttest.RunMiddleware(auth.Middleware(), httptest.NewRequest("GET", "/", nil), nil)
+//	if res.NextCalled || res.StatusCode != http.StatusUnauthorized {
+//		t.Fatalf("unauthenticated request passed: %+v", res)
+//	}
+//
+// A nil req is a GET of "/", a nil next answers nil, nil. The route matches any
+// path, under the template "/{path:.*}". opts configure the router, e.g. with
+// the keys the middleware needs.
+func RunMiddleware(mw prouter.Middleware, req *http.Request, next prouter.HandleFunc, opts ...prouter.RouterOption) *Result {
+	if req == nil {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+	}
+
+	res := &Result{}
+	router := prouter.New(opts...)
+	router.UseMiddleware(prouter.Observe(func(_ *prouter.Context, resp prouter.Response, err error) {
+		res.Response, res.Err = resp, err
+	}), mw)
+	router.Any("/{path:.*}", func(ctx *prouter.Context) (prouter.Response, error) {
+		res.NextCalled = true
+		if next == nil {
+			return nil, nil
+		}
+		return next(ctx)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	res.StatusCode = rec.Code
+	res.Header = rec.Header()
+	res.Body = rec.Body.Bytes()
+	return res
+}
@@ -0,0 +1,60 @@
+package prouttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func requireToken(ctx *prouter.Context) (prouter.Response, error) {
+	if ctx.Request.Header.Get("Authorization") != "Bearer token" {
+		return nil, prouter.MsgError(http.StatusUnauthorized, "unauthorized")
+	}
+	ctx.Writer.Header().Set("X-User", "jane")
+	return nil, nil
+}
+
+func TestRunMiddleware(t *testing.T) {
+	authed := httptest.NewRequest(http.MethodPost, "/orders/1", nil)
+	authed.Header.Set("Authorization", "Bearer token")
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		next   prouter.HandleFunc
+		called bool
+		code   int
+		err    bool
+		user   string
+	}{
+		{"rejected", httptest.NewRequest(http.MethodGet, "/orders/1", nil), nil, false, http.StatusUnauthorized, true, ""},
+		{"nil request", nil, nil, false, http.StatusUnauthorized, true, ""},
+		{"passed to nil next", authed, nil, true, http.StatusOK, false, "jane"},
+		{"passed to next", authed, func(*prouter.Context) (prouter.Response, error) {
+			return prouter.SuccessResponse("created"), nil
+		}, true, http.StatusOK, false, "jane"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := RunMiddleware(prouter.HandleFunc(requireToken), tt.req, tt.next)
+
+			if res.NextCalled != tt.called {
+				t.Errorf("NextCalled = %v, want %v", res.NextCalled, tt.called)
+			}
+			if res.StatusCode != tt.code {
+				t.Errorf("StatusCode = %d, want %d: %s", res.StatusCode, tt.code, res.Body)
+			}
+			if (res.Err != nil) != tt.err {
+				t.Errorf("Err = %v, want error %v", res.Err, tt.err)
+			}
+			if got := res.Header.Get("X-User"); got != tt.user {
+				t.Errorf("X-User = %q, want %q", got, tt.user)
+			}
+			if tt.next != nil && (res.Response == nil || res.Response.GetData() != "created") {
+				t.Errorf("Response = %+v, want the one of next", res.Response)
+			}
+		})
+	}
+}
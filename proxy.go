@@ -0,0 +1,124 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const proxyPathVar = "proxypath"
+
+type proxyConfig struct {
+	transport    http.RoundTripper
+	hedge        *hedgeConfig
+	routeOptions []RouteOption
+
+	targets  []ProxyTarget
+	strategy BalanceStrategy
+	maxFails int
+	ejectFor time.Duration
+}
+
+type proxyAttemptKey struct{}
+
+// proxyAttempt carries the picked upstream of a request through the reverse proxy
+type proxyAttempt struct {
+	upstream *upstream
+	failed   bool
+}
+
+func requestAttempt(r *http.Request) *proxyAttempt {
+	a, _ := r.Context().Value(proxyAttemptKey{}).(*proxyAttempt)
+	return a
+}
+
+type ProxyOption func(*proxyConfig)
+
+// WithProxyTransport sets the transport used to reach the upstream, http.DefaultTransport by default.
+func WithProxyTransport(transport http.RoundTripper) ProxyOption {
+	return func(c *proxyConfig) {
+		c.transport = transport
+	}
+}
+
+// WithProxyRouteOptions applies the route options to the proxy route.
+func WithProxyRouteOptions(opts ...RouteOption) ProxyOption {
+	return func(c *proxyConfig) {
+		c.routeOptions = append(c.routeOptions, opts...)
+	}
+}
+
+func proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	if a := requestAttempt(r); a != nil {
+		a.failed = true
+	}
+	plog.Errorc(r.Context(), "proxy %s error: %v", r.URL.String(), err)
+	_ = WriteJSON(w, http.StatusBadGateway, ErrorResponse(http.StatusBadGateway, http.StatusText(http.StatusBadGateway)))
+}
+
+// Proxy forwards all requests under relativePath to target, the path below
+// relativePath is appended to the path of target. More upstreams are added with
+// WithProxyTargets, the returned pool reports their stats. It panics on an invalid target.
+func (rg *RouterGroup) Proxy(relativePath, target string, opts ...ProxyOption) *ProxyUpstreams {
+	cfg := &proxyConfig{
+		transport: http.DefaultTransport,
+		targets:   []ProxyTarget{{URL: target, Weight: 1}},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	pool := newProxyUpstreams(cfg, rg.prouter.now)
+
+	transport := cfg.transport
+	if cfg.hedge != nil {
+		transport = newHedgedTransport(transport, cfg.hedge)
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(requestAttempt(pr.In).upstream.url)
+			pr.SetXForwarded()
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if a := requestAttempt(resp.Request); a != nil && resp.StatusCode >= http.StatusInternalServerError {
+				a.failed = true
+			}
+			return nil
+		},
+		Transport:    transport,
+		ErrorHandler: proxyErrorHandler,
+	}
+
+	if !strings.HasPrefix(relativePath, "/") {
+		relativePath = "/" + relativePath
+	}
+	handler := &wrapHandler{
+		name: "ProxyHandler",
+		handler: func(ctx *Context) (Response, error) {
+			r := ctx.Request
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.URL = new(url.URL)
+			*r2.URL = *r.URL
+			r2.URL.Path = "/" + ctx.ParamPath(proxyPathVar)
+			r2.URL.RawPath = ""
+
+			a := &proxyAttempt{upstream: pool.pick()}
+			defer func() {
+				pool.done(a.upstream, a.failed)
+			}()
+
+			proxy.ServeHTTP(ctx.Writer, r2.WithContext(context.WithValue(r2.Context(), proxyAttemptKey{}, a)))
+			return nil, nil
+		},
+	}
+
+	rg.handleRoute("", path.Join(relativePath, "{"+proxyPathVar+":path}"), handler, cfg.routeOptions...)
+	return pool
+}
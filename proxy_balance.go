@@ -0,0 +1,188 @@
+package prouter
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BalanceStrategy int
+
+const (
+	RoundRobin BalanceStrategy = iota
+	LeastConnections
+	// Weighted is the smooth weighted round robin over ProxyTarget.Weight
+	Weighted
+)
+
+// ProxyTarget is an upstream of a proxy route, Weight is used by the Weighted strategy.
+type ProxyTarget struct {
+	URL    string
+	Weight int
+}
+
+// WithProxyTargets adds upstreams to the target given to Proxy.
+func WithProxyTargets(targets ...ProxyTarget) ProxyOption {
+	return func(c *proxyConfig) {
+		c.targets = append(c.targets, targets...)
+	}
+}
+
+func WithBalanceStrategy(strategy BalanceStrategy) ProxyOption {
+	return func(c *proxyConfig) {
+		c.strategy = strategy
+	}
+}
+
+// WithPassiveHealthCheck ejects an upstream for ejectFor after maxFails consecutive
+// failed requests, a failure is a transport error or a 5xx response. When all
+// upstreams are ejected the requests are spread over all of them again.
+func WithPassiveHealthCheck(maxFails int, ejectFor time.Duration) ProxyOption {
+	return func(c *proxyConfig) {
+		c.maxFails = maxFails
+		c.ejectFor = ejectFor
+	}
+}
+
+type upstream struct {
+	url    *url.URL
+	weight int
+
+	active   atomic.Int64
+	requests atomic.Uint64
+	failures atomic.Uint64
+
+	// guarded by the pool mutex
+	fails        int
+	ejectedUntil time.Time
+	current      int
+}
+
+// UpstreamStats is a snapshot of the counters of an upstream
+type UpstreamStats struct {
+	URL          string
+	Active       int64
+	Requests     uint64
+	Failures     uint64
+	Healthy      bool
+	EjectedUntil time.Time
+}
+
+// ProxyUpstreams is the upstream pool of a proxy route.
+type ProxyUpstreams struct {
+	strategy BalanceStrategy
+	maxFails int
+	ejectFor time.Duration
+	// now is the router clock, ejections expire in its time
+	now func() time.Time
+
+	mu        sync.Mutex
+	upstreams []*upstream
+	next      int
+}
+
+func newProxyUpstreams(cfg *proxyConfig, now func() time.Time) *ProxyUpstreams {
+	p := &ProxyUpstreams{
+		strategy: cfg.strategy,
+		maxFails: cfg.maxFails,
+		ejectFor: cfg.ejectFor,
+		now:      now,
+	}
+
+	for _, t := range cfg.targets {
+		u, err := url.Parse(t.URL)
+		if err != nil {
+			panic(err)
+		}
+		p.upstreams = append(p.upstreams, &upstream{url: u, weight: max(t.Weight, 1)})
+	}
+
+	return p
+}
+
+func (p *ProxyUpstreams) healthy(now time.Time) []*upstream {
+	ups := make([]*upstream, 0, len(p.upstreams))
+	for _, u := range p.upstreams {
+		if !now.Before(u.ejectedUntil) {
+			ups = append(ups, u)
+		}
+	}
+	if len(ups) == 0 {
+		return p.upstreams
+	}
+	return ups
+}
+
+func (p *ProxyUpstreams) pick() *upstream {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ups := p.healthy(p.now())
+
+	var picked *upstream
+	switch p.strategy {
+	case LeastConnections:
+		for _, u := range ups {
+			if picked == nil || u.active.Load() < picked.active.Load() {
+				picked = u
+			}
+		}
+	case Weighted:
+		total := 0
+		for _, u := range ups {
+			u.current += u.weight
+			total += u.weight
+			if picked == nil || u.current > picked.current {
+				picked = u
+			}
+		}
+		picked.current -= total
+	default:
+		picked = ups[p.next%len(ups)]
+		p.next++
+	}
+
+	picked.active.Add(1)
+	picked.requests.Add(1)
+	return picked
+}
+
+func (p *ProxyUpstreams) done(u *upstream, failed bool) {
+	u.active.Add(-1)
+	if failed {
+		u.failures.Add(1)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !failed {
+		u.fails = 0
+		return
+	}
+	u.fails++
+	if p.maxFails > 0 && u.fails >= p.maxFails {
+		u.ejectedUntil = p.now().Add(p.ejectFor)
+		u.fails = 0
+	}
+}
+
+func (p *ProxyUpstreams) Stats() []UpstreamStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	ret := make([]UpstreamStats, 0, len(p.upstreams))
+	for _, u := range p.upstreams {
+		ret = append(ret, UpstreamStats{
+			URL:          u.url.String(),
+			Active:       u.active.Load(),
+			Requests:     u.requests.Load(),
+			Failures:     u.failures.Load(),
+			Healthy:      !now.Before(u.ejectedUntil),
+			EjectedUntil: u.ejectedUntil,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,171 @@
+package prouter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newUpstream answers its name, or 503 while failing is set
+func newUpstream(t *testing.T, name string, failing *atomic.Bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing != nil && failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func proxyGet(t *testing.T, router *Prouter) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/items", nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestProxyBalanceStrategies(t *testing.T) {
+	a, b := newUpstream(t, "a", nil), newUpstream(t, "b", nil)
+
+	tests := []struct {
+		name     string
+		strategy BalanceStrategy
+		targets  []ProxyTarget
+		want     string
+	}{
+		{"round robin", RoundRobin, []ProxyTarget{{URL: b.URL}}, "abababab"},
+		{"weighted", Weighted, []ProxyTarget{{URL: b.URL, Weight: 3}}, "babbbabb"},
+		// sequential requests leave no connection active, the first upstream wins
+		{"least connections", LeastConnections, []ProxyTarget{{URL: b.URL}}, "aaaaaaaa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New()
+			router.Proxy("/api", a.URL, WithProxyTargets(tt.targets...), WithBalanceStrategy(tt.strategy))
+
+			got := ""
+			for range len(tt.want) {
+				code, body := proxyGet(t, router)
+				if code != http.StatusOK {
+					t.Fatalf("code = %d", code)
+				}
+				got += body
+			}
+			if got != tt.want {
+				t.Errorf("upstreams = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyPassiveHealthCheck(t *testing.T) {
+	var failing atomic.Bool
+	a, b := newUpstream(t, "a", &failing), newUpstream(t, "b", nil)
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock))
+	pool := router.Proxy("/api", a.URL, WithProxyTargets(ProxyTarget{URL: b.URL}), WithPassiveHealthCheck(2, time.Minute))
+
+	healthy := func() []bool {
+		var ret []bool
+		for _, st := range pool.Stats() {
+			ret = append(ret, st.Healthy)
+		}
+		return ret
+	}
+
+	// round robin sends every other request to a, the second failure ejects it
+	failing.Store(true)
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusServiceUnavailable} {
+		if code, _ := proxyGet(t, router); code != want {
+			t.Fatalf("request %d: code = %d, want %d", i, code, want)
+		}
+	}
+	if h := healthy(); h[0] || !h[1] {
+		t.Fatalf("healthy = %v after %d failures", h, 2)
+	}
+	if st := pool.Stats()[0]; st.Failures != 2 || !st.EjectedUntil.Equal(clock.Now().Add(time.Minute)) {
+		t.Errorf("stats = %+v", st)
+	}
+
+	// an ejected upstream gets no requests
+	for i := 0; i < 4; i++ {
+		if code, body := proxyGet(t, router); code != http.StatusOK || body != "b" {
+			t.Fatalf("request to an ejected pool: %d %s", code, body)
+		}
+	}
+
+	// a recovers once the ejection expired
+	failing.Store(false)
+	clock.Advance(time.Minute)
+	if h := healthy(); !h[0] || !h[1] {
+		t.Fatalf("healthy = %v after the ejection", h)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		_, body := proxyGet(t, router)
+		seen[body] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Errorf("upstreams after recovery = %v, want both", seen)
+	}
+}
+
+func TestProxyAllEjected(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	a, b := newUpstream(t, "a", &failing), newUpstream(t, "b", &failing)
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock))
+	pool := router.Proxy("/api", a.URL, WithProxyTargets(ProxyTarget{URL: b.URL}), WithPassiveHealthCheck(1, time.Minute))
+
+	proxyGet(t, router)
+	proxyGet(t, router)
+	for _, st := range pool.Stats() {
+		if st.Healthy {
+			t.Fatalf("%s healthy after a failure", st.URL)
+		}
+	}
+
+	// with every upstream ejected the requests are spread over all of them
+	failing.Store(false)
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		code, body := proxyGet(t, router)
+		if code != http.StatusOK {
+			t.Fatalf("code = %d with all upstreams ejected", code)
+		}
+		seen[body] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Errorf("upstreams = %v, want both", seen)
+	}
+}
+
+func TestProxyTransportErrorEjects(t *testing.T) {
+	down := newUpstream(t, "down", nil)
+	down.Close()
+	b := newUpstream(t, "b", nil)
+
+	router := New()
+	pool := router.Proxy("/api", down.URL, WithProxyTargets(ProxyTarget{URL: b.URL}), WithPassiveHealthCheck(1, time.Minute))
+
+	if code, _ := proxyGet(t, router); code != http.StatusBadGateway {
+		t.Fatalf("code = %d, want %d", code, http.StatusBadGateway)
+	}
+	if st := pool.Stats()[0]; st.Healthy || st.Failures != 1 {
+		t.Errorf("stats of the unreachable upstream = %+v", st)
+	}
+	for i := 0; i < 2; i++ {
+		if code, body := proxyGet(t, router); code != http.StatusOK || body != "b" {
+			t.Errorf("request %d: %d %s", i, code, body)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package prouter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	hedgeSamples    = 256
+	hedgeMinSamples = 20
+	// hedgeRecompute is how many observations are made before the threshold is recomputed
+	hedgeRecompute = 32
+)
+
+type hedgeConfig struct {
+	percentile float64
+	fallback   time.Duration
+}
+
+// WithHedging sends a second attempt of idempotent bodiless requests (GET, HEAD,
+// OPTIONS) when the first one did not respond within the given latency percentile
+// of the route, e.g. 0.95, and takes the first response. fallback is the delay
+// used until enough latencies were observed.
+func WithHedging(percentile float64, fallback time.Duration) ProxyOption {
+	return func(c *proxyConfig) {
+		c.hedge = &hedgeConfig{percentile: percentile, fallback: fallback}
+	}
+}
+
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples [hedgeSamples]time.Duration
+	n       int
+	next    int
+
+	threshold atomic.Int64
+}
+
+func (t *latencyTracker) observe(d time.Duration, percentile float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % hedgeSamples
+	if t.n < hedgeSamples {
+		t.n++
+	}
+	if t.n < hedgeMinSamples || t.next%hedgeRecompute != 0 {
+		return
+	}
+
+	sorted := slices.Clone(t.samples[:t.n])
+	slices.Sort(sorted)
+	idx := min(int(float64(len(sorted))*percentile), len(sorted)-1)
+	t.threshold.Store(int64(sorted[idx]))
+}
+
+type hedgedTransport struct {
+	next    http.RoundTripper
+	cfg     *hedgeConfig
+	latency latencyTracker
+}
+
+func newHedgedTransport(next http.RoundTripper, cfg *hedgeConfig) *hedgedTransport {
+	return &hedgedTransport{next: next, cfg: cfg}
+}
+
+func (t *hedgedTransport) delay() time.Duration {
+	if d := time.Duration(t.latency.threshold.Load()); d > 0 {
+		return d
+	}
+	return t.cfg.fallback
+}
+
+func hedgeable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return r.Body == nil || r.Body == http.NoBody
+	default:
+		return false
+	}
+}
+
+type hedgeResult struct {
+	idx  int
+	resp *http.Response
+	err  error
+}
+
+// cancelOnClose releases the context of the winning attempt once its body is consumed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (t *hedgedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	if !hedgeable(req) {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil {
+			t.latency.observe(time.Since(start), t.cfg.percentile)
+		}
+		return resp, err
+	}
+
+	results := make(chan hedgeResult, 2)
+	var (
+		cancels []context.CancelFunc
+		pending int
+		lastErr error
+	)
+	attempt := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		idx := len(cancels)
+		cancels = append(cancels, cancel)
+		pending++
+		go func() {
+			resp, err := t.next.RoundTrip(req.Clone(ctx))
+			results <- hedgeResult{idx: idx, resp: resp, err: err}
+		}()
+	}
+
+	attempt()
+	timer := time.NewTimer(t.delay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) < 2 {
+				attempt()
+			}
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[res.idx]()
+				lastErr = res.err
+				if len(cancels) < 2 {
+					// the first attempt failed before the hedge was sent, send it now
+					timer.Stop()
+					attempt()
+				} else if pending == 0 {
+					return nil, lastErr
+				}
+				continue
+			}
+
+			t.latency.observe(time.Since(start), t.cfg.percentile)
+			for i, cancel := range cancels {
+				if i != res.idx {
+					cancel()
+				}
+			}
+			go drainHedges(results, pending)
+
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.idx]}
+			return res.resp, nil
+		}
+	}
+}
+
+// drainHedges closes the responses of the attempts which lost the race
+func drainHedges(results chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		res := <-results
+		if res.resp != nil {
+			_ = res.resp.Body.Close()
+		}
+	}
+}
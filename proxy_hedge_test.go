@@ -0,0 +1,211 @@
+package prouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// trackedBody records whether the response body was closed
+type trackedBody struct {
+	io.Reader
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newTrackedBody(s string) *trackedBody {
+	return &trackedBody{Reader: strings.NewReader(s), closed: make(chan struct{})}
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(func() { close(b.closed) })
+	return nil
+}
+
+// attemptTransport answers the nth attempt with attempts[n]
+type attemptTransport struct {
+	mu       sync.Mutex
+	attempts []func(r *http.Request) (*http.Response, error)
+	calls    int
+}
+
+func (t *attemptTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	n := t.calls
+	t.calls++
+	t.mu.Unlock()
+	return t.attempts[n](r)
+}
+
+func (t *attemptTransport) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls
+}
+
+func respond(body *trackedBody) func(r *http.Request) (*http.Response, error) {
+	return func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: body, Request: r}, nil
+	}
+}
+
+func waitClosed(t *testing.T, b *trackedBody, what string) {
+	t.Helper()
+	select {
+	case <-b.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s not closed", what)
+	}
+}
+
+func TestHedgedTransportFires(t *testing.T) {
+	var (
+		release    = make(chan struct{})
+		firstCtx   context.Context
+		ctxSet     = make(chan struct{})
+		loser      = newTrackedBody("first")
+		winner     = newTrackedBody("second")
+		hedgeTimes atomic.Int64
+	)
+	start := time.Now()
+	next := &attemptTransport{attempts: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) {
+			firstCtx = r.Context()
+			close(ctxSet)
+			// a slow upstream answers after the hedge won, the body must not leak
+			<-release
+			return respond(loser)(r)
+		},
+		func(r *http.Request) (*http.Response, error) {
+			hedgeTimes.Store(int64(time.Since(start)))
+			return respond(winner)(r)
+		},
+	}}
+	transport := newHedgedTransport(next, &hedgeConfig{percentile: 0.95, fallback: 20 * time.Millisecond})
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if string(data) != "second" {
+		t.Fatalf("body = %q, want the hedge", data)
+	}
+	if d := time.Duration(hedgeTimes.Load()); d < 20*time.Millisecond {
+		t.Errorf("hedge sent after %s, before the fallback delay", d)
+	}
+
+	<-ctxSet
+	select {
+	case <-firstCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the losing attempt was not canceled")
+	}
+	close(release)
+	waitClosed(t, loser, "losing body")
+
+	resp.Body.Close()
+	waitClosed(t, winner, "winning body")
+}
+
+func TestHedgedTransportFastFirstAttempt(t *testing.T) {
+	body := newTrackedBody("first")
+	next := &attemptTransport{attempts: []func(*http.Request) (*http.Response, error){respond(body)}}
+	transport := newHedgedTransport(next, &hedgeConfig{percentile: 0.95, fallback: 50 * time.Millisecond})
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqCtx := resp.Request.Context()
+	time.Sleep(100 * time.Millisecond)
+	if n := next.count(); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+	if reqCtx.Err() != nil {
+		t.Error("the winning attempt was canceled before its body was closed")
+	}
+	resp.Body.Close()
+	if reqCtx.Err() == nil {
+		t.Error("closing the body did not release the attempt")
+	}
+}
+
+func TestHedgedTransportNotHedgeable(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"post", httptest.NewRequest(http.MethodPost, "http://upstream/", strings.NewReader("order"))},
+		{"delete", httptest.NewRequest(http.MethodDelete, "http://upstream/", nil)},
+		{"get with body", httptest.NewRequest(http.MethodGet, "http://upstream/", strings.NewReader("query"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &attemptTransport{attempts: []func(*http.Request) (*http.Response, error){
+				func(r *http.Request) (*http.Response, error) {
+					// slower than the fallback, a hedge would be sent by now
+					time.Sleep(50 * time.Millisecond)
+					return respond(newTrackedBody("once"))(r)
+				},
+				func(*http.Request) (*http.Response, error) {
+					t.Error("request hedged")
+					return nil, errors.New("hedged")
+				},
+			}}
+			transport := newHedgedTransport(next, &hedgeConfig{percentile: 0.95, fallback: 5 * time.Millisecond})
+			resp, err := transport.RoundTrip(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if n := next.count(); n != 1 {
+				t.Errorf("attempts = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestHedgedTransportErrors(t *testing.T) {
+	fail := func(*http.Request) (*http.Response, error) { return nil, errors.New("connection refused") }
+
+	// a failed first attempt sends the hedge at once
+	body := newTrackedBody("second")
+	next := &attemptTransport{attempts: []func(*http.Request) (*http.Response, error){fail, respond(body)}}
+	transport := newHedgedTransport(next, &hedgeConfig{percentile: 0.95, fallback: time.Hour})
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream/", nil))
+	if err != nil {
+		t.Fatalf("RoundTrip = %v, want the hedge response", err)
+	}
+	resp.Body.Close()
+
+	next = &attemptTransport{attempts: []func(*http.Request) (*http.Response, error){fail, fail}}
+	transport = newHedgedTransport(next, &hedgeConfig{percentile: 0.95, fallback: time.Hour})
+	if _, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream/", nil)); err == nil {
+		t.Error("RoundTrip with failing attempts: want error")
+	}
+	if n := next.count(); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+}
+
+func TestLatencyTrackerThreshold(t *testing.T) {
+	var tracker latencyTracker
+	for i := 1; i < hedgeRecompute; i++ {
+		tracker.observe(time.Duration(i)*time.Millisecond, 0.5)
+	}
+	if d := tracker.threshold.Load(); d != 0 {
+		t.Fatalf("threshold = %s before enough samples", time.Duration(d))
+	}
+	tracker.observe(hedgeRecompute*time.Millisecond, 0.5)
+	if d := time.Duration(tracker.threshold.Load()); d != (hedgeRecompute/2+1)*time.Millisecond {
+		t.Errorf("threshold = %s, want the median", d)
+	}
+}
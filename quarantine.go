@@ -0,0 +1,156 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// QuarantineAlertFunc is called when a route is quarantined, report is the panic which tripped it.
+type QuarantineAlertFunc func(ctx context.Context, route string, report *PanicReport)
+
+// panicQuarantine counts the panics per route and quarantines a route which
+// panicked more than max times within window
+type panicQuarantine struct {
+	max    int
+	window time.Duration
+	alert  QuarantineAlertFunc
+
+	mu   sync.Mutex
+	hits map[string][]time.Time
+}
+
+// WithQuarantine answers every request of a route with 503 once it panicked more
+// than maxPanics times within window, until it is released with ReleaseRoute or
+// the admin routes of MountQuarantine. alert may be nil.
+func WithQuarantine(maxPanics int, window time.Duration, alert QuarantineAlertFunc) RecoveryOption {
+	return func(m *RecoveryMiddleware) {
+		m.quarantine = &panicQuarantine{
+			max:    maxPanics,
+			window: window,
+			alert:  alert,
+			hits:   make(map[string][]time.Time),
+		}
+	}
+}
+
+// hit records a panic of route and reports whether it exceeds the limit
+func (q *panicQuarantine) hit(route string, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cutoff := now.Add(-q.window)
+	ts := q.hits[route]
+	i := 0
+	for i < len(ts) && ts[i].Before(cutoff) {
+		i++
+	}
+	ts = append(ts[i:], now)
+	if len(ts) > q.max {
+		delete(q.hits, route)
+		return true
+	}
+	q.hits[route] = ts
+	return false
+}
+
+func (q *panicQuarantine) record(ctx *Context, report *PanicReport) {
+	if ctx.router == nil || ctx.route == nil {
+		return
+	}
+
+	key := routeKey(ctx.route.method, ctx.route.template)
+	if !q.hit(key, report.Time) {
+		return
+	}
+
+	slot := ctx.router.routeSlots.lookup(ctx.route.method, ctx.route.template)
+	if slot == nil || !slot.quarantine(true) {
+		return
+	}
+
+	plog.Errorc(ctx, "route %s quarantined after %d panics within %v", key, q.max+1, q.window)
+	if q.alert != nil {
+		q.alert(context.WithoutCancel(ctx), key, report)
+	}
+}
+
+// quarantine sets the quarantine flag of the route, it reports whether it changed
+func (s *routeSlot) quarantine(on bool) bool {
+	for {
+		old := s.state.Load()
+		if old.quarantined == on {
+			return false
+		}
+		state := *old
+		state.quarantined = on
+		if s.state.CompareAndSwap(old, &state) {
+			return true
+		}
+	}
+}
+
+func serveQuarantined(w http.ResponseWriter) {
+	_ = WriteJSON(w, http.StatusServiceUnavailable, ErrorResponse(http.StatusServiceUnavailable, "route quarantined"))
+}
+
+// Quarantined returns the quarantined routes as "METHOD template".
+func (v *Prouter) Quarantined() []string {
+	v.routeSlots.mu.RLock()
+	defer v.routeSlots.mu.RUnlock()
+
+	var ret []string
+	for key, slot := range v.routeSlots.slots {
+		if slot.state.Load().quarantined {
+			ret = append(ret, key)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ReleaseRoute lifts the quarantine of the route registered with method and the
+// full path template, e.g. ReleaseRoute("GET", "/api/users/{id}").
+func (v *Prouter) ReleaseRoute(method, template string) error {
+	slot := v.routeSlots.lookup(method, template)
+	if slot == nil {
+		return ErrRouteNotFound
+	}
+	if slot.quarantine(false) {
+		v.debugPrintRouteAction("release", slot.info, slot.state.Load().handlerName)
+	}
+	return nil
+}
+
+type releaseRequest struct {
+	Method string `json:"method"`
+	Route  string `json:"route" binding:"required"`
+}
+
+// MountQuarantine registers the admin routes of the quarantine in rg, protect
+// the group with an auth middleware:
+//
+//	GET  /quarantine
+//	POST /quarantine/release {"method": "GET", "route": "/api/users/{id}"}
+func (v *Prouter) MountQuarantine(rg *RouterGroup) {
+	rg.GET("/quarantine", func(ctx *Context) (Response, error) {
+		return SuccessResponse(v.Quarantined()), nil
+	})
+
+	rg.POST("/quarantine/release", func(ctx *Context) (Response, error) {
+		req := new(releaseRequest)
+		if err := ctx.Bind(req); err != nil {
+			return nil, err
+		}
+		if err := v.ReleaseRoute(req.Method, req.Route); err != nil {
+			return nil, MsgError(http.StatusNotFound, err.Error()).
+				SetComponent(ErrProuter).
+				SetResponseType(NotFound)
+		}
+		return SuccessResponse(nil), nil
+	})
+}
@@ -0,0 +1,214 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultQueryLimit    = 20
+	defaultQueryMaxLimit = 100
+)
+
+type FilterOp string
+
+const (
+	OpEq   FilterOp = "eq"
+	OpNe   FilterOp = "ne"
+	OpGt   FilterOp = "gt"
+	OpGte  FilterOp = "gte"
+	OpLt   FilterOp = "lt"
+	OpLte  FilterOp = "lte"
+	OpIn   FilterOp = "in"
+	OpLike FilterOp = "like"
+)
+
+// Filter is a condition of ?filter[field][op]=value, Values has one entry except for OpIn.
+// Field is always one of the allowlist, so it is safe to use as column name.
+type Filter struct {
+	Field  string
+	Op     FilterOp
+	Values []string
+}
+
+func (f Filter) Value() string {
+	if len(f.Values) == 0 {
+		return ""
+	}
+	return f.Values[0]
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// QuerySpec is the parsed filtering, sorting and paging of a list request.
+type QuerySpec struct {
+	Filters []Filter
+	Sort    []SortField
+	Limit   int
+	Offset  int
+}
+
+// Filter returns the first filter on field.
+func (q *QuerySpec) Filter(field string) (Filter, bool) {
+	for _, f := range q.Filters {
+		if f.Field == field {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
+
+// QueryAllowlist declares which fields a list route can be filtered and sorted by.
+type QueryAllowlist struct {
+	// Filters maps the field to its allowed operators, no operators allows OpEq only
+	Filters map[string][]FilterOp
+	Sort    []string
+	// DefaultSort applies when the request has no sort
+	DefaultSort  []SortField
+	DefaultLimit int
+	// MaxLimit caps the limit of the request
+	MaxLimit int
+}
+
+// WithQuerySpec declares the allowlist ctx.QuerySpec validates the query against.
+func WithQuerySpec(allow QueryAllowlist) RouteOption {
+	return func(c *routeConfig) {
+		c.queryAllowlist = &allow
+	}
+}
+
+func queryError(format string, args ...any) error {
+	return MsgError(http.StatusBadRequest, fmt.Sprintf(format, args...)).
+		SetComponent(ErrProuter).
+		SetResponseType(BadRequest)
+}
+
+// parseFilterKey splits filter[field] and filter[field][op]
+func parseFilterKey(key string) (field string, op FilterOp, ok bool) {
+	rest, found := strings.CutPrefix(key, "filter[")
+	if !found {
+		return "", "", false
+	}
+
+	field, rest, found = strings.Cut(rest, "]")
+	if !found || field == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return field, OpEq, true
+	}
+
+	opStr, found := strings.CutPrefix(rest, "[")
+	if !found || !strings.HasSuffix(opStr, "]") {
+		return "", "", false
+	}
+	return field, FilterOp(strings.TrimSuffix(opStr, "]")), true
+}
+
+func parseQuerySpec(query url.Values, allow *QueryAllowlist) (*QuerySpec, error) {
+	if allow == nil {
+		allow = &QueryAllowlist{}
+	}
+	spec := &QuerySpec{Limit: allow.DefaultLimit, Sort: slices.Clone(allow.DefaultSort)}
+	if spec.Limit <= 0 {
+		spec.Limit = defaultQueryLimit
+	}
+	maxLimit := allow.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = defaultQueryMaxLimit
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		field, op, ok := parseFilterKey(key)
+		if !ok {
+			if strings.HasPrefix(key, "filter") {
+				return nil, queryError("invalid filter: %s", key)
+			}
+			continue
+		}
+
+		ops, allowed := allow.Filters[field]
+		if !allowed {
+			return nil, queryError("filter on %s is not allowed", field)
+		}
+		if len(ops) == 0 {
+			ops = []FilterOp{OpEq}
+		}
+		if !slices.Contains(ops, op) {
+			return nil, queryError("filter operator %s on %s is not allowed", op, field)
+		}
+
+		value := query.Get(key)
+		values := []string{value}
+		if op == OpIn {
+			values = strings.Split(value, ",")
+		}
+		spec.Filters = append(spec.Filters, Filter{Field: field, Op: op, Values: values})
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		spec.Sort = spec.Sort[:0]
+		for _, s := range strings.Split(sort, ",") {
+			s = strings.TrimSpace(s)
+			desc := strings.HasPrefix(s, "-")
+			s = strings.TrimLeft(s, "+-")
+			if !slices.Contains(allow.Sort, s) {
+				return nil, queryError("sort by %s is not allowed", s)
+			}
+			spec.Sort = append(spec.Sort, SortField{Field: s, Desc: desc})
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, queryError("invalid limit: %s", limit)
+		}
+		spec.Limit = n
+	}
+	spec.Limit = min(spec.Limit, maxLimit)
+
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return nil, queryError("invalid offset: %s", offset)
+		}
+		spec.Offset = n
+	}
+
+	return spec, nil
+}
+
+// QuerySpec parses ?filter[status]=active&sort=-created_at&limit=50 against the
+// allowlist of the route given by WithQuerySpec, a field outside of it is
+// answered with 400. Routes without allowlist only accept limit and offset.
+func (c *Context) QuerySpec() (*QuerySpec, error) {
+	if c.querySpec != nil {
+		return c.querySpec, nil
+	}
+
+	var allow *QueryAllowlist
+	if c.route != nil {
+		allow = c.route.queryAllowlist
+	}
+
+	spec, err := parseQuerySpec(c.QueryValues(), allow)
+	if err != nil {
+		return nil, err
+	}
+	c.querySpec = spec
+	return spec, nil
+}
@@ -17,10 +17,9 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
-
-	"github.com/go-puzzles/puzzles/plog"
 )
 
 var (
@@ -30,10 +29,68 @@ var (
 	slash     = []byte("/")
 )
 
-type RecoveryMiddleware struct{}
+type RecoveryMiddleware struct {
+	reporter PanicReporter
+	limiter  *panicLimiter
+
+	quarantine *panicQuarantine
+	converters []PanicConverter
+	details    bool
+}
+
+type RecoveryOption func(*RecoveryMiddleware)
 
-func NewRecoveryMiddleware() *RecoveryMiddleware {
-	return &RecoveryMiddleware{}
+// WithPanicReporter reports recovered panics through r instead of the logger.
+func WithPanicReporter(r PanicReporter) RecoveryOption {
+	return func(m *RecoveryMiddleware) {
+		m.reporter = r
+	}
+}
+
+// WithPanicRateLimit reports at most limit panics with the same fingerprint
+// per window, the rest are counted in PanicReport.Suppressed.
+func WithPanicRateLimit(limit int, window time.Duration) RecoveryOption {
+	return func(m *RecoveryMiddleware) {
+		m.limiter = newPanicLimiter(limit, window)
+	}
+}
+
+func NewRecoveryMiddleware(opts ...RecoveryOption) *RecoveryMiddleware {
+	m := &RecoveryMiddleware{
+		reporter: logPanicReporter{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *RecoveryMiddleware) report(ctx *Context, recoverErr any, frames []string, stack []byte, request string) {
+	report := &PanicReport{
+		Fingerprint: panicFingerprint(recoverErr, frames),
+		Value:       recoverErr,
+		Frames:      frames,
+		Stack:       stack,
+		Request:     request,
+		Route:       ctx.RouteTemplate(),
+		Time:        ctx.Now(),
+	}
+
+	if m.quarantine != nil {
+		m.quarantine.record(ctx, report)
+	}
+
+	if m.limiter != nil {
+		ok, suppressed := m.limiter.allow(report.Fingerprint, report.Time)
+		if !ok {
+			return
+		}
+		report.Suppressed = suppressed
+	}
+
+	m.reporter.ReportPanic(ctx, report)
 }
 
 func stack(skip int) []byte {
@@ -101,6 +158,12 @@ func (m *RecoveryMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 
 		defer func() {
 			if recoverErr := recover(); recoverErr != nil {
+				if converted, ok := m.convert(ctx, recoverErr); ok {
+					resp, err = nil, converted
+					return
+				}
+				frames := panicFrames(fingerprintFrames)
+
 				// Check for a broken connection, as it is not really a
 				// condition that warrants a panic stack trace.
 				var brokenPipe bool
@@ -132,11 +195,25 @@ func (m *RecoveryMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 						fmt.Errorf("%s. Headers: %s", recoverErr, headersToStr),
 					).SetComponent(ErrRecovery)
 				} else {
+					var msgs []string
+					if ctx.router != nil {
+						if msg, ok := ctx.router.message(r, MsgInternalServerError); ok {
+							msgs = append(msgs, msg)
+						}
+					}
 					err = NewErr(
 						http.StatusInternalServerError,
 						fmt.Errorf("panic recovered: %s", recoverErr),
+						msgs...,
 					).SetComponent(ErrRecovery)
-					plog.Errorc(ctx, string(stack))
+					m.report(ctx, recoverErr, frames, stack, headersToStr)
+					if m.details {
+						resp = SuccessResponse(PanicDetails{
+							Panic:  fmt.Sprint(recoverErr),
+							Frames: frames,
+							Stack:  string(stack),
+						})
+					}
 				}
 			}
 		}()
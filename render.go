@@ -0,0 +1,252 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// KeyCase is the case policy of the JSON keys written by the router.
+type KeyCase int
+
+const (
+	KeepCase KeyCase = iota
+	// SnakeCase writes userID and user-id as user_id
+	SnakeCase
+	// CamelCase writes user_id and UserID as userId
+	CamelCase
+)
+
+type renderer struct {
+	keyCase KeyCase
+
+	int64AsString bool
+	timeLayout    string
+	timeLocation  *time.Location
+	decimals      map[reflect.Type]DecimalFormat
+
+	emptyCollections bool
+	emptyFields      EmptyFieldPolicy
+	// types caches needsConvert
+	types sync.Map
+}
+
+type RenderOption func(*renderer)
+
+// WithKeyCase converts every object key of the response, map keys and the
+// output of MarshalJSON included, the Go structs keep their tags.
+func WithKeyCase(c KeyCase) RenderOption {
+	return func(r *renderer) {
+		r.keyCase = c
+	}
+}
+
+// WithRenderer sets the policies applied when the router serializes responses.
+func WithRenderer(opts ...RenderOption) RouterOption {
+	return func(v *Prouter) {
+		r := &renderer{}
+		for _, opt := range opts {
+			opt(r)
+		}
+		v.renderer = r
+	}
+}
+
+// splitWords splits at _, - and spaces and at case changes, an acronym is kept
+// as one word: HTTPServerID is HTTP, Server and ID
+func splitWords(s string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(s)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+func (r *renderer) key(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return s
+	}
+
+	switch r.keyCase {
+	case SnakeCase:
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		return strings.Join(words, "_")
+	case CamelCase:
+		var b strings.Builder
+		for i, w := range words {
+			w = strings.ToLower(w)
+			if i > 0 {
+				first, size := utf8.DecodeRuneInString(w)
+				b.WriteRune(unicode.ToUpper(first))
+				w = w[size:]
+			}
+			b.WriteString(w)
+		}
+		return b.String()
+	}
+	return s
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	// drop the newline of Encode
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// rewriteKeys streams the encoded JSON and replaces the object keys, the order
+// of the keys and the number literals are kept.
+func (r *renderer) rewriteKeys(src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	type frame struct {
+		object bool
+		n      int
+	}
+	var (
+		out   bytes.Buffer
+		stack []frame
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			out.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			isKey := top.object && top.n%2 == 0
+			switch {
+			case top.object && !isKey:
+				out.WriteByte(':')
+			case top.n > 0:
+				out.WriteByte(',')
+			}
+			top.n++
+			if isKey {
+				if err := writeJSONString(&out, r.key(tok.(string))); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(t))
+			stack = append(stack, frame{object: t == '{'})
+		case string:
+			if err := writeJSONString(&out, t); err != nil {
+				return nil, err
+			}
+		case json.Number:
+			out.WriteString(t.String())
+		case bool:
+			out.WriteString(strconv.FormatBool(t))
+		case nil:
+			out.WriteString("null")
+		}
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func (r *renderer) render(v any) ([]byte, error) {
+	// the envelope is created per response, its data can be replaced
+	if resp, ok := v.(Response); ok && r.hasDataPolicies() {
+		data, err := r.convert(reflect.ValueOf(resp.GetData()), 0, true)
+		if err != nil {
+			return nil, err
+		}
+		resp.SetData(data)
+	}
+	if v != nil && r.hasValuePolicies() {
+		converted, err := r.convert(reflect.ValueOf(v), 0, false)
+		if err != nil {
+			return nil, err
+		}
+		v = converted
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if r.keyCase == KeepCase {
+		return bytes.Clone(buf.Bytes()), nil
+	}
+	return r.rewriteKeys(buf.Bytes())
+}
+
+// writeJSON is WriteJSON with the policies of WithRenderer
+func (v *Prouter) writeJSON(w http.ResponseWriter, code int, data any) error {
+	if v.renderer == nil {
+		return WriteJSON(w, code, data)
+	}
+
+	body, err := v.renderer.render(data)
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return errors.Wrap(err, "render response")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(code)
+	_, err = w.Write(body)
+	return err
+}
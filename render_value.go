@@ -0,0 +1,505 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// maxRenderDepth matches the depth encoding/json starts to suspect a cycle at
+const maxRenderDepth = 1000
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	anyType           = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// DecimalFormat is how WithDecimalTypes writes decimal values.
+type DecimalFormat int
+
+const (
+	// DecimalString writes "12.50", exact in every client
+	DecimalString DecimalFormat = iota
+	// DecimalNumber writes 12.50 as number literal
+	DecimalNumber
+)
+
+// WithInt64AsString writes int64 and uint64 values as strings, JavaScript loses
+// precision beyond 2^53. int stays a number, so the envelope code is unchanged.
+func WithInt64AsString() RenderOption {
+	return func(r *renderer) {
+		r.int64AsString = true
+	}
+}
+
+// WithTimeFormat writes time.Time values with layout in loc, a nil loc keeps the
+// zone of the value.
+func WithTimeFormat(layout string, loc *time.Location) RenderOption {
+	return func(r *renderer) {
+		r.timeLayout = layout
+		r.timeLocation = loc
+	}
+}
+
+// WithDecimalTypes writes the types of samples, which have to implement
+// fmt.Stringer, in format whatever their MarshalJSON does:
+//
+//	prouter.WithDecimalTypes(prouter.DecimalString, decimal.Decimal{})
+func WithDecimalTypes(format DecimalFormat, samples ...any) RenderOption {
+	return func(r *renderer) {
+		if r.decimals == nil {
+			r.decimals = make(map[reflect.Type]DecimalFormat)
+		}
+		for _, s := range samples {
+			t := reflect.TypeOf(s)
+			if !t.Implements(stringerType) && !reflect.PointerTo(t).Implements(stringerType) {
+				panic("prouter: decimal type " + t.String() + " does not implement fmt.Stringer")
+			}
+			r.decimals[t] = format
+		}
+	}
+}
+
+// EmptyFieldPolicy decides how WithEmptyFields writes the empty fields of the data payload.
+type EmptyFieldPolicy int
+
+const (
+	// EmptyKeep follows the omitempty tags
+	EmptyKeep EmptyFieldPolicy = iota
+	// EmptyOmit leaves every empty field out
+	EmptyOmit
+	// EmptyNull writes every empty field as null, omitempty included
+	EmptyNull
+)
+
+// WithEmptyCollections writes nil slices and maps of the data payload as [] and
+// {}, fields tagged omitempty are still left out.
+func WithEmptyCollections() RenderOption {
+	return func(r *renderer) {
+		r.emptyCollections = true
+	}
+}
+
+// WithEmptyFields applies policy to the struct fields of the data payload which
+// are nil, an empty string or an empty slice or map. Numbers and booleans are
+// never empty, and the collections normalized by WithEmptyCollections neither.
+func WithEmptyFields(policy EmptyFieldPolicy) RenderOption {
+	return func(r *renderer) {
+		r.emptyFields = policy
+	}
+}
+
+func (r *renderer) hasValuePolicies() bool {
+	return r.int64AsString || r.timeLayout != "" || len(r.decimals) > 0
+}
+
+func (r *renderer) hasDataPolicies() bool {
+	return r.emptyCollections || r.emptyFields != EmptyKeep
+}
+
+// walkData reports whether values of t are walked for the empty policies
+func (r *renderer) walkData(t reflect.Type) bool {
+	if !r.hasDataPolicies() || isMarshaler(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Pointer, reflect.Interface, reflect.Struct, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+func (r *renderer) emptyField(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Slice:
+		if r.emptyCollections && v.Type().Elem().Kind() != reflect.Uint8 {
+			return false
+		}
+		return v.Len() == 0
+	case reflect.Map:
+		return !r.emptyCollections && v.Len() == 0
+	}
+	return false
+}
+
+func isMarshaler(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+// policyType reports whether values of t are written by a policy
+func (r *renderer) policyType(t reflect.Type) bool {
+	if _, ok := r.decimals[t]; ok {
+		return true
+	}
+	if r.timeLayout != "" && t == timeType {
+		return true
+	}
+	return r.int64AsString && (t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64) && !isMarshaler(t)
+}
+
+// needsConvert reports whether values of t can hold a value written by a policy
+func (r *renderer) needsConvert(t reflect.Type) bool {
+	if ret, ok := r.types.Load(t); ok {
+		return ret.(bool)
+	}
+
+	ret := r.scanType(t, make(map[reflect.Type]bool))
+	r.types.Store(t, ret)
+	return ret
+}
+
+func (r *renderer) scanType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if r.policyType(t) {
+		return true
+	}
+	if seen[t] || isMarshaler(t) {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return r.scanType(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if sf := t.Field(i); (sf.IsExported() || sf.Anonymous) && r.scanType(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type renderField struct {
+	key   string
+	value any
+}
+
+// renderObject is a struct rewritten for the policies, it keeps the field order
+type renderObject []renderField
+
+func (o renderObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSONString(&buf, f.key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := enc.Encode(f.value); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type jsonField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+var jsonFieldCache sync.Map // reflect.Type -> []jsonField
+
+// jsonFields lists the fields encoding/json writes for t, embedded structs are
+// flattened with the same dominance rules.
+func jsonFields(t reflect.Type) []jsonField {
+	if ret, ok := jsonFieldCache.Load(t); ok {
+		return ret.([]jsonField)
+	}
+
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var (
+		fields  []jsonField
+		current []embedded
+		next    = []embedded{{typ: t}}
+		visited = map[reflect.Type]bool{}
+	)
+	for len(next) > 0 {
+		current, next = next, nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := range e.typ.NumField() {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous {
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(e.index), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+
+				f := jsonField{name: name, index: index, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					switch opt {
+					case "omitempty":
+						f.omitEmpty = true
+					case "string":
+						switch ft.Kind() {
+						case reflect.Bool, reflect.String,
+							reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64:
+							f.quoted = true
+						}
+					}
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	// keep the dominant field of every name: the shallowest, at equal depth the
+	// only tagged one, otherwise the name is dropped
+	slices.SortStableFunc(fields, func(a, b jsonField) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return len(a.index) - len(b.index)
+	})
+	ret := make([]jsonField, 0, len(fields))
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		group := fields[i:j]
+		i = j
+
+		depth := len(group[0].index)
+		var dominant []jsonField
+		for _, f := range group {
+			if len(f.index) == depth {
+				dominant = append(dominant, f)
+			}
+		}
+		if len(dominant) > 1 {
+			tagged := slices.DeleteFunc(slices.Clone(dominant), func(f jsonField) bool { return !f.tagged })
+			if len(tagged) != 1 {
+				continue
+			}
+			dominant = tagged
+		}
+		ret = append(ret, dominant[0])
+	}
+	slices.SortFunc(ret, func(a, b jsonField) int {
+		return slices.Compare(a.index, b.index)
+	})
+
+	jsonFieldCache.Store(t, ret)
+	return ret
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// readable returns v so that Interface works, fields promoted from unexported
+// embedded structs are read through their address as encoding/json does
+func readable(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// addressable copies v if needed, so the fields of a struct can be read through readable
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	cp := reflect.New(v.Type()).Elem()
+	cp.Set(v)
+	return cp
+}
+
+func (r *renderer) policyValue(v reflect.Value) (any, error) {
+	t := v.Type()
+	if format, ok := r.decimals[t]; ok {
+		var s string
+		if sv, ok := v.Interface().(fmt.Stringer); ok {
+			s = sv.String()
+		} else {
+			s = addressable(v).Addr().Interface().(fmt.Stringer).String()
+		}
+		if format == DecimalNumber {
+			return json.Number(s), nil
+		}
+		return s, nil
+	}
+
+	if t == timeType && r.timeLayout != "" {
+		tm := v.Interface().(time.Time)
+		if r.timeLocation != nil {
+			tm = tm.In(r.timeLocation)
+		}
+		return tm.Format(r.timeLayout), nil
+	}
+
+	if t.Kind() == reflect.Int64 {
+		return strconv.FormatInt(v.Int(), 10), nil
+	}
+	return strconv.FormatUint(v.Uint(), 10), nil
+}
+
+// convert rewrites the values written by a policy, the rest is left to
+// encoding/json. inData applies the empty policies of the data payload.
+func (r *renderer) convert(v reflect.Value, depth int, inData bool) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if depth > maxRenderDepth {
+		return nil, fmt.Errorf("prouter: render %s: too deep, cyclic value?", v.Type())
+	}
+
+	v = readable(v)
+	t := v.Type()
+	if r.policyType(t) {
+		return r.policyValue(v)
+	}
+	if !r.needsConvert(t) && !(inData && r.walkData(t)) {
+		return v.Interface(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return r.convert(v.Elem(), depth+1, inData)
+	case reflect.Struct:
+		v = addressable(v)
+		obj := make(renderObject, 0, t.NumField())
+		for _, f := range jsonFields(t) {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				// behind a nil embedded pointer
+				continue
+			}
+			fv = readable(fv)
+			if inData && r.emptyFields != EmptyKeep && r.emptyField(fv) {
+				if r.emptyFields == EmptyNull {
+					obj = append(obj, renderField{key: f.name})
+				}
+				continue
+			}
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+
+			var value any
+			if f.quoted {
+				b, err := json.Marshal(fv.Interface())
+				if err != nil {
+					return nil, err
+				}
+				value = string(b)
+			} else if value, err = r.convert(fv, depth+1, inData); err != nil {
+				return nil, err
+			}
+			obj = append(obj, renderField{key: f.name, value: value})
+		}
+		return obj, nil
+	case reflect.Map:
+		m := reflect.MakeMapWithSize(reflect.MapOf(t.Key(), anyType), v.Len())
+		if v.IsNil() {
+			if inData && r.emptyCollections {
+				return m.Interface(), nil
+			}
+			return nil, nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := r.convert(iter.Value(), depth+1, inData)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				m.SetMapIndex(iter.Key(), reflect.Zero(anyType))
+			} else {
+				m.SetMapIndex(iter.Key(), reflect.ValueOf(value))
+			}
+		}
+		return m.Interface(), nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() && !(inData && r.emptyCollections) {
+			return nil, nil
+		}
+		ret := make([]any, v.Len())
+		for i := range v.Len() {
+			value, err := r.convert(v.Index(i), depth+1, inData)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = value
+		}
+		return ret, nil
+	}
+	return v.Interface(), nil
+}
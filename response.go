@@ -30,12 +30,22 @@ func NewResponseTmpl() ResponseTmpl {
 	return reflect.New(responseTmpl).Interface().(ResponseTmpl)
 }
 
-var _ Response = (*Ret)(nil)
+var (
+	_ Response       = (*Ret)(nil)
+	_ LinksResponse  = (*Ret)(nil)
+	_ ErrorsResponse = (*Ret)(nil)
+	_ MetaResponse   = (*Ret)(nil)
+)
 
 type Ret struct {
 	Code    int    `json:"code"`
 	Data    any    `json:"data,omitempty"`
 	Message string `json:"message,omitempty"`
+	Links   Links  `json:"links,omitempty"`
+	// Errors holds the field messages of ValidationErrors
+	Errors map[string]string `json:"errors,omitempty"`
+	// Meta holds diagnostics of the request like the trace id of WithTraceDebug
+	Meta map[string]any `json:"meta,omitempty"`
 }
 
 func (r *Ret) SetCode(i int) Response {
@@ -65,6 +75,33 @@ func (r *Ret) GetMessage() string {
 	return r.Message
 }
 
+func (r *Ret) SetLinks(l Links) Response {
+	r.Links = l
+	return r
+}
+
+func (r *Ret) GetLinks() Links {
+	return r.Links
+}
+
+func (r *Ret) SetErrors(errs map[string]string) Response {
+	r.Errors = errs
+	return r
+}
+
+func (r *Ret) GetErrors() map[string]string {
+	return r.Errors
+}
+
+func (r *Ret) SetMeta(meta map[string]any) Response {
+	r.Meta = meta
+	return r
+}
+
+func (r *Ret) GetMeta() map[string]any {
+	return r.Meta
+}
+
 func SuccessResponse(data any) Response {
 	ret := NewResponseTmpl()
 	ret.SetCode(http.StatusOK).SetData(data)
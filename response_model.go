@@ -0,0 +1,65 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/pkg/errors"
+)
+
+// WithResponseModel declares the type of the data the route answers, e.g.
+// WithResponseModel(User{}) or WithResponseModel([]User{}). In DebugMode a
+// successful response whose data does not decode into the model, or fails its
+// binding tags, is logged and answered with 500 so contract drift shows up
+// before clients see it. Other modes do not check it.
+func WithResponseModel(model any) RouteOption {
+	return func(c *routeConfig) {
+		c.responseModel = reflect.TypeOf(model)
+	}
+}
+
+// checkResponseModel round trips data through JSON into a new model, the
+// model is validated by the validator of the router, see WithValidator
+func checkResponseModel(ctx *Context, model reflect.Type, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "encode response")
+	}
+
+	v := reflect.New(model)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v.Interface()); err != nil {
+		return err
+	}
+
+	for model.Kind() == reflect.Pointer {
+		model = model.Elem()
+	}
+	switch model.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array:
+		return ctx.validate(v.Interface())
+	}
+	return nil
+}
+
+func (v *Prouter) checkResponse(ctx *Context, resp Response, err error) (Response, error) {
+	if prouterMode != DebugMode || err != nil || resp == nil || ctx.route == nil || ctx.route.responseModel == nil {
+		return resp, err
+	}
+	if resp.GetCode() >= http.StatusMultipleChoices {
+		return resp, err
+	}
+
+	if mismatch := checkResponseModel(ctx, ctx.route.responseModel, resp.GetData()); mismatch != nil {
+		plog.Errorc(ctx, "response of %s does not match its model %s: %v",
+			routeKey(ctx.Request.Method, ctx.RouteTemplate()), ctx.route.responseModel, mismatch)
+		return nil, NewErr(http.StatusInternalServerError, mismatch, "response does not match the model "+ctx.route.responseModel.String()).
+			SetComponent(ErrProuter).
+			SetResponseType(InternalServerError)
+	}
+	return resp, err
+}
@@ -0,0 +1,48 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type modelUser struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func TestResponseModelUsesTheRouterValidator(t *testing.T) {
+	rejectBob := ValidatorFunc(func(obj any) error {
+		if u, ok := obj.(*modelUser); ok && u.Name == "bob" {
+			return errors.New("bob is not allowed")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name string
+		opts []RouterOption
+		user modelUser
+		code int
+	}{
+		{"router validator rejects", []RouterOption{WithValidator(rejectBob)}, modelUser{Name: "bob"}, http.StatusInternalServerError},
+		{"router validator accepts", []RouterOption{WithValidator(rejectBob)}, modelUser{Name: "ann"}, http.StatusOK},
+		{"router validator replaces binding tags", []RouterOption{WithValidator(rejectBob)}, modelUser{}, http.StatusOK},
+		{"default validator checks binding tags", nil, modelUser{}, http.StatusInternalServerError},
+		{"default validator accepts", nil, modelUser{Name: "bob"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New(tt.opts...)
+			router.GET("/user", func(*Context) (Response, error) {
+				return SuccessResponse(tt.user), nil
+			}, WithResponseModel(modelUser{}))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/user", nil))
+			if rec.Code != tt.code {
+				t.Errorf("code = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+		})
+	}
+}
@@ -1,24 +1,115 @@
 package prouter
 
-import "net/http"
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
 
 type ResponseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
+	counter     *countingWriter
+
+	// finishers run after the response envelope was written
+	finishers []func()
+	completed atomic.Pointer[completedResponse]
 }
 
 func (w *ResponseWriter) WriteHeader(code int) {
+	if w.lateWrite("WriteHeader") {
+		return
+	}
 	if code > 0 && w.statusCode != code {
 		w.statusCode = code
 	}
+	w.wroteHeader = true
 
 	w.ResponseWriter.WriteHeader(code)
 }
 
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.lateWrite("Write") {
+		return 0, ErrWriteAfterResponse
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Written reports whether the status or a part of the body was sent.
+func (w *ResponseWriter) Written() bool {
+	return w.wroteHeader || w.Size() > 0
+}
+
 func (w *ResponseWriter) StatusCode() int {
 	return w.statusCode
 }
 
+// Size returns the bytes of the response body written to the connection so
+// far, after any content encoding.
+func (w *ResponseWriter) Size() int64 {
+	return w.counter.n.Load()
+}
+
+// Flush sends buffered data to the client if the underlying writer supports it.
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, e.g. to upgrade it to a WebSocket.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// OnFinish registers fn to run once the response is completely written,
+// e.g. to close a writer a middleware wrapped around the underlying one.
+func (w *ResponseWriter) OnFinish(fn func()) {
+	w.finishers = append(w.finishers, fn)
+}
+
+func (w *ResponseWriter) finish() {
+	for i := len(w.finishers) - 1; i >= 0; i-- {
+		w.finishers[i]()
+	}
+	w.finishers = nil
+}
+
 func WrapResponseWriter(w http.ResponseWriter) *ResponseWriter {
-	return &ResponseWriter{w, http.StatusOK}
+	cw := &countingWriter{ResponseWriter: w}
+	return &ResponseWriter{ResponseWriter: cw, statusCode: http.StatusOK, counter: cw}
+}
+
+// countingWriter sits directly on the connection writer so middlewares which
+// wrap ResponseWriter, like compression, are counted with their output
+type countingWriter struct {
+	http.ResponseWriter
+	n atomic.Int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n.Add(int64(n))
+	return n, err
+}
+
+func (w *countingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *countingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
@@ -0,0 +1,72 @@
+package prouter
+
+import (
+	"net/http"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// Result is a Response with headers and cookies, which are set on the response
+// when the router writes it. Handlers return it instead of touching ctx.Writer:
+//
+//	return prouter.ResultOf(prouter.SuccessResponse(user)).
+//		WithHeader("Location", "/users/"+user.ID).
+//		WithCookie(&http.Cookie{Name: "last_user", Value: user.ID}), nil
+type Result struct {
+	Response
+	header  http.Header
+	cookies []*http.Cookie
+}
+
+// ResultOf wraps resp, a nil resp is an empty SuccessResponse so middlewares
+// can read the data of every Result.
+func ResultOf(resp Response) *Result {
+	if r, ok := resp.(*Result); ok {
+		return r
+	}
+	if resp == nil {
+		resp = SuccessResponse(nil)
+	}
+	return &Result{Response: resp, header: make(http.Header)}
+}
+
+// WithHeader adds value to the header key, the values of key replace those set
+// on ctx.Writer.
+func (r *Result) WithHeader(key, value string) *Result {
+	r.header.Add(key, value)
+	return r
+}
+
+func (r *Result) WithCookie(c *http.Cookie) *Result {
+	r.cookies = append(r.cookies, c)
+	return r
+}
+
+func (r *Result) Header() http.Header {
+	return r.header
+}
+
+func (r *Result) Cookies() []*http.Cookie {
+	return r.cookies
+}
+
+// applyResult sets the headers and cookies of a Result and returns the response it wraps
+func applyResult(ctx *Context, resp Response) Response {
+	r, ok := resp.(*Result)
+	if !ok {
+		return resp
+	}
+
+	if ctx.Writer.Written() {
+		plog.Warnc(ctx, "handler of %s returned headers after writing the response, they are dropped", ctx.RouteTemplate())
+		return r.Response
+	}
+	h := ctx.Writer.Header()
+	for key, values := range r.header {
+		h[key] = values
+	}
+	for _, c := range r.cookies {
+		http.SetCookie(ctx.Writer, c)
+	}
+	return r.Response
+}
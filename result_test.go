@@ -0,0 +1,28 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResultOfNilThroughMiddlewares(t *testing.T) {
+	router := New()
+	router.UseMiddleware(
+		NewMaskMiddleware(),
+		NewLocalizeMiddleware(),
+	)
+	router.GET("/", func(*Context) (Response, error) {
+		return ResultOf(nil).WithHeader("X-Result", "1"), nil
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("code = %d, want 200", rec.Code)
+	}
+	if rec.Header().Get("X-Result") != "1" {
+		t.Errorf("header of the Result not set")
+	}
+}
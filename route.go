@@ -1,6 +1,7 @@
 package prouter
 
 import (
+	"reflect"
 	"slices"
 	"strings"
 
@@ -15,18 +16,87 @@ type Route interface {
 
 type OptRoute interface {
 	Route
-	Option(*mux.Route) *mux.Route
+	Options() []RouteOption
 }
 
 // iRoute the final iRoute group which will use to register into mux.Router
 type iRoute struct {
 	Route
-	router      *mux.Router
-	middleware  []Middleware
-	routeOption RouteOption
+	router       *mux.Router
+	middleware   []Middleware
+	values       []contextValue
+	routeOptions []RouteOption
 }
 
-type RouteOption func(*mux.Route) *mux.Route
+// RouteOption configures a route while it is registered.
+type RouteOption func(*routeConfig)
+
+// routeConfig collects the settings of a route given by its RouteOptions
+type routeConfig struct {
+	route    *mux.Route
+	params   *routeParams
+	examples []Example
+	// disabled routes are declared but not served
+	disabled       bool
+	compression    routeCompression
+	slo            *SLO
+	queryAllowlist *QueryAllowlist
+	surrogateKeys  []string
+	preconditions  *Preconditions
+	payloadLimit   int
+	cors           *CORSConfig
+	schemaURL      string
+	capabilities   map[string]any
+	discoverable   bool
+	unknownFields  UnknownFieldPolicy
+	responseModel  reflect.Type
+	maxBodySize    int64
+	spoolThreshold int64
+	warmup         WarmupFunc
+	// err is the first error of an option, it fails the registration
+	err error
+}
+
+// fail records err unless an earlier option already failed.
+func (c *routeConfig) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// MuxOption is the escape hatch to configure the underlying mux route directly.
+func MuxOption(fn func(*mux.Route) *mux.Route) RouteOption {
+	return func(c *routeConfig) {
+		c.route = fn(c.route)
+	}
+}
+
+// routeInfo describes the registered route which served the request
+type routeInfo struct {
+	method string
+	// template is the path pattern as declared, including the group prefix,
+	// hasVars is set when it has path, host or query variables
+	template       string
+	hasVars        bool
+	name           string
+	examples       []Example
+	compression    routeCompression
+	slo            *SLO
+	queryAllowlist *QueryAllowlist
+	traffic        *routeTraffic
+	surrogateKeys  []string
+	preconditions  *Preconditions
+	payloadLimit   int
+	cors           *CORSConfig
+	schemaURL      string
+	capabilities   map[string]any
+	discoverable   bool
+	unknownFields  UnknownFieldPolicy
+	responseModel  reflect.Type
+	maxBodySize    int64
+	spoolThreshold int64
+	warmup         WarmupFunc
+}
 
 func (r *iRoute) handleSpecifyMiddleware(handler handlerFunc) handlerFunc {
 	next := handler
@@ -58,13 +128,8 @@ func (r *defaultRoute) Method() string {
 	return r.method
 }
 
-func (r *defaultRoute) Option(route *mux.Route) *mux.Route {
-	next := route
-	for _, opt := range r.opts {
-		next = opt(next)
-	}
-
-	return next
+func (r *defaultRoute) Options() []RouteOption {
+	return r.opts
 }
 
 func newHandlerFuncRoute(method, path string, handler handlerFunc, opts ...RouteOption) Route {
@@ -0,0 +1,249 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/gorilla/mux"
+)
+
+var (
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrDrainTimeout is returned when the requests of a removed or replaced handler
+	// did not finish within the drain timeout, the route change is applied anyway
+	ErrDrainTimeout = errors.New("route drain timeout")
+)
+
+const drainPollInterval = 10 * time.Millisecond
+
+type routeState struct {
+	// route is the handler and the middlewares handler is composed of
+	route       iRoute
+	handler     http.HandlerFunc
+	handlerName string
+	removed     bool
+	// quarantined routes panicked too often, see WithQuarantine
+	quarantined bool
+	// inflight counts the requests served by handler
+	inflight *atomic.Int64
+}
+
+func newRouteState(route iRoute, handler http.HandlerFunc) *routeState {
+	return &routeState{route: route, handler: handler, handlerName: route.Handler().Name(), inflight: new(atomic.Int64)}
+}
+
+// WithDrainTimeout makes RemoveRoute and ReplaceRoute wait up to d for the
+// requests in flight on the previous handler to finish.
+func WithDrainTimeout(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.drainTimeout = d
+	}
+}
+
+// drain waits for the requests of state to finish within the drain timeout
+func (v *Prouter) drain(state *routeState) error {
+	if v.drainTimeout <= 0 {
+		return nil
+	}
+
+	// the timeout runs on the router clock, the requests are polled in real time
+	timeout := clockAfter(v.clock, v.drainTimeout)
+	poll := time.NewTicker(drainPollInterval)
+	defer poll.Stop()
+
+	for state.inflight.Load() > 0 {
+		select {
+		case <-timeout:
+			return ErrDrainTimeout
+		case <-poll.C:
+		}
+	}
+	return nil
+}
+
+// routeSlot sits between a mux route and its handler so the handler can be
+// swapped or disabled at runtime without touching the mux route table, which
+// is not safe to modify while serving. Every change of the state is a
+// CompareAndSwap of a copy, so concurrent changes are not lost.
+type routeSlot struct {
+	info   *routeInfo
+	params *routeParams
+	state  atomic.Pointer[routeState]
+
+	group *groupStats
+	stats inflightCounter
+}
+
+func newRouteSlot(route iRoute, info *routeInfo, params *routeParams, handler http.HandlerFunc) *routeSlot {
+	s := &routeSlot{info: info, params: params}
+	s.state.Store(newRouteState(route, handler))
+	return s
+}
+
+// match makes a removed route invisible to mux so the request falls through
+// to the next matching route or the NotFound handler.
+func (s *routeSlot) match(*http.Request, *mux.RouteMatch) bool {
+	return !s.state.Load().removed
+}
+
+func (s *routeSlot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.stats.inc()
+	s.group.inc()
+	defer func() {
+		s.stats.dec()
+		s.group.dec()
+	}()
+
+	state := s.acquire()
+	defer state.inflight.Add(-1)
+	if state.quarantined {
+		serveQuarantined(w)
+		return
+	}
+
+	state.handler(w, r)
+}
+
+// acquire returns the current state with the request counted in its inflight.
+// The state is loaded again after counting, so drain cannot miss a request
+// about to run the handler it waits for.
+func (s *routeSlot) acquire() *routeState {
+	state := s.state.Load()
+	inflight := state.inflight
+	inflight.Add(1)
+	for {
+		cur := s.state.Load()
+		if cur == state {
+			return state
+		}
+		// a removed state shares the counter, the request stays counted
+		if cur.inflight != inflight {
+			inflight.Add(-1)
+			inflight = cur.inflight
+			inflight.Add(1)
+		}
+		state = cur
+	}
+}
+
+// recompose applies update to the route of the current state and builds its
+// chain again, e.g. after a middleware was added to its group. Requests in
+// flight finish on the previous chain.
+func (s *routeSlot) recompose(v *Prouter, update func(r *iRoute)) {
+	for {
+		old := s.state.Load()
+		state := *old
+		update(&state.route)
+		state.handler = v.makeHttpHandler(state.route, s.info, s.params)
+		if s.state.CompareAndSwap(old, &state) {
+			return
+		}
+	}
+}
+
+type routeRegistry struct {
+	mu    sync.RWMutex
+	slots map[string]*routeSlot
+	names map[string]*routeSlot
+}
+
+func routeKey(method, template string) string {
+	return method + " " + template
+}
+
+func (rr *routeRegistry) add(s *routeSlot) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if rr.slots == nil {
+		rr.slots = make(map[string]*routeSlot)
+		rr.names = make(map[string]*routeSlot)
+	}
+	rr.slots[routeKey(s.info.method, s.info.template)] = s
+	if s.info.name != "" {
+		rr.names[s.info.name] = s
+	}
+}
+
+func (rr *routeRegistry) lookup(method, template string) *routeSlot {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	return rr.slots[routeKey(method, template)]
+}
+
+func (rr *routeRegistry) lookupName(name string) *routeSlot {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	return rr.names[name]
+}
+
+// ReplaceRoute atomically swaps the handler of the route registered with
+// method and path in this group, a removed route is enabled again. The route
+// keeps its matching conditions, middlewares and param options; in-flight
+// requests finish on the old handler, see WithDrainTimeout to wait for them.
+func (rg *RouterGroup) ReplaceRoute(method, path string, handler HandleFunc) error {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	slot := rg.prouter.routeSlots.lookup(method, strings.TrimRight(rg.prefix, "/")+path)
+	if slot == nil {
+		return ErrRouteNotFound
+	}
+
+	var old *routeState
+	for {
+		old = slot.state.Load()
+		route := old.route
+		route.Route = newHandlerFuncRoute(method, path, handler)
+		state := newRouteState(route, rg.prouter.makeHttpHandler(route, slot.info, slot.params))
+		if slot.state.CompareAndSwap(old, state) {
+			break
+		}
+	}
+
+	rg.debugPrintRouteAction("replace", slot.info, handler.Name())
+	return rg.prouter.drain(old)
+}
+
+// RemoveRoute disables the route named by WithName, requests which matched it
+// fall through to the next matching route or NotFound. Requests in flight
+// finish, see WithDrainTimeout to wait for them.
+func (rg *RouterGroup) RemoveRoute(name string) error {
+	slot := rg.prouter.routeSlots.lookupName(name)
+	if slot == nil {
+		return ErrRouteNotFound
+	}
+
+	var old *routeState
+	for {
+		old = slot.state.Load()
+		state := *old
+		state.removed = true
+		if slot.state.CompareAndSwap(old, &state) {
+			break
+		}
+	}
+
+	rg.debugPrintRouteAction("remove", slot.info, old.handlerName)
+	return rg.prouter.drain(old)
+}
+
+func (rg *RouterGroup) debugPrintRouteAction(action string, info *routeInfo, handlerName string) {
+	if prouterMode != DebugMode {
+		return
+	}
+
+	method := info.method
+	if method == "" {
+		method = "ANY"
+	}
+	plog.Infof("Method: %-6s Router: %-30s Handler: %s (%s)", method, info.template, handlerName, action)
+}
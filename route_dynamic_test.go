@@ -0,0 +1,210 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplaceRouteDrainsEveryOldRequest(t *testing.T) {
+	router := New(WithDrainTimeout(time.Second))
+
+	var drained, late atomic.Bool
+	router.GET("/", func(*Context) (Response, error) {
+		if drained.Load() {
+			late.Store(true)
+		}
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	err := router.ReplaceRoute(http.MethodGet, "/", func(*Context) (Response, error) { return nil, nil })
+	drained.Store(true)
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if late.Load() {
+		t.Error("the replaced handler ran after ReplaceRoute drained it")
+	}
+}
+
+func TestReplaceRouteWhileRecomposing(t *testing.T) {
+	const n = 50
+
+	router := New()
+	api := router.Group("/api")
+	api.GET("/x", func(*Context) (Response, error) { return nil, nil })
+
+	var ran atomic.Int32
+	mw := HandleFunc(func(*Context) (Response, error) {
+		ran.Add(1)
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for range n {
+			api.UseMiddleware(mw)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range n {
+			if err := api.ReplaceRoute(http.MethodGet, "/x", func(*Context) (Response, error) { return nil, nil }); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range n {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/x", nil))
+		}
+	}()
+	wg.Wait()
+
+	// no middleware was lost to a concurrent replace
+	ran.Store(0)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if got := ran.Load(); got != n {
+		t.Errorf("%d late middlewares ran, want %d", got, n)
+	}
+}
+
+func TestRemoveRouteKeepsConcurrentQuarantine(t *testing.T) {
+	for range 100 {
+		router := New()
+		router.GET("/x", func(*Context) (Response, error) { return nil, nil }, WithName("x"))
+		slot := router.routeSlots.lookupName("x")
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			slot.quarantine(true)
+		}()
+		go func() {
+			defer wg.Done()
+			if err := router.RemoveRoute("x"); err != nil {
+				t.Error(err)
+			}
+		}()
+		wg.Wait()
+
+		if st := slot.state.Load(); !st.removed || !st.quarantined {
+			t.Fatalf("state removed %v quarantined %v, want both", st.removed, st.quarantined)
+		}
+	}
+}
+
+func TestDrainTimeoutFollowsTheRouterClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock), WithDrainTimeout(time.Minute))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.GET("/", func(*Context) (Response, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+
+	served := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(served)
+	}()
+	<-started
+	defer func() {
+		close(release)
+		<-served
+	}()
+
+	replaced := make(chan error, 1)
+	go func() {
+		replaced <- router.ReplaceRoute(http.MethodGet, "/", func(*Context) (Response, error) { return nil, nil })
+	}()
+
+	// the wall clock does not end the drain
+	select {
+	case err := <-replaced:
+		t.Fatalf("ReplaceRoute returned %v before the timeout", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.Advance(59 * time.Second)
+	select {
+	case err := <-replaced:
+		t.Fatalf("ReplaceRoute returned %v before the timeout", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case err := <-replaced:
+		if err != ErrDrainTimeout {
+			t.Errorf("ReplaceRoute = %v, want %v", err, ErrDrainTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReplaceRoute still draining after the clock passed the timeout")
+	}
+}
+
+func TestFakeClockAfter(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	soon, later := clock.After(time.Second), clock.After(time.Minute)
+
+	clock.Advance(time.Second)
+	select {
+	case now := <-soon:
+		if !now.Equal(clock.Now()) {
+			t.Errorf("fired with %s, want %s", now, clock.Now())
+		}
+	default:
+		t.Fatal("timer not fired when the clock reached it")
+	}
+	select {
+	case <-later:
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Set(clock.Now().Add(time.Hour))
+	select {
+	case <-later:
+	default:
+		t.Fatal("timer not fired by Set")
+	}
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("timer of 0 not fired at once")
+	}
+}
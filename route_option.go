@@ -0,0 +1,80 @@
+package prouter
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// WithName names the route, the name can be read with ctx.RouteName.
+func WithName(name string) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.Name(name)
+	})
+}
+
+// RequireQuery matches requests whose query contains key with value. The value
+// accepts the mux variable syntax, e.g. RequireQuery("page", "{page:[0-9]+}").
+func RequireQuery(key, value string) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.Queries(key, value)
+	})
+}
+
+// RequireHeader matches requests with header key equal to value,
+// an empty value only requires the header to be present.
+func RequireHeader(key, value string) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.Headers(key, value)
+	})
+}
+
+// RequireHeaderRegexp matches requests with header key matching pattern.
+func RequireHeaderRegexp(key, pattern string) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.HeadersRegexp(key, pattern)
+	})
+}
+
+// RequireHost matches requests for host, which accepts the mux variable syntax.
+func RequireHost(host string) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.Host(host)
+	})
+}
+
+// RequireSchemes matches requests with one of the url schemes.
+func RequireSchemes(schemes ...string) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.Schemes(schemes...)
+	})
+}
+
+// MatchIf matches requests for which fn returns true.
+func MatchIf(fn func(r *http.Request) bool) RouteOption {
+	return MuxOption(func(r *mux.Route) *mux.Route {
+		return r.MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+			return fn(req)
+		})
+	})
+}
+
+// WithEnabledWhen serves the route only when fn returns true at registration,
+// so environment specific routes can be declared inline.
+func WithEnabledWhen(fn func() bool) RouteOption {
+	return func(c *routeConfig) {
+		if !fn() {
+			c.disabled = true
+		}
+	}
+}
+
+// WithEnvGate serves the route only when the environment variable name is set to a true value.
+func WithEnvGate(name string) RouteOption {
+	return WithEnabledWhen(func() bool {
+		enabled, _ := strconv.ParseBool(os.Getenv(name))
+		return enabled
+	})
+}
@@ -0,0 +1,149 @@
+package prouter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// RouteEntry is a served route in RouteTable, it is JSON encodable to be kept
+// as the snapshot of a deploy.
+type RouteEntry struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Name        string   `json:"name,omitempty"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares,omitempty"`
+}
+
+func (e RouteEntry) key() string {
+	method := e.Method
+	if method == "" {
+		method = "ANY"
+	}
+	return routeKey(method, e.Path)
+}
+
+// RouteDiff is the change of the route table against a previous snapshot.
+// Handler names are not compared, they change with refactorings.
+type RouteDiff struct {
+	Added   []RouteEntry `json:"added,omitempty"`
+	Removed []RouteEntry `json:"removed,omitempty"`
+	// Changed holds the current entries of routes whose name or middlewares changed
+	Changed []RouteEntry `json:"changed,omitempty"`
+}
+
+func (d RouteDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func middlewareName(m Middleware) string {
+	if f, ok := m.(HandleFunc); ok {
+		return f.Name()
+	}
+	return strings.TrimPrefix(reflect.TypeOf(m).String(), "*")
+}
+
+// RouteTable returns the served routes sorted by path and method, routes removed
+// by RemoveRoute are left out.
+func (v *Prouter) RouteTable() []RouteEntry {
+	v.routeSlots.mu.RLock()
+	entries := make([]RouteEntry, 0, len(v.routeSlots.slots))
+	for _, slot := range v.routeSlots.slots {
+		state := slot.state.Load()
+		if state.removed {
+			continue
+		}
+
+		e := RouteEntry{
+			Method:  slot.info.method,
+			Path:    slot.info.template,
+			Name:    slot.info.name,
+			Handler: state.handlerName,
+		}
+		for _, m := range state.route.middleware {
+			e.Middlewares = append(e.Middlewares, middlewareName(m))
+		}
+		entries = append(entries, e)
+	}
+	v.routeSlots.mu.RUnlock()
+
+	slices.SortFunc(entries, func(a, b RouteEntry) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return entries
+}
+
+// DiffRoutes compares the route table with a previous snapshot of RouteTable,
+// e.g. to fail a CI smoke test when a route went missing:
+//
+//	if diff := router.DiffRoutes(previous); len(diff.Removed) > 0 {
+//		t.Fatalf("routes removed: %v", diff.Removed)
+//	}
+func (v *Prouter) DiffRoutes(previous []RouteEntry) RouteDiff {
+	current := v.RouteTable()
+	before := make(map[string]RouteEntry, len(previous))
+	for _, e := range previous {
+		before[e.key()] = e
+	}
+
+	var diff RouteDiff
+	for _, e := range current {
+		old, ok := before[e.key()]
+		delete(before, e.key())
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, e)
+		case old.Name != e.Name || !slices.Equal(old.Middlewares, e.Middlewares):
+			diff.Changed = append(diff.Changed, e)
+		}
+	}
+	for _, e := range previous {
+		if _, ok := before[e.key()]; ok {
+			diff.Removed = append(diff.Removed, e)
+		}
+	}
+	return diff
+}
+
+// WithRouteInfoMetric adds the route table to WritePrometheus as info series, a
+// prouter_route_info per route and the fingerprint of the whole table, so the
+// instances serving different routes after a deploy show in the dashboards.
+func WithRouteInfoMetric() RouterOption {
+	return func(v *Prouter) {
+		v.routeInfoMetric = true
+	}
+}
+
+// routeFingerprint hashes the methods and paths of the table, handler names are
+// left out as in DiffRoutes
+func routeFingerprint(entries []RouteEntry) string {
+	h := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\n", e.key())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func (v *Prouter) writeRouteInfo(b *strings.Builder) {
+	entries := v.RouteTable()
+
+	b.WriteString("# HELP prouter_route_info Registered routes, the value is always 1.\n# TYPE prouter_route_info gauge\n")
+	for _, e := range entries {
+		method := e.Method
+		if method == "" {
+			method = "ANY"
+		}
+		fmt.Fprintf(b, "prouter_route_info{method=\"%s\",route=\"%s\",handler=\"%s\"} 1\n",
+			method, promLabelReplacer.Replace(e.Path), promLabelReplacer.Replace(e.Handler))
+	}
+	fmt.Fprintf(b, "# HELP prouter_route_table_info Fingerprint of the route table, the value is always 1.\n# TYPE prouter_route_table_info gauge\nprouter_route_table_info{fingerprint=\"%s\"} 1\n",
+		routeFingerprint(entries))
+	fmt.Fprintf(b, "# HELP prouter_routes Registered routes.\n# TYPE prouter_routes gauge\nprouter_routes %d\n", len(entries))
+}
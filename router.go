@@ -2,9 +2,13 @@ package prouter
 
 import (
 	"errors"
+	"io"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-puzzles/puzzles/plog"
@@ -14,12 +18,27 @@ import (
 const (
 	DebugMode = iota
 	ReleaseMode
+	// TestMode prints nothing at registration and runs the hooks of OnTest
+	TestMode
 )
 
+// EnvProuterMode sets the initial mode to debug, release or test.
+const EnvProuterMode = "PROUTER_MODE"
+
 var (
 	prouterMode = DebugMode
 )
 
+func init() {
+	if name := os.Getenv(EnvProuterMode); name != "" {
+		mode, err := ParseMode(name)
+		if err != nil {
+			panic(err)
+		}
+		SetMode(mode)
+	}
+}
+
 type Router interface {
 	Routes() []Route
 }
@@ -30,16 +49,76 @@ func SetMode(value int64) {
 		prouterMode = DebugMode
 	case ReleaseMode:
 		prouterMode = ReleaseMode
+	case TestMode:
+		prouterMode = TestMode
 	default:
 		panic("Prouter mode unknown: " + strconv.FormatInt(value, 10) + " (available mode: debug release test)")
 	}
 }
 
+// Mode returns the current mode.
+func Mode() int64 {
+	return int64(prouterMode)
+}
+
+// ParseMode parses the mode names debug, release and test.
+func ParseMode(name string) (int64, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return DebugMode, nil
+	case "release":
+		return ReleaseMode, nil
+	case "test":
+		return TestMode, nil
+	default:
+		return 0, errors.New("prouter: mode unknown: " + name + " (available mode: debug release test)")
+	}
+}
+
 type Prouter struct {
 	RouterGroup
 	host   string
 	scheme string
+	// encodedPath indicates routes are matched against the escaped path
+	encodedPath bool
 	// middlewares []Middleware
+
+	localizer Localizer
+	messages  map[MessageKey]string
+
+	methodOverride *MethodOverride
+	binders        map[string]BinderFunc
+	validator      Validator
+
+	routeSlots      routeRegistry
+	examplesMounted bool
+	stats           routerStats
+	conns           connTracker
+	leakDetection   bool
+	surrogateHeader string
+	urlSigningKey   []byte
+	drainTimeout    time.Duration
+	trustedProxies  []*net.IPNet
+	cors            *CORSConfig
+	testHooks       []TestHook
+	banner          io.Writer
+	renderer        *renderer
+	payloadGuard    *payloadGuard
+	bypassKey       []byte
+	shutdown        shutdownState
+	background      backgroundTasks
+	routeCORS       bool
+	bodyBufferLimit int64
+	routeInfoMetric bool
+	debugBaggageKey string
+	certs           certMonitor
+	// optionsDiscovery answers OPTIONS with the capabilities of the path
+	optionsDiscovery bool
+	clock            Clock
+	normalizer       *Normalizer
+	warmup           warmupState
+	// swapped serves the requests instead of the router, see Swap
+	swapped atomic.Pointer[Prouter]
 }
 
 type RouterOption func(v *Prouter)
@@ -68,6 +147,15 @@ func WithMethodNotAllowedHandler(handler http.Handler) RouterOption {
 	}
 }
 
+// WithEncodedPath matches routes against the escaped path, so an encoded slash
+// (%2F) stays inside a single path variable.
+func WithEncodedPath() RouterOption {
+	return func(v *Prouter) {
+		v.encodedPath = true
+		v.router.UseEncodedPath()
+	}
+}
+
 func (v *Prouter) parseOptions(opts ...RouterOption) {
 	for _, opt := range opts {
 		opt(v)
@@ -77,7 +165,7 @@ func (v *Prouter) parseOptions(opts ...RouterOption) {
 	}
 
 	if v.scheme != "" {
-		v.router = v.router.Schemes(v.host).Subrouter()
+		v.router = v.router.Schemes(v.scheme).Subrouter()
 	}
 }
 
@@ -87,8 +175,10 @@ func New(opts ...RouterOption) *Prouter {
 	v := &Prouter{
 		RouterGroup: newGroupWithRouter(m),
 	}
+	v.warmup.concurrency = defaultWarmupConcurrency
 	v.RouterGroup.root = true
 	v.RouterGroup.prouter = v
+	v.RouterGroup.stats = v.stats.group("", nil)
 	v.parseOptions(opts...)
 
 	return v
@@ -101,27 +191,95 @@ func NewProuter(opts ...RouterOption) *Prouter {
 		NewRecoveryMiddleware(),
 	)
 
-	notFoundHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_ = WriteJSON(w, http.StatusNotFound, ErrorResponse(http.StatusNotFound, "page not found"))
-	})
-	v.router.NotFoundHandler = notFoundHandler
-	v.router.MethodNotAllowedHandler = notFoundHandler
+	if v.router.NotFoundHandler == nil {
+		v.router.NotFoundHandler = v.messageHandler(http.StatusNotFound, MsgPageNotFound)
+	}
+	if v.router.MethodNotAllowedHandler == nil {
+		v.router.MethodNotAllowedHandler = v.methodNotAllowedHandler()
+	}
 	return v
 }
+
+var allowMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+// allowedMethods returns the methods the routes of the path of r accept
+func (v *Prouter) allowedMethods(r *http.Request) []string {
+	var ret []string
+	for _, method := range allowMethods {
+		req := new(http.Request)
+		*req = *r
+		req.Method = method
+
+		var match mux.RouteMatch
+		if v.router.Match(req, &match) && match.MatchErr == nil {
+			ret = append(ret, method)
+		}
+	}
+	return ret
+}
+
+// methodNotAllowedHandler answers 405 with the Allow header of the path
+func (v *Prouter) methodNotAllowedHandler() http.Handler {
+	notAllowed := v.messageHandler(http.StatusMethodNotAllowed, MsgMethodNotAllowed)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(v.allowedMethods(r), ", "))
+		notAllowed.ServeHTTP(w, r)
+	})
+}
+
+func (v *Prouter) messageHandler(code int, key MessageKey) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg, _ := v.message(r, key)
+		_ = v.writeJSON(w, code, ErrorResponse(code, msg))
+	})
+}
+
 func (v *Prouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// a shutting down router answers itself
+	if next := v.swapped.Load(); next != nil && !v.shutdown.active.Load() {
+		next.ServeHTTP(w, r)
+		return
+	}
+	if v.normalizer != nil && v.normalize(w, r) {
+		return
+	}
+	if v.methodOverride != nil {
+		r = v.methodOverride.Override(r)
+	}
+	if v.shuttingDown(w, r) {
+		return
+	}
+	if cors := v.corsFor(r); cors != nil && cors.handle(w, r) {
+		return
+	}
+	if v.optionsDiscovery && r.Method == http.MethodOptions && v.serveCapabilities(w, r) {
+		return
+	}
 	v.router.ServeHTTP(w, r)
 }
 
-func (v *Prouter) ServeHandler() *mux.Router {
+// ServeHandler returns the router as http.Handler. It returned the *mux.Router
+// before, use MuxRouter for it, see the package doc.
+func (v *Prouter) ServeHandler() http.Handler {
+	return v
+}
+
+// MuxRouter is the escape hatch to the underlying mux router.
+func (v *Prouter) MuxRouter() *mux.Router {
 	return v.router
 }
 
 func (v *Prouter) Run(addr string) error {
-	srv := http.Server{
-		Addr:    addr,
-		Handler: v,
+	l, err := v.listen(addr)
+	if err != nil {
+		return err
 	}
-	return srv.ListenAndServe()
+	v.printBanner(l, false)
+	v.startBackground()
+	return v.trackServer(v.newServer(addr)).Serve(l)
 }
 
 func (v *Prouter) handlerName(handler handlerFunc) string {
@@ -131,9 +289,27 @@ func (v *Prouter) handlerName(handler handlerFunc) string {
 	return fs[len(fs)-1]
 }
 
-func (v *Prouter) makeHttpHandler(wr iRoute) http.HandlerFunc {
+// makeHttpHandler composes the middleware chain of the route at registration and
+// again when its group gets a middleware, requests only run the prebuilt chain.
+func (v *Prouter) makeHttpHandler(wr iRoute, info *routeInfo, params *routeParams) http.HandlerFunc {
 	handlerName := wr.Handler().Name()
-	handlerFunc := wr.handleSpecifyMiddleware(wr.Handler())
+	handler := wr.Handler()
+	if len(info.examples) > 0 {
+		handler = (&exampleHandler{examples: info.examples}).WrapHandler(handler)
+	}
+	if !params.empty() {
+		handler = params.WrapHandler(handler)
+	}
+	if info.preconditions != nil {
+		handler = info.preconditions.WrapHandler(handler)
+	}
+	if info.maxBodySize > 0 {
+		handler = maxBodyHandler(info.maxBodySize, handler)
+	}
+	handlerFunc := wr.handleSpecifyMiddleware(handler)
+	if v.leakDetection {
+		handlerFunc = &leakDetector{handler: handlerFunc}
+	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
@@ -147,26 +323,50 @@ func (v *Prouter) makeHttpHandler(wr iRoute) http.HandlerFunc {
 			Writer:    WrapResponseWriter(w),
 			Path:      path,
 			Method:    r.Method,
-			ClientIp:  clientIP(r),
-			startTime: time.Now(),
+			ClientIp:  v.clientIP(r),
+			startTime: v.now(),
 		}
 		r = r.Clone(ctx)
 		ctx.Request = r
 
-		vars := mux.Vars(ctx.Request)
-		if vars == nil {
-			vars = make(map[string]string)
-		}
-		ctx.vars = vars
 		ctx.router = v
+		ctx.route = info
+		// parsed before any middleware derives a context from ctx
+		ctx.WithValue(baggageKey{}, requestBaggage(r))
+		for _, cv := range wr.values {
+			ctx.WithValue(cv.key, cv.val)
+		}
+
+		ctx.countRequestBody()
+		if len(info.surrogateKeys) > 0 {
+			ctx.AddSurrogateKeys(info.surrogateKeys...)
+		}
+		ctx.Writer.OnFinish(func() {
+			info.traffic.record(ctx.RequestSize(), ctx.Writer.Size())
+		})
+		defer ctx.Writer.finish()
+		defer ctx.Writer.complete(routeKey(r.Method, info.template), handlerName)
+
+		resp, err := handlerFunc.Handle(ctx)
+		resp = applyResult(ctx, resp)
+		resp, err = v.checkResponse(ctx, resp, err)
+		if v.payloadGuard != nil {
+			resp, err = v.payloadGuard.check(ctx, resp, err)
+		}
+		if prouterMode == TestMode {
+			v.runTestHooks(ctx, resp, err)
+		}
 
-		code, resp := v.packResponseTmpl(handlerFunc.Handle(ctx))
+		code, tmpl := v.packResponseTmpl(resp, err)
 		if code == -1 {
 			return
 		}
+		if v.debugBaggageKey != "" {
+			applyTraceMeta(ctx, tmpl)
+		}
 
-		status := mapCodeToStatus(code)
-		_ = WriteJSON(w, status, resp)
+		status := statusOfCode(code)
+		_ = v.writeJSON(ctx.Writer, status, tmpl)
 	}
 }
 
@@ -197,6 +397,19 @@ func (v *Prouter) packResponseTmpl(resp Response, err error) (status int, ret Re
 	ret.SetMessage(msg)
 	ret.SetData(data)
 
+	if lr, ok := resp.(LinksResponse); ok && err == nil {
+		if tmpl, ok := ret.(LinksResponse); ok {
+			tmpl.SetLinks(lr.GetLinks())
+		}
+	}
+	if ve, ok := asValidationErrors(err); ok {
+		if tmpl, ok := ret.(ErrorsResponse); ok {
+			tmpl.SetErrors(ve)
+		} else {
+			ret.SetData(ve)
+		}
+	}
+
 	return code, ret
 }
 
@@ -207,10 +420,15 @@ func (v *Prouter) parseError(resp Response, err error) (code int, msg string) {
 
 	if err != nil {
 		rErr := new(prouterError)
-		if errors.As(err, &rErr) {
+		var ve ValidationErrors
+		switch {
+		case errors.As(err, &rErr):
 			msg = rErr.Message()
 			code = rErr.Code()
-		} else {
+		case errors.As(err, &ve):
+			msg = "validation failed"
+			code = http.StatusBadRequest
+		default:
 			msg = err.Error()
 		}
 
@@ -0,0 +1,72 @@
+package prouter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// Swap serves the requests of v with next from now on, e.g. a router built from
+// a reloaded config, requests in flight finish on the router which took them.
+// v stays the handler of its servers, so its listeners, connection limits and
+// Shutdown are kept, while the routes, middlewares and options of next apply.
+// The Background tasks and warmups of next start when v is serving, the
+// previous router keeps running its own until it is stopped with Stop.
+// Shutdown of v stops the router served at the time.
+//
+// Swap returns the router served before, swap it back to roll back or stop it:
+//
+//	prev := router.Swap(buildRouter(cfg))
+//	if !healthy() {
+//		router.Swap(prev)
+//	} else if prev != router {
+//		go prev.Stop(context.Background())
+//	}
+//
+// Swapping in nil or v serves v itself again.
+func (v *Prouter) Swap(next *Prouter) *Prouter {
+	if next == v {
+		next = nil
+	}
+	prev := v.swapped.Swap(next)
+	if prev == nil {
+		prev = v
+	}
+
+	v.background.mu.Lock()
+	serving := v.background.serving && !v.shutdown.active.Load()
+	v.background.mu.Unlock()
+	if serving {
+		v.Snapshot().startBackground()
+	}
+
+	if next != nil {
+		plog.Infof("router swapped, serving %d route(s)", len(next.RouteTable()))
+	} else {
+		plog.Infof("router swapped back, serving %d route(s)", len(v.RouteTable()))
+	}
+	return prev
+}
+
+// Snapshot returns the router serving the requests of v, v itself until Swap.
+func (v *Prouter) Snapshot() *Prouter {
+	if next := v.swapped.Load(); next != nil {
+		return next
+	}
+	return v
+}
+
+// Stop waits for the requests in flight on v and stops its Background tasks
+// until ctx expires, e.g. for a router swapped out by Swap. Unlike Shutdown it
+// leaves the servers alone, swapping v in again restarts its tasks.
+func (v *Prouter) Stop(ctx context.Context) error {
+	abandoned := v.drainRequests(ctx)
+	if err := v.stopBackground(ctx); err != nil {
+		return err
+	}
+	if abandoned > 0 {
+		return fmt.Errorf("prouter: %d request(s) still in flight: %w", abandoned, ctx.Err())
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSwapStopsSwappedRouters(t *testing.T) {
+	background := func(r *Prouter) chan struct{} {
+		stopped := make(chan struct{})
+		r.Background("task", func(ctx context.Context) {
+			<-ctx.Done()
+			close(stopped)
+		})
+		return stopped
+	}
+	stopped := func(ch chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	router := New()
+	router.startBackground()
+
+	first := New()
+	firstStopped := background(first)
+	if prev := router.Swap(first); prev != router {
+		t.Fatalf("Swap returned %p, want the router itself", prev)
+	}
+
+	second := New()
+	secondStopped := background(second)
+	prev := router.Swap(second)
+	if prev != first {
+		t.Fatalf("Swap returned %p, want the first router", prev)
+	}
+	if err := prev.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped(firstStopped) {
+		t.Error("Stop did not stop the tasks of the swapped out router")
+	}
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+	second.GET("/slow", func(*Context) (Response, error) {
+		close(running)
+		<-release
+		return nil, nil
+	})
+	go router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	<-running
+
+	var report ShutdownReport
+	router.shutdown.report = func(r ShutdownReport) { report = r }
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	if err := router.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if report.InFlight != 1 || report.Drained != 1 {
+		t.Errorf("report = %+v, want the request of the swapped in router drained", report)
+	}
+	if !stopped(secondStopped) {
+		t.Error("Shutdown did not stop the tasks of the swapped in router")
+	}
+}
@@ -0,0 +1,59 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	router := NewProuter()
+	noop := func(*Context) (Response, error) { return nil, nil }
+	router.GET("/users", noop)
+	router.POST("/users", noop)
+	router.DELETE("/users/{id}", noop)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		allow  string
+	}{
+		{http.MethodPut, "/users", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodGet, "/users/1", http.StatusMethodNotAllowed, "DELETE"},
+		{http.MethodGet, "/users", http.StatusOK, ""},
+		{http.MethodGet, "/missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: code = %d, want %d", tt.method, tt.path, rec.Code, tt.code)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", tt.method, tt.path, got, tt.allow)
+		}
+	}
+}
+
+func TestServeHandlerServesTheRouter(t *testing.T) {
+	router := New(WithCORS(CORSConfig{AllowOrigins: []string{"*"}}))
+	router.GET("/", func(*Context) (Response, error) { return nil, nil })
+
+	// a preflight is answered by the router, the mux router alone has no OPTIONS route
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	router.ServeHandler().ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("preflight through ServeHandler: Allow-Origin = %q, want *", got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.MuxRouter().ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("preflight through MuxRouter: Allow-Origin = %q, want none", got)
+	}
+}
@@ -0,0 +1,203 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+const defaultVersionHeader = "X-API-Version"
+
+// SchemaTransform rewrites a decoded JSON value, maps are map[string]any and arrays []any.
+type SchemaTransform func(v any) (any, error)
+
+// VersionChange is a schema change introduced in Version. Request rewrites a
+// request of the previous version to Version, Response rewrites a response of
+// Version back to the previous one.
+type VersionChange struct {
+	Version string
+	// Route limits the change to "METHOD /template", e.g. "GET /users/{id}", empty applies to all routes
+	Route    string
+	Request  SchemaTransform
+	Response SchemaTransform
+}
+
+// SchemaMigrator lets handlers implement the latest schema only, requests of
+// clients pinned to an older version are migrated up through every change since
+// it and the responses migrated back down.
+type SchemaMigrator struct {
+	versions    []string
+	header      string
+	versionFunc func(ctx *Context) string
+	changes     map[string][]VersionChange
+}
+
+type MigratorOption func(*SchemaMigrator)
+
+// WithVersionHeader sets the request header carrying the pinned version, X-API-Version by default.
+func WithVersionHeader(header string) MigratorOption {
+	return func(m *SchemaMigrator) {
+		m.header = header
+	}
+}
+
+// WithVersionFunc resolves the pinned version of the request, e.g. from the account
+// settings, an empty version falls back to the header.
+func WithVersionFunc(fn func(ctx *Context) string) MigratorOption {
+	return func(m *SchemaMigrator) {
+		m.versionFunc = fn
+	}
+}
+
+// NewSchemaMigrator creates the migrator for versions ordered from oldest to latest.
+func NewSchemaMigrator(versions []string, opts ...MigratorOption) *SchemaMigrator {
+	m := &SchemaMigrator{
+		versions: versions,
+		header:   defaultVersionHeader,
+		changes:  make(map[string][]VersionChange),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Register adds changes, it panics on a version not given to NewSchemaMigrator.
+func (m *SchemaMigrator) Register(changes ...VersionChange) *SchemaMigrator {
+	for _, c := range changes {
+		if !slices.Contains(m.versions, c.Version) {
+			panic("prouter: unknown api version " + c.Version)
+		}
+		m.changes[c.Version] = append(m.changes[c.Version], c)
+	}
+	return m
+}
+
+func (m *SchemaMigrator) version(ctx *Context) string {
+	if m.versionFunc != nil {
+		if v := m.versionFunc(ctx); v != "" {
+			return v
+		}
+	}
+	return ctx.Request.Header.Get(m.header)
+}
+
+// pending returns the changes of the route after version, oldest first
+func (m *SchemaMigrator) pending(idx int, route string) []VersionChange {
+	var ret []VersionChange
+	for _, v := range m.versions[idx+1:] {
+		for _, c := range m.changes[v] {
+			if c.Route == "" || c.Route == route {
+				ret = append(ret, c)
+			}
+		}
+	}
+	return ret
+}
+
+func applyTransform(v any, fn SchemaTransform) (any, error) {
+	if fn == nil {
+		return v, nil
+	}
+	return fn(v)
+}
+
+// migrateRequest rewrites the JSON body by the request transforms, the body is
+// read by ctx.BodyBytes within its limit
+func (m *SchemaMigrator) migrateRequest(ctx *Context, changes []VersionChange, latest string) error {
+	r := ctx.Request
+	if r.Body == nil || r.Body == http.NoBody || contentType(r) != "application/json" {
+		return nil
+	}
+
+	body, err := ctx.BodyBytes()
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	migrateError := func(err error) error {
+		return NewErr(http.StatusBadRequest, err, "migrate request to api version "+latest+" failed").
+			SetComponent(ErrProuter).
+			SetResponseType(BadRequest)
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return migrateError(err)
+	}
+	for _, c := range changes {
+		if v, err = applyTransform(v, c.Request); err != nil {
+			return migrateError(err)
+		}
+	}
+
+	if body, err = json.Marshal(v); err != nil {
+		return migrateError(err)
+	}
+	ctx.keepBody(body)
+	r.ContentLength = int64(len(body))
+	return nil
+}
+
+func (m *SchemaMigrator) migrateResponse(resp Response, changes []VersionChange) error {
+	if resp == nil || resp.GetData() == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(resp.GetData())
+	if err != nil {
+		return err
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+
+	for _, c := range slices.Backward(changes) {
+		if v, err = applyTransform(v, c.Response); err != nil {
+			return err
+		}
+	}
+	resp.SetData(v)
+	return nil
+}
+
+func (m *SchemaMigrator) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		latest := m.versions[len(m.versions)-1]
+		version := m.version(ctx)
+		if version == "" {
+			version = latest
+		}
+
+		idx := slices.Index(m.versions, version)
+		if idx < 0 {
+			return nil, MsgError(http.StatusBadRequest, "unsupported api version: "+version).
+				SetComponent(ErrProuter).
+				SetResponseType(BadRequest)
+		}
+		ctx.Writer.Header().Set(m.header, version)
+
+		changes := m.pending(idx, routeKey(ctx.Request.Method, ctx.RouteTemplate()))
+		if len(changes) == 0 {
+			return handler.Handle(ctx)
+		}
+
+		if err := m.migrateRequest(ctx, changes, latest); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler.Handle(ctx)
+		if mErr := m.migrateResponse(resp, changes); mErr != nil {
+			return nil, NewErr(http.StatusInternalServerError, mErr, "migrate response to api version "+version+" failed").
+				SetComponent(ErrProuter).
+				SetResponseType(InternalServerError)
+		}
+		return resp, err
+	})
+}
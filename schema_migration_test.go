@@ -0,0 +1,58 @@
+package prouter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSchemaMigratorRequestBody(t *testing.T) {
+	migrator := NewSchemaMigrator([]string{"1", "2"}).Register(VersionChange{
+		Version: "2",
+		Request: func(v any) (any, error) {
+			m := v.(map[string]any)
+			m["full_name"] = m["name"]
+			delete(m, "name")
+			return m, nil
+		},
+	})
+
+	router := New(WithBodyBufferLimit(64))
+	router.UseMiddleware(migrator)
+	router.POST("/users", func(ctx *Context) (Response, error) {
+		body, err := ctx.BodyBytes()
+		if err != nil {
+			return nil, err
+		}
+		return SuccessResponse(string(body)), nil
+	})
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		want string
+	}{
+		{"migrated", `{"name":"jane"}`, http.StatusOK, `full_name`},
+		{"over the body limit", `{"name":"` + strings.Repeat("a", 128) + `"}`, http.StatusRequestEntityTooLarge, ""},
+		{"invalid json", `{"name":`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(defaultVersionHeader, "1")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("code = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.want)
+			}
+		})
+	}
+}
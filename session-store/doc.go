@@ -0,0 +1,19 @@
+// Package sessionstore keeps gorilla/sessions in Redis.
+//
+// # Migrating
+//
+// NewRedisStoreWithAddr and NewRedisStoreWithClient return (*RedisStore, error),
+// the error reports an invalid option such as a bad encryption key:
+//
+//	store, err := sessionstore.NewRedisStoreWithClient(client, "sess")
+//	if err != nil {
+//		return err
+//	}
+//
+// NewRedisStoreWithClient takes a redis.UniversalClient, a
+// *goredis.PuzzleRedisClient still satisfies it.
+//
+// Enabling WithEncryptionKeys on a store holding plaintext sessions rejects them
+// and logs their users out, add WithPlaintextFallback to encrypt them on their
+// next save instead.
+package sessionstore
@@ -0,0 +1,129 @@
+package sessionstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+)
+
+const (
+	encryptedVersion = 1
+	keyIDSize        = 4
+)
+
+var ErrUnknownEncryptionKey = errors.New("sessionstore: payload encrypted with unknown key")
+
+type encryptionKey struct {
+	id   [keyIDSize]byte
+	aead cipher.AEAD
+}
+
+// EncryptedSerializer seals the payload of the wrapped serializer with AES-GCM.
+// The first key encrypts, all keys decrypt, so a key is rotated by prepending
+// the new one and dropping the old one once its sessions expired.
+type EncryptedSerializer struct {
+	serializer SessionSerializer
+	keys       []encryptionKey
+	plaintext  bool
+}
+
+// NewEncryptedSerializer creates the serializer, the keys must be 16, 24 or 32 bytes long.
+func NewEncryptedSerializer(serializer SessionSerializer, keys ...[]byte) (*EncryptedSerializer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("sessionstore: no encryption key given")
+	}
+
+	es := &EncryptedSerializer{serializer: serializer}
+	for i, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("encryption key %d", i))
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("encryption key %d", i))
+		}
+
+		ek := encryptionKey{aead: aead}
+		sum := sha256.Sum256(key)
+		copy(ek.id[:], sum[:keyIDSize])
+		es.keys = append(es.keys, ek)
+	}
+
+	return es, nil
+}
+
+// WithPlaintextFallback reads the payloads written before encryption was
+// enabled with the wrapped serializer, instead of rejecting them, so enabling
+// encryption does not log out every user. They are encrypted on their next
+// save, drop the fallback once the plaintext sessions expired.
+func (es *EncryptedSerializer) WithPlaintextFallback() *EncryptedSerializer {
+	es.plaintext = true
+	return es
+}
+
+// Serialize produces version | key id | nonce | ciphertext
+func (es *EncryptedSerializer) Serialize(s *sessions.Session) ([]byte, error) {
+	plain, err := es.serializer.Serialize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	key := es.keys[0]
+	nonceSize := key.aead.NonceSize()
+	out := make([]byte, 1+keyIDSize+nonceSize, 1+keyIDSize+nonceSize+len(plain)+key.aead.Overhead())
+	out[0] = encryptedVersion
+	copy(out[1:], key.id[:])
+
+	nonce := out[1+keyIDSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+
+	return key.aead.Seal(out, nonce, plain, additionalData(s)), nil
+}
+
+// additionalData binds the payload to the session id, and the cookie name, so
+// a payload copied to the key of another session fails to decrypt. The store
+// sets the id before it saves or loads a session.
+func additionalData(s *sessions.Session) []byte {
+	return []byte(s.Name() + "\x00" + s.ID)
+}
+
+func (es *EncryptedSerializer) Deserialize(d []byte, s *sessions.Session) error {
+	if len(d) < 1+keyIDSize || d[0] != encryptedVersion {
+		if es.plaintext {
+			return es.serializer.Deserialize(d, s)
+		}
+		return errors.New("sessionstore: payload is not encrypted")
+	}
+
+	for _, key := range es.keys {
+		if string(key.id[:]) != string(d[1:1+keyIDSize]) {
+			continue
+		}
+
+		nonceSize := key.aead.NonceSize()
+		if len(d) < 1+keyIDSize+nonceSize {
+			return errors.New("sessionstore: encrypted payload too short")
+		}
+		nonce := d[1+keyIDSize : 1+keyIDSize+nonceSize]
+		plain, err := key.aead.Open(nil, nonce, d[1+keyIDSize+nonceSize:], additionalData(s))
+		if err != nil {
+			return errors.Wrap(err, "decrypt")
+		}
+		return es.serializer.Deserialize(plain, s)
+	}
+
+	// a plaintext payload may start with the version byte by chance
+	if es.plaintext && es.serializer.Deserialize(d, s) == nil {
+		return nil
+	}
+	return ErrUnknownEncryptionKey
+}
@@ -0,0 +1,101 @@
+package sessionstore
+
+import (
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+func newTestSession(name, id string, values map[any]any) *sessions.Session {
+	s := sessions.NewSession(nil, name)
+	s.ID = id
+	for k, v := range values {
+		s.Values[k] = v
+	}
+	return s
+}
+
+func TestEncryptedSerializerBindsTheSession(t *testing.T) {
+	es, err := NewEncryptedSerializer(GobSerializer{}, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := es.Serialize(newTestSession("sid", "alice", map[any]any{"user": "alice"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cookie  string
+		id      string
+		wantErr bool
+	}{
+		{"same session", "sid", "alice", false},
+		{"swapped to another session id", "sid", "mallory", true},
+		{"swapped to another cookie", "other", "alice", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession(tt.cookie, tt.id, nil)
+			err := es.Deserialize(sealed, s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Deserialize into %s/%s = %v, want an error", tt.cookie, tt.id, s.Values)
+				}
+				return
+			}
+			if err != nil || s.Values["user"] != "alice" {
+				t.Fatalf("Deserialize = %v, %v", s.Values, err)
+			}
+		})
+	}
+}
+
+func TestEncryptedSerializerPlaintextFallback(t *testing.T) {
+	plain, err := GobSerializer{}.Serialize(newTestSession("sid", "alice", map[any]any{"user": "alice"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		fallback bool
+		wantErr  bool
+	}{
+		{"rejected", false, true},
+		{"fallback", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, err := NewEncryptedSerializer(GobSerializer{}, make([]byte, 32))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.fallback {
+				es.WithPlaintextFallback()
+			}
+
+			s := newTestSession("sid", "alice", nil)
+			err = es.Deserialize(plain, s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Deserialize of a plaintext payload = %v, want an error", s.Values)
+				}
+				return
+			}
+			if err != nil || s.Values["user"] != "alice" {
+				t.Fatalf("Deserialize = %v, %v", s.Values, err)
+			}
+
+			// sealed payloads are still bound to their session with the fallback
+			sealed, err := es.Serialize(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := es.Deserialize(sealed, newTestSession("sid", "mallory", nil)); err == nil {
+				t.Error("swapped payload accepted with the plaintext fallback")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package sessionstore
+
+import (
+	"context"
+	"expvar"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const (
+	defaultGCInterval  = 10 * time.Minute
+	defaultGCBatchSize = 500
+	defaultGCJitter    = 0.1
+)
+
+// ExpiredPurger is implemented by stores without native expiry, e.g. a SQL or
+// Bolt store, to delete their expired sessions.
+type ExpiredPurger interface {
+	// PurgeExpired deletes up to limit expired sessions and returns how many it deleted.
+	PurgeExpired(ctx context.Context, limit int) (int, error)
+}
+
+// GCStats are the counters of a GC.
+type GCStats struct {
+	Runs       uint64    `json:"runs"`
+	Purged     uint64    `json:"purged"`
+	Errors     uint64    `json:"errors"`
+	LastRun    time.Time `json:"last_run"`
+	LastPurged int       `json:"last_purged"`
+}
+
+// GC purges the expired sessions of a store periodically, in batches so a run
+// does not hold the store for long. Run it with the router lifecycle:
+//
+//	router.Background("session-gc", sessionstore.NewGC(store).Run)
+type GC struct {
+	purger    ExpiredPurger
+	interval  time.Duration
+	batchSize int
+	jitter    float64
+
+	mu    sync.Mutex
+	stats GCStats
+}
+
+type GCOption func(*GC)
+
+// WithGCInterval sets the time between runs, 10 minutes by default.
+func WithGCInterval(d time.Duration) GCOption {
+	return func(g *GC) {
+		g.interval = d
+	}
+}
+
+// WithGCBatchSize sets the sessions deleted per PurgeExpired call, 500 by default.
+func WithGCBatchSize(n int) GCOption {
+	return func(g *GC) {
+		g.batchSize = n
+	}
+}
+
+// WithGCJitter spreads the runs by a fraction of the interval, 0.1 by default,
+// so replicas sharing a store do not purge at the same time.
+func WithGCJitter(fraction float64) GCOption {
+	return func(g *GC) {
+		g.jitter = fraction
+	}
+}
+
+func NewGC(purger ExpiredPurger, opts ...GCOption) *GC {
+	g := &GC{
+		purger:    purger,
+		interval:  defaultGCInterval,
+		batchSize: defaultGCBatchSize,
+		jitter:    defaultGCJitter,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+func (g *GC) wait() time.Duration {
+	if g.jitter <= 0 {
+		return g.interval
+	}
+	spread := float64(g.interval) * g.jitter
+	return g.interval + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// Run purges every interval until ctx is cancelled.
+func (g *GC) Run(ctx context.Context) {
+	timer := time.NewTimer(g.wait())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if _, err := g.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			plog.Errorc(ctx, "session gc error: %v", err)
+		}
+		timer.Reset(g.wait())
+	}
+}
+
+// RunOnce purges batches until a batch is not full or ctx is cancelled and
+// returns the number of purged sessions.
+func (g *GC) RunOnce(ctx context.Context) (int, error) {
+	var (
+		total int
+		err   error
+	)
+	for ctx.Err() == nil {
+		var n int
+		n, err = g.purger.PurgeExpired(ctx, g.batchSize)
+		total += n
+		if err != nil || n < g.batchSize {
+			break
+		}
+	}
+
+	g.mu.Lock()
+	g.stats.Runs++
+	g.stats.Purged += uint64(total)
+	g.stats.LastRun = time.Now()
+	g.stats.LastPurged = total
+	if err != nil {
+		g.stats.Errors++
+	}
+	g.mu.Unlock()
+
+	if total > 0 {
+		plog.Debugc(ctx, "session gc purged %d expired sessions", total)
+	}
+	return total, err
+}
+
+func (g *GC) Stats() GCStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
+
+// PublishExpvar publishes Stats under name in expvar, it panics if name is already published.
+func (g *GC) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return g.Stats()
+	}))
+}
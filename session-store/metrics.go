@@ -0,0 +1,122 @@
+package sessionstore
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// StoreOp is the store operation of a StoreEvent.
+type StoreOp string
+
+const (
+	OpGet    StoreOp = "get"
+	OpSave   StoreOp = "save"
+	OpDelete StoreOp = "delete"
+)
+
+// StoreEvent describes one round trip to the backend of a store. Hit is only
+// meaningful for OpGet, a get without error and without Hit is a miss.
+type StoreEvent struct {
+	Op       StoreOp
+	Duration time.Duration
+	Hit      bool
+	Err      error
+}
+
+// MetricsHook receives every StoreEvent, it runs on the request goroutine and
+// should not block.
+type MetricsHook func(ev StoreEvent)
+
+// WithMetrics reports the get, save and delete round trips of the store to
+// hook, e.g. StoreMetrics.Hook or an adapter of a metrics library.
+func WithMetrics(hook MetricsHook) RedisStoreOption {
+	return func(s *RedisStore) {
+		s.metrics = hook
+	}
+}
+
+// observe times fn and reports it to the hook of the store
+func (s *RedisStore) observe(op StoreOp, fn func() (bool, error)) error {
+	if s.metrics == nil {
+		_, err := fn()
+		return err
+	}
+
+	start := time.Now()
+	hit, err := fn()
+	s.metrics(StoreEvent{Op: op, Duration: time.Since(start), Hit: hit, Err: err})
+	return err
+}
+
+// OpStats are the counters of one store operation.
+type OpStats struct {
+	Count         uint64        `json:"count"`
+	Errors        uint64        `json:"errors"`
+	Hits          uint64        `json:"hits,omitempty"`
+	Misses        uint64        `json:"misses,omitempty"`
+	TotalDuration time.Duration `json:"total_duration"`
+	MaxDuration   time.Duration `json:"max_duration"`
+}
+
+// Mean is the average duration of the operation.
+func (o OpStats) Mean() time.Duration {
+	if o.Count == 0 {
+		return 0
+	}
+	return o.TotalDuration / time.Duration(o.Count)
+}
+
+// StoreMetrics aggregates StoreEvents in memory for stats pages and expvar:
+//
+//	metrics := sessionstore.NewStoreMetrics()
+//	store, err := sessionstore.NewRedisStoreWithAddr(addr, 0, "sess", sessionstore.WithMetrics(metrics.Hook))
+//	metrics.PublishExpvar("session_store")
+type StoreMetrics struct {
+	mu    sync.Mutex
+	stats map[StoreOp]OpStats
+}
+
+func NewStoreMetrics() *StoreMetrics {
+	return &StoreMetrics{stats: make(map[StoreOp]OpStats)}
+}
+
+// Hook is the MetricsHook of WithMetrics.
+func (m *StoreMetrics) Hook(ev StoreEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.stats[ev.Op]
+	st.Count++
+	st.TotalDuration += ev.Duration
+	st.MaxDuration = max(st.MaxDuration, ev.Duration)
+	switch {
+	case ev.Err != nil:
+		st.Errors++
+	case ev.Op != OpGet:
+	case ev.Hit:
+		st.Hits++
+	default:
+		st.Misses++
+	}
+	m.stats[ev.Op] = st
+}
+
+// Stats returns a copy of the counters per operation.
+func (m *StoreMetrics) Stats() map[StoreOp]OpStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[StoreOp]OpStats, len(m.stats))
+	for op, st := range m.stats {
+		stats[op] = st
+	}
+	return stats
+}
+
+// PublishExpvar publishes Stats under name in expvar, it panics if name is already published.
+func (m *StoreMetrics) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return m.Stats()
+	}))
+}
@@ -50,26 +50,68 @@ type RedisStore struct {
 	serializer SessionSerializer
 	Options    *sessions.Options
 
-	client *goredis.PuzzleRedisClient
-	prefix string
+	client  redis.UniversalClient
+	prefix  string
+	metrics MetricsHook
+	timeout time.Duration
+	// err is the first error of an option, returned by the constructor
+	err error
 }
 
-func NewRedisStoreWithAddr(addr string, db int, prefix string) *RedisStore {
-	return &RedisStore{
-		client:     goredis.NewRedisClient(addr, db),
-		serializer: &GobSerializer{},
-		prefix:     prefix,
-		Options: &sessions.Options{
-			Path:     "/",
-			MaxAge:   86400 * 30,
-			SameSite: http.SameSiteNoneMode,
-			Secure:   true,
-		},
+type RedisStoreOption func(*RedisStore)
+
+// WithSerializer replaces the default gob serializer.
+func WithSerializer(serializer SessionSerializer) RedisStoreOption {
+	return func(s *RedisStore) {
+		s.serializer = serializer
 	}
 }
 
-func NewRedisStoreWithClient(client *goredis.PuzzleRedisClient, prefix string) *RedisStore {
-	return &RedisStore{
+// WithEncryptionKeys encrypts the serialized sessions with AES-GCM before they
+// are written, see EncryptedSerializer for the key rotation. An invalid key
+// fails the constructor. Apply it after WithSerializer to encrypt a custom
+// serializer. The plaintext sessions of a store encryption is enabled on are
+// rejected, which logs their users out, unless WithPlaintextFallback follows.
+func WithEncryptionKeys(keys ...[]byte) RedisStoreOption {
+	return func(s *RedisStore) {
+		es, err := NewEncryptedSerializer(s.serializer, keys...)
+		if err != nil {
+			if s.err == nil {
+				s.err = err
+			}
+			return
+		}
+		s.serializer = es
+	}
+}
+
+// WithPlaintextFallback keeps reading the plaintext sessions written before
+// WithEncryptionKeys was enabled, see EncryptedSerializer.WithPlaintextFallback.
+// It must follow WithEncryptionKeys.
+func WithPlaintextFallback() RedisStoreOption {
+	return func(s *RedisStore) {
+		es, ok := s.serializer.(*EncryptedSerializer)
+		if !ok {
+			if s.err == nil {
+				s.err = errors.New("sessionstore: WithPlaintextFallback needs WithEncryptionKeys before it")
+			}
+			return
+		}
+		es.WithPlaintextFallback()
+	}
+}
+
+// WithOperationTimeout bounds every Redis call of the store. Loads are cancelled
+// with the request as well, saves and deletes are not, so a client hanging up
+// cannot keep a destroyed session alive. No timeout by default.
+func WithOperationTimeout(d time.Duration) RedisStoreOption {
+	return func(s *RedisStore) {
+		s.timeout = d
+	}
+}
+
+func newRedisStore(client redis.UniversalClient, prefix string, opts ...RedisStoreOption) (*RedisStore, error) {
+	s := &RedisStore{
 		client:     client,
 		serializer: &GobSerializer{},
 		prefix:     prefix,
@@ -80,6 +122,28 @@ func NewRedisStoreWithClient(client *goredis.PuzzleRedisClient, prefix string) *
 			Secure:   true,
 		},
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s, nil
+}
+
+// NewRedisStoreWithAddr dials a single node at addr, an invalid option is returned as error.
+func NewRedisStoreWithAddr(addr string, db int, prefix string, opts ...RedisStoreOption) (*RedisStore, error) {
+	return newRedisStore(goredis.NewRedisClient(addr, db), prefix, opts...)
+}
+
+// NewRedisStoreWithClient stores the sessions with client, a single node, a
+// sentinel failover or a cluster client from redis.NewUniversalClient. Every
+// session is a single key, so sessions spread over the slots of a cluster.
+// An invalid option is returned as error.
+func NewRedisStoreWithClient(client redis.UniversalClient, prefix string, opts ...RedisStoreOption) (*RedisStore, error) {
+	return newRedisStore(client, prefix, opts...)
 }
 
 func (s *RedisStore) Key(k string) string {
@@ -104,20 +168,26 @@ func (s *RedisStore) New(r *http.Request, name string) (*sessions.Session, error
 	}
 	session.ID = c.Value
 
-	err = s.load(session)
-	if err == nil {
-		session.IsNew = false
-	} else if errors.Is(err, redis.Nil) {
-		err = nil // no data stored
-	}
+	err = s.observe(OpGet, func() (bool, error) {
+		err := s.load(r.Context(), session)
+		if errors.Is(err, redis.Nil) {
+			return false, nil // no data stored
+		}
+		if err == nil {
+			session.IsNew = false
+		}
+		return err == nil, err
+	})
 	return session, err
 }
 
-// Save session to redis
+// Save session to redis, the write is not cancelled by the request
 func (s *RedisStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	ctx := context.WithoutCancel(r.Context())
+
 	// Delete if max-age is <= 0
 	if session.Options.MaxAge <= 0 {
-		if err := s.delete(session); err != nil {
+		if err := s.observe(OpDelete, func() (bool, error) { return false, s.delete(ctx, session) }); err != nil {
 			return err
 		}
 		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
@@ -131,7 +201,7 @@ func (s *RedisStore) Save(r *http.Request, w http.ResponseWriter, session *sessi
 		}
 		session.ID = id
 	}
-	if err := s.save(session); err != nil {
+	if err := s.observe(OpSave, func() (bool, error) { return false, s.save(ctx, session) }); err != nil {
 		return err
 	}
 
@@ -140,19 +210,21 @@ func (s *RedisStore) Save(r *http.Request, w http.ResponseWriter, session *sessi
 }
 
 // save writes session in Redis
-func (s *RedisStore) save(session *sessions.Session) error {
+func (s *RedisStore) save(ctx context.Context, session *sessions.Session) error {
 	b, err := s.serializer.Serialize(session)
 	if err != nil {
 		return err
 	}
 
-	return s.client.SetValue(context.Background(), s.Key(session.ID), b, time.Duration(session.Options.MaxAge)*time.Second)
+	ctx, cancel := s.opContext(ctx)
+	defer cancel()
+	return s.client.Set(ctx, s.Key(session.ID), b, time.Duration(session.Options.MaxAge)*time.Second).Err()
 }
 
-func (s *RedisStore) load(session *sessions.Session) error {
-	var data []byte
-
-	err := s.client.GetValue(context.Background(), s.Key(session.ID), &data)
+func (s *RedisStore) load(ctx context.Context, session *sessions.Session) error {
+	ctx, cancel := s.opContext(ctx)
+	defer cancel()
+	data, err := s.client.Get(ctx, s.Key(session.ID)).Bytes()
 	if err != nil {
 		return errors.Wrap(err, "getRedis")
 	}
@@ -164,8 +236,18 @@ func (s *RedisStore) load(session *sessions.Session) error {
 }
 
 // delete deletes session in Redis
-func (s *RedisStore) delete(session *sessions.Session) error {
-	return s.client.DeleteValue(context.Background(), s.Key(session.ID))
+func (s *RedisStore) delete(ctx context.Context, session *sessions.Session) error {
+	ctx, cancel := s.opContext(ctx)
+	defer cancel()
+	return s.client.Del(ctx, s.Key(session.ID)).Err()
+}
+
+// opContext bounds a Redis call by ctx and the operation timeout
+func (s *RedisStore) opContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
 }
 
 // generateRandomKey returns a new random key
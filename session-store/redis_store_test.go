@@ -0,0 +1,125 @@
+package sessionstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/redis/go-redis/v9"
+)
+
+// ctxClient records the context of the calls of the store
+type ctxClient struct {
+	redis.UniversalClient
+	ctxs map[string]context.Context
+}
+
+func (c *ctxClient) Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd {
+	c.ctxs["set"] = ctx
+	cmd := redis.NewStatusCmd(ctx)
+	cmd.SetErr(ctx.Err())
+	return cmd
+}
+
+func (c *ctxClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	c.ctxs["del"] = ctx
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetErr(ctx.Err())
+	return cmd
+}
+
+func TestSaveOutlivesTheRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge int
+		op     string
+	}{
+		{"save", 60, "set"},
+		{"logout", -1, "del"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &ctxClient{ctxs: map[string]context.Context{}}
+			store, err := NewRedisStoreWithClient(client, "sess", WithOperationTimeout(time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r := httptest.NewRequest(http.MethodPost, "/logout", nil).WithContext(ctx)
+
+			session := sessions.NewSession(store, "sid")
+			session.ID = "id"
+			session.Options = &sessions.Options{MaxAge: tt.maxAge}
+			if err := store.Save(r, httptest.NewRecorder(), session); err != nil {
+				t.Fatalf("Save of a disconnected client: %v", err)
+			}
+
+			opCtx := client.ctxs[tt.op]
+			if opCtx == nil {
+				t.Fatalf("no %s call", tt.op)
+			}
+			if _, ok := opCtx.Deadline(); !ok {
+				t.Errorf("%s is not bounded by the operation timeout", tt.op)
+			}
+		})
+	}
+}
+
+func TestWithEncryptionKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    [][]byte
+		wantErr bool
+	}{
+		{"aes-128", [][]byte{make([]byte, 16)}, false},
+		{"aes-256 rotated", [][]byte{make([]byte, 32), make([]byte, 16)}, false},
+		{"no key", nil, true},
+		{"short key", [][]byte{make([]byte, 10)}, true},
+		{"bad rotated key", [][]byte{make([]byte, 32), make([]byte, 33)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewRedisStoreWithClient(&ctxClient{}, "sess", WithEncryptionKeys(tt.keys...))
+			if tt.wantErr {
+				if err == nil || store != nil {
+					t.Fatalf("NewRedisStoreWithClient = %v, %v, want an error", store, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRedisStoreWithClient: %v", err)
+			}
+			if _, ok := store.serializer.(*EncryptedSerializer); !ok {
+				t.Errorf("serializer = %T, want *EncryptedSerializer", store.serializer)
+			}
+		})
+	}
+}
+
+func TestWithPlaintextFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []RedisStoreOption
+		wantErr bool
+	}{
+		{"after encryption", []RedisStoreOption{WithEncryptionKeys(make([]byte, 16)), WithPlaintextFallback()}, false},
+		{"without encryption", []RedisStoreOption{WithPlaintextFallback()}, true},
+		{"before encryption", []RedisStoreOption{WithPlaintextFallback(), WithEncryptionKeys(make([]byte, 16))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewRedisStoreWithClient(&ctxClient{}, "sess", tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRedisStoreWithClient error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !store.serializer.(*EncryptedSerializer).plaintext {
+				t.Error("plaintext fallback not enabled")
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-puzzles/puzzles/plog"
 	"github.com/gorilla/sessions"
@@ -34,6 +35,8 @@ type Session struct {
 
 	r *http.Request
 	w http.ResponseWriter
+	// modified is set by Set, Delete and Destroy
+	modified bool
 }
 
 func (s *Session) Save() error {
@@ -68,6 +71,7 @@ func (s *Session) Set(key string, value interface{}) error {
 	}
 
 	s.session.Values[key] = value
+	s.modified = true
 	return nil
 }
 
@@ -81,12 +85,48 @@ func (s *Session) Delete(key string) error {
 	}
 
 	delete(s.session.Values, key)
+	s.modified = true
 	return nil
 }
 
+// Destroy clears the session and expires it when it is saved.
+func (s *Session) Destroy() error {
+	if s == nil {
+		return SessionNotInitialized
+	}
+
+	clear(s.session.Values)
+	// the options may be shared with the store
+	opts := *s.session.Options
+	opts.MaxAge = -1
+	s.session.Options = &opts
+	s.modified = true
+	return nil
+}
+
+// SessionHook is called after the session was saved at the end of the request.
+type SessionHook func(ctx *Context, s *Session)
+
 type SessionMiddleware struct {
 	key   string
 	store sessions.Store
+
+	onCreated   []SessionHook
+	onRefreshed []SessionHook
+	onDestroyed []SessionHook
+
+	cachePolicy *SessionCachePolicy
+}
+
+// SessionCachePolicy keeps session cookies out of shared caches like CDNs, which
+// would serve one user's cookie and personalized response to everybody.
+type SessionCachePolicy struct {
+	// CacheControl is set on responses which set the session cookie, replacing
+	// the header of the handler, "private, no-store" if empty
+	CacheControl string
+	// SaveUnmodified saves sessions on GET and HEAD requests which did not change
+	// them, which extends their expiry but sets a cookie on cacheable responses
+	SaveUnmodified bool
 }
 
 func NewSessionMiddleware(key string, stores ...sessions.Store) *SessionMiddleware {
@@ -102,6 +142,72 @@ func NewSessionMiddleware(key string, stores ...sessions.Store) *SessionMiddlewa
 	}
 }
 
+// OnCreated registers a hook called when a new session was saved for the first time.
+func (m *SessionMiddleware) OnCreated(hooks ...SessionHook) *SessionMiddleware {
+	m.onCreated = append(m.onCreated, hooks...)
+	return m
+}
+
+// OnRefreshed registers a hook called when an existing session was saved again,
+// which extends its expiry.
+func (m *SessionMiddleware) OnRefreshed(hooks ...SessionHook) *SessionMiddleware {
+	m.onRefreshed = append(m.onRefreshed, hooks...)
+	return m
+}
+
+// OnDestroyed registers a hook called when a session was saved with a MaxAge <= 0,
+// e.g. after Session.Destroy. Sessions expired by the store itself are not reported.
+func (m *SessionMiddleware) OnDestroyed(hooks ...SessionHook) *SessionMiddleware {
+	m.onDestroyed = append(m.onDestroyed, hooks...)
+	return m
+}
+
+// WithCachePolicy applies p to the responses of the middleware, unmodified
+// sessions are not saved on GET and HEAD requests unless p.SaveUnmodified.
+func (m *SessionMiddleware) WithCachePolicy(p SessionCachePolicy) *SessionMiddleware {
+	if p.CacheControl == "" {
+		p.CacheControl = "private, no-store"
+	}
+	m.cachePolicy = &p
+	return m
+}
+
+func (m *SessionMiddleware) skipSave(ctx *Context) bool {
+	if m.cachePolicy == nil || m.cachePolicy.SaveUnmodified || ctx.session.modified {
+		return false
+	}
+	return ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead
+}
+
+// setsCookie reports whether the response sets the cookie of the session
+func (m *SessionMiddleware) setsCookie(h http.Header) bool {
+	for _, c := range h.Values("Set-Cookie") {
+		if name, _, _ := strings.Cut(c, "="); name == m.key {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SessionMiddleware) runHooks(ctx *Context, isNew bool) {
+	var hooks []SessionHook
+	switch {
+	case ctx.session.session.Options.MaxAge <= 0:
+		if isNew {
+			return
+		}
+		hooks = m.onDestroyed
+	case isNew:
+		hooks = m.onCreated
+	default:
+		hooks = m.onRefreshed
+	}
+
+	for _, hook := range hooks {
+		hook(ctx, ctx.session)
+	}
+}
+
 type sessionGetter func(r *http.Request, w http.ResponseWriter) (*Session, error)
 
 func (m *SessionMiddleware) sessionGetter(r *http.Request, w http.ResponseWriter) (*Session, error) {
@@ -133,11 +239,23 @@ func (m *SessionMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 		}
 		ctx.session = sess
 		ctx.WithValue(sessionGetterKey, m.sessionGetter)
+		isNew := s.IsNew
 		defer func() {
+			if m.skipSave(ctx) {
+				return
+			}
 			if newErr := ctx.session.Save(); newErr != nil {
 				err = errors.Join(err, newErr)
 				plog.Errorf("Save session error: %v", err)
+				return
+			}
+			if ctx.router != nil {
+				ctx.router.flushedSession()
+			}
+			if m.cachePolicy != nil && m.setsCookie(ctx.Writer.Header()) {
+				ctx.Writer.Header().Set("Cache-Control", m.cachePolicy.CacheControl)
 			}
+			m.runHooks(ctx, isNew)
 		}()
 
 		resp, err = handler.Handle(ctx)
@@ -0,0 +1,156 @@
+package prouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// ShutdownReport sums up a graceful shutdown for deploy tooling verifying it was clean.
+type ShutdownReport struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	// InFlight is the number of requests running when the shutdown began
+	InFlight int64 `json:"in_flight"`
+	Drained  int64 `json:"drained"`
+	// Abandoned requests were still running when the context of Shutdown expired
+	Abandoned int64 `json:"abandoned"`
+	// Rejected counts the requests answered with 503 after the shutdown began
+	Rejected uint64 `json:"rejected"`
+	// SessionsFlushed counts the sessions saved by the drained requests
+	SessionsFlushed uint64 `json:"sessions_flushed"`
+	Clean           bool   `json:"clean"`
+}
+
+type shutdownState struct {
+	active   atomic.Bool
+	rejected atomic.Uint64
+	sessions atomic.Uint64
+
+	mu      sync.Mutex
+	servers []*http.Server
+	report  func(ShutdownReport)
+}
+
+// WithShutdownReport calls fn with the report of Shutdown, it is logged either way.
+func WithShutdownReport(fn func(ShutdownReport)) RouterOption {
+	return func(v *Prouter) {
+		v.shutdown.report = fn
+	}
+}
+
+func (v *Prouter) trackServer(srv *http.Server) *http.Server {
+	v.shutdown.mu.Lock()
+	defer v.shutdown.mu.Unlock()
+	v.shutdown.servers = append(v.shutdown.servers, srv)
+	return srv
+}
+
+// shuttingDown answers 503 to requests which still reach the router after the
+// shutdown began, e.g. through a server it does not run
+func (v *Prouter) shuttingDown(w http.ResponseWriter, r *http.Request) bool {
+	if !v.shutdown.active.Load() {
+		return false
+	}
+
+	v.shutdown.rejected.Add(1)
+	v.RecordRejection("shutdown")
+	w.Header().Set("Connection", "close")
+	msg, _ := v.message(r, MsgShuttingDown)
+	_ = v.writeJSON(w, http.StatusServiceUnavailable, ErrorResponse(http.StatusServiceUnavailable, msg))
+	return true
+}
+
+func (v *Prouter) flushedSession() {
+	if v.shutdown.active.Load() {
+		v.shutdown.sessions.Add(1)
+	}
+}
+
+// Shutdown stops the servers of Run and RunTLS from accepting connections and
+// waits for the requests in flight and the Background tasks until ctx expires,
+// then logs the report and passes it to the WithShutdownReport callback. Run
+// returns http.ErrServerClosed. The router swapped in by Swap is stopped along,
+// its requests are part of the report.
+func (v *Prouter) Shutdown(ctx context.Context) error {
+	start := time.Now()
+	if !v.shutdown.active.CompareAndSwap(false, true) {
+		return errors.New("prouter: shutdown already started")
+	}
+	routers := []*Prouter{v}
+	if served := v.swapped.Load(); served != nil {
+		routers = append(routers, served)
+	}
+	var inflight int64
+	for _, r := range routers {
+		inflight += r.inflightRequests()
+	}
+
+	v.shutdown.mu.Lock()
+	servers := v.shutdown.servers
+	v.shutdown.mu.Unlock()
+
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// hijacked connections and servers not run by the router are not waited for by srv.Shutdown
+	var abandoned int64
+	for _, r := range routers {
+		abandoned += r.drainRequests(ctx)
+	}
+	for _, r := range routers {
+		if err := r.stopBackground(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	report := ShutdownReport{
+		StartedAt:       start,
+		Duration:        time.Since(start),
+		InFlight:        inflight,
+		Drained:         max(inflight-abandoned, 0),
+		Abandoned:       abandoned,
+		Rejected:        v.shutdown.rejected.Load(),
+		SessionsFlushed: v.shutdown.sessions.Load(),
+	}
+	err := errors.Join(errs...)
+	if err == nil && abandoned > 0 {
+		err = ctx.Err()
+	}
+	report.Clean = err == nil
+
+	if report.Clean {
+		plog.Infof("shutdown complete in %v: drained=%d rejected=%d sessionsFlushed=%d",
+			report.Duration, report.Drained, report.Rejected, report.SessionsFlushed)
+	} else {
+		plog.Warnf("shutdown incomplete after %v: drained=%d abandoned=%d rejected=%d sessionsFlushed=%d err=%v",
+			report.Duration, report.Drained, report.Abandoned, report.Rejected, report.SessionsFlushed, err)
+	}
+	if v.shutdown.report != nil {
+		v.shutdown.report(report)
+	}
+	return err
+}
+
+func (v *Prouter) inflightRequests() int64 {
+	return v.RouterGroup.stats.inflight.Load()
+}
+
+// drainRequests waits for the requests in flight until ctx expires and returns
+// the number still running
+func (v *Prouter) drainRequests(ctx context.Context) int64 {
+	for v.inflightRequests() > 0 && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-time.After(drainPollInterval):
+		}
+	}
+	return v.inflightRequests()
+}
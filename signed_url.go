@@ -0,0 +1,99 @@
+package prouter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	SignedURLExpiresParam   = "expires"
+	SignedURLSignatureParam = "signature"
+)
+
+// WithURLSigningKey sets the key of SignedURL and SignedURLMiddleware.
+func WithURLSigningKey(key []byte) RouterOption {
+	return func(v *Prouter) {
+		v.urlSigningKey = key
+	}
+}
+
+func (v *Prouter) signURL(path string, query url.Values) string {
+	mac := hmac.New(sha256.New, v.urlSigningKey)
+	mac.Write([]byte(path + "?" + query.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL returns a link to the route named routeName valid for expiry, e.g. to
+// let a client download a file without a session. params fill the path variables
+// of the route, the others are added to the query and covered by the signature.
+func (v *Prouter) SignedURL(routeName string, params map[string]string, expiry time.Duration) (string, error) {
+	if len(v.urlSigningKey) == 0 {
+		return "", errors.New("prouter: no url signing key, see WithURLSigningKey")
+	}
+
+	r := v.router.Get(routeName)
+	if r == nil {
+		return "", errors.New("prouter: no route named " + routeName)
+	}
+	names, err := r.GetVarNames()
+	if err != nil {
+		return "", err
+	}
+
+	var pairs []string
+	query := make(url.Values)
+	for k, val := range params {
+		if slices.Contains(names, k) {
+			pairs = append(pairs, k, val)
+		} else {
+			query.Set(k, val)
+		}
+	}
+
+	u, err := r.URLPath(pairs...)
+	if err != nil {
+		return "", err
+	}
+
+	query.Set(SignedURLExpiresParam, strconv.FormatInt(v.now().Add(expiry).Unix(), 10))
+	query.Set(SignedURLSignatureParam, v.signURL(u.EscapedPath(), query))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func signedURLError(msg string) error {
+	return MsgError(http.StatusForbidden, msg).
+		SetComponent(ErrProuter).
+		SetResponseType(Forbidden)
+}
+
+// SignedURLMiddleware answers 403 to requests of the routes it guards unless
+// their URL was made by SignedURL and has not expired.
+func (v *Prouter) SignedURLMiddleware() HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		query := ctx.Request.URL.Query()
+		signature := query.Get(SignedURLSignatureParam)
+		query.Del(SignedURLSignatureParam)
+
+		expires, err := strconv.ParseInt(query.Get(SignedURLExpiresParam), 10, 64)
+		if signature == "" || err != nil || len(v.urlSigningKey) == 0 {
+			return nil, signedURLError("signed url required")
+		}
+
+		expected := v.signURL(ctx.Request.URL.EscapedPath(), query)
+		if !hmac.Equal([]byte(expected), []byte(signature)) {
+			return nil, signedURLError("invalid url signature")
+		}
+		if ctx.Now().Unix() > expires {
+			return nil, signedURLError("signed url expired")
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,85 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedURL(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock), WithURLSigningKey([]byte("key")))
+	files := router.Group("/files", router.SignedURLMiddleware())
+	files.GET("/{id}", func(ctx *Context) (Response, error) {
+		return SuccessResponse(ctx.Var("id")), nil
+	}, WithName("download"))
+
+	signed, err := router.SignedURL("download", map[string]string{"id": "7", "disposition": "inline"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/files/7" || u.Query().Get("disposition") != "inline" {
+		t.Fatalf("SignedURL = %s", signed)
+	}
+
+	with := func(fn func(q url.Values)) string {
+		q := u.Query()
+		fn(q)
+		return u.Path + "?" + q.Encode()
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		advance time.Duration
+		code    int
+		msg     string
+	}{
+		{"valid", signed, 0, http.StatusOK, "7"},
+		{"valid until expiry", signed, time.Minute, http.StatusOK, "7"},
+		{"expired", signed, time.Minute + time.Second, http.StatusForbidden, "signed url expired"},
+		{"other path", "/files/8?" + u.RawQuery, 0, http.StatusForbidden, "invalid url signature"},
+		{"changed param", with(func(q url.Values) { q.Set("disposition", "attachment") }), 0, http.StatusForbidden, "invalid url signature"},
+		{"added param", with(func(q url.Values) { q.Set("admin", "1") }), 0, http.StatusForbidden, "invalid url signature"},
+		{"extended expiry", with(func(q url.Values) { q.Set(SignedURLExpiresParam, "9999999999") }), 0, http.StatusForbidden, "invalid url signature"},
+		{"no signature", with(func(q url.Values) { q.Del(SignedURLSignatureParam) }), 0, http.StatusForbidden, "signed url required"},
+		{"unsigned", "/files/7", 0, http.StatusForbidden, "signed url required"},
+	}
+	start := clock.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Set(start.Add(tt.advance))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.code || !strings.Contains(rec.Body.String(), tt.msg) {
+				t.Errorf("GET %s = %d %s, want %d with %q", tt.target, rec.Code, rec.Body, tt.code, tt.msg)
+			}
+		})
+	}
+}
+
+func TestSignedURLErrors(t *testing.T) {
+	noop := func(*Context) (Response, error) { return nil, nil }
+	tests := []struct {
+		name  string
+		opts  []RouterOption
+		route string
+	}{
+		{"no key", nil, "download"},
+		{"unknown route", []RouterOption{WithURLSigningKey([]byte("key"))}, "missing"},
+	}
+	for _, tt := range tests {
+		router := New(tt.opts...)
+		router.GET("/files/{id}", noop, WithName("download"))
+		if u, err := router.SignedURL(tt.route, map[string]string{"id": "1"}, time.Minute); err == nil {
+			t.Errorf("%s: SignedURL = %s, want an error", tt.name, u)
+		}
+	}
+}
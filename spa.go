@@ -0,0 +1,77 @@
+package prouter
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+const spaIndex = "/index.html"
+
+// spaHandler serves the files of fs and answers index.html for the client side
+// routes, paths with an extension are taken for missing assets and get a 404.
+func (rg *RouterGroup) spaHandler(fs http.FileSystem) HandleFunc {
+	static := rg.staticHandler(fs)
+
+	return func(ctx *Context) (Response, error) {
+		p := "/" + ctx.ParamPath(staticPathVar)
+		if p != spaIndex && isFile(fs, p) {
+			return static(ctx)
+		}
+		if path.Ext(p) != "" && p != spaIndex {
+			http.NotFound(ctx.Writer, ctx.Request)
+			return nil, nil
+		}
+
+		f, err := fs.Open(spaIndex)
+		if err != nil {
+			http.NotFound(ctx.Writer, ctx.Request)
+			return nil, nil
+		}
+		defer f.Close()
+		st, err := f.Stat()
+		if err != nil {
+			return nil, NewErr(http.StatusInternalServerError, err, "stat index.html").SetComponent(ErrProuter)
+		}
+
+		// the index references the hashed assets of a build, it is revalidated on every visit
+		ctx.Writer.Header().Set("Cache-Control", "no-cache")
+		http.ServeContent(ctx.Writer, ctx.Request, spaIndex, st.ModTime(), f)
+		return nil, nil
+	}
+}
+
+func isFile(fs http.FileSystem, name string) bool {
+	f, err := fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	return err == nil && !st.IsDir()
+}
+
+// SPA mounts a single page application at relativePath of the group, behind the
+// middlewares of the group. The files of fs are served as by StaticFS, any other
+// path without an extension is answered with /index.html so the client side
+// router can handle it. Register it after the API routes of the group, it
+// matches every path under relativePath:
+//
+//	admin := router.Group("/admin", requireAdmin)
+//	admin.GET("/api/users", listUsers)
+//	admin.SPA("/ui", http.FS(uiDist))
+func (rg *RouterGroup) SPA(relativePath string, fs http.FileSystem, opts ...RouteOption) {
+	if !strings.HasPrefix(relativePath, "/") {
+		relativePath = "/" + relativePath
+	}
+	handler := &wrapHandler{
+		name:    "SPAHandler",
+		handler: rg.spaHandler(fs),
+	}
+
+	if relativePath != "/" {
+		// the bare mount path has no filepath, it serves the index as well
+		rg.handleRoute(http.MethodGet, relativePath, handler, opts...)
+	}
+	rg.handleRoute(http.MethodGet, path.Join(relativePath, "{"+staticPathVar+":path}"), handler, opts...)
+}
@@ -0,0 +1,71 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPA(t *testing.T) {
+	dist := fstest.MapFS{
+		"index.html":        {Data: []byte("<html>index</html>")},
+		"app.js":            {Data: []byte("console.log(1)")},
+		"assets/logo.svg":   {Data: []byte("<svg/>")},
+		"assets/sub/a.html": {Data: []byte("nested")},
+	}
+
+	router := New()
+	admin := router.Group("/admin", func(ctx *Context) (Response, error) {
+		if ctx.Request.Header.Get("X-Admin") == "" {
+			return nil, MsgError(http.StatusForbidden, "forbidden").SetResponseType(BadRequest)
+		}
+		return nil, nil
+	})
+	admin.GET("/api/users", func(*Context) (Response, error) {
+		return SuccessResponse("users"), nil
+	})
+	admin.SPA("/ui", http.FS(dist))
+
+	tests := []struct {
+		name  string
+		path  string
+		admin bool
+		code  int
+		body  string
+		cache string
+	}{
+		{"mount path", "/admin/ui", true, http.StatusOK, "<html>index</html>", "no-cache"},
+		{"client route", "/admin/ui/users/42", true, http.StatusOK, "<html>index</html>", "no-cache"},
+		{"directory", "/admin/ui/assets", true, http.StatusOK, "<html>index</html>", "no-cache"},
+		{"index", "/admin/ui/index.html", true, http.StatusOK, "<html>index</html>", "no-cache"},
+		{"asset", "/admin/ui/app.js", true, http.StatusOK, "console.log(1)", ""},
+		{"nested asset", "/admin/ui/assets/logo.svg", true, http.StatusOK, "<svg/>", ""},
+		{"missing asset", "/admin/ui/missing.js", true, http.StatusNotFound, "404 page not found", ""},
+		// the cleaned path leaves the mount, it is redirected instead of served from fs
+		{"encoded traversal", "/admin/ui/..%2f..%2fgo.mod", true, http.StatusMovedPermanently, "", ""},
+		{"api route before the spa", "/admin/api/users", true, http.StatusOK, "users", ""},
+		{"group middleware", "/admin/ui/users", false, http.StatusForbidden, "forbidden", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.admin {
+				req.Header.Set("X-Admin", "1")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("code = %d, want %d", rec.Code, tt.code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body, tt.body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.cache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cache)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const (
+	defaultClientBuffer = 64
+	defaultReplaySize   = 256
+	defaultHeartbeat    = 15 * time.Second
+)
+
+type Event struct {
+	// ID is assigned by the ReplayStore when the event is published
+	ID    string
+	Event string
+	Data  []byte
+	Retry time.Duration
+}
+
+// fieldReplacer drops the line breaks which would end a field and start another
+var fieldReplacer = strings.NewReplacer("\r", "", "\n", "", "\x00", "")
+
+// writeTo writes the event in the text/event-stream format, line breaks are
+// removed from ID and Event, a CR, LF or CRLF in Data starts a new data line.
+func (e *Event) writeTo(b *bytes.Buffer) {
+	if id := fieldReplacer.Replace(e.ID); id != "" {
+		fmt.Fprintf(b, "id: %s\n", id)
+	}
+	if event := fieldReplacer.Replace(e.Event); event != "" {
+		fmt.Fprintf(b, "event: %s\n", event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	data := bytes.ReplaceAll(e.Data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		b.WriteString("data: ")
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+}
+
+// TopicsFunc returns the topics the request subscribes to.
+type TopicsFunc func(ctx *prouter.Context) []string
+
+type client struct {
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+	})
+}
+
+// Broker fans events published to a topic out to the subscribed clients, a
+// client whose buffer is full is disconnected and resumes with Last-Event-ID.
+type Broker struct {
+	store      ReplayStore
+	bufferSize int
+	heartbeat  time.Duration
+	topics     TopicsFunc
+
+	mu      sync.RWMutex
+	clients map[string]map[*client]struct{}
+	closed  bool
+}
+
+type BrokerOption func(*Broker)
+
+// WithReplayStore sets where events are kept for replay, an in memory RingStore by default.
+func WithReplayStore(store ReplayStore) BrokerOption {
+	return func(b *Broker) {
+		b.store = store
+	}
+}
+
+func WithClientBuffer(n int) BrokerOption {
+	return func(b *Broker) {
+		b.bufferSize = n
+	}
+}
+
+// WithHeartbeat sets the interval of the keepalive comments sent to idle clients.
+func WithHeartbeat(d time.Duration) BrokerOption {
+	return func(b *Broker) {
+		b.heartbeat = d
+	}
+}
+
+// WithTopics sets how the topics are read from the request, the topic query parameter by default.
+func WithTopics(fn TopicsFunc) BrokerOption {
+	return func(b *Broker) {
+		b.topics = fn
+	}
+}
+
+func NewBroker(opts ...BrokerOption) *Broker {
+	b := &Broker{
+		store:      NewRingStore(defaultReplaySize),
+		bufferSize: defaultClientBuffer,
+		heartbeat:  defaultHeartbeat,
+		topics: func(ctx *prouter.Context) []string {
+			return ctx.QueryAll("topic")
+		},
+		clients: make(map[string]map[*client]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Publish stores the event and sends it to the subscribers of topic.
+func (b *Broker) Publish(ctx context.Context, topic string, e Event) error {
+	if err := b.store.Append(ctx, topic, &e); err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for c := range b.clients[topic] {
+		select {
+		case c.events <- e:
+		case <-c.done:
+		default:
+			c.close()
+		}
+	}
+	return nil
+}
+
+func (b *Broker) subscribe(c *client, topics []string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false
+	}
+	for _, topic := range topics {
+		subs, ok := b.clients[topic]
+		if !ok {
+			subs = make(map[*client]struct{})
+			b.clients[topic] = subs
+		}
+		subs[c] = struct{}{}
+	}
+	return true
+}
+
+func (b *Broker) unsubscribe(c *client, topics []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(b.clients[topic], c)
+		if len(b.clients[topic]) == 0 {
+			delete(b.clients, topic)
+		}
+	}
+}
+
+// Close disconnects all clients and rejects new ones.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.clients {
+		for c := range subs {
+			c.close()
+		}
+	}
+}
+
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
+}
+
+// Handle streams the events of the requested topics, it is meant to be
+// registered as route handler: r.GET("/events", broker.Handle).
+func (b *Broker) Handle(ctx *prouter.Context) (prouter.Response, error) {
+	topics := b.topics(ctx)
+	if len(topics) == 0 {
+		return nil, prouter.MsgError(http.StatusBadRequest, "no topic given").
+			SetComponent(prouter.ErrProuter).
+			SetResponseType(prouter.BadRequest)
+	}
+
+	c := &client{events: make(chan Event, b.bufferSize), done: make(chan struct{})}
+	// subscribe before the replay so no event published in between is lost
+	if !b.subscribe(c, topics) {
+		return nil, prouter.MsgError(http.StatusServiceUnavailable, "broker closed").SetComponent(prouter.ErrProuter)
+	}
+	defer b.unsubscribe(c, topics)
+
+	w := ctx.Writer
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var buf bytes.Buffer
+	send := func(e *Event) bool {
+		buf.Reset()
+		e.writeTo(&buf)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return false
+		}
+		w.Flush()
+		return true
+	}
+
+	replayed := make(map[string]struct{})
+	if last := lastEventID(ctx.Request); last != "" {
+		for _, topic := range topics {
+			events, err := b.store.Since(ctx, topic, last)
+			if err != nil {
+				plog.Errorc(ctx, "sse replay topic %s since %s error: %v", topic, last, err)
+				continue
+			}
+			for i := range events {
+				replayed[events[i].ID] = struct{}{}
+				if !send(&events[i]) {
+					return nil, nil
+				}
+			}
+		}
+	}
+	w.Flush()
+
+	ticker := time.NewTicker(b.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-c.events:
+			if _, ok := replayed[e.ID]; ok {
+				continue
+			}
+			if !send(&e) {
+				return nil, nil
+			}
+		case <-ticker.C:
+			if _, err := w.Write([]byte(": ping " + strconv.FormatInt(time.Now().Unix(), 10) + "\n\n")); err != nil {
+				return nil, nil
+			}
+			w.Flush()
+		case <-c.done:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
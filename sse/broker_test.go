@@ -0,0 +1,62 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventWriteTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{"plain", Event{ID: "1", Event: "msg", Data: []byte("hello")},
+			"id: 1\nevent: msg\ndata: hello\n\n"},
+		{"multi line data", Event{Data: []byte("a\nb\r\nc\rd")},
+			"data: a\ndata: b\ndata: c\ndata: d\n\n"},
+		{"injected id", Event{ID: "1\ndata: forged", Data: []byte("x")},
+			"id: 1data: forged\ndata: x\n\n"},
+		{"injected event", Event{Event: "msg\r\nretry: 1", Data: []byte("x")},
+			"event: msgretry: 1\ndata: x\n\n"},
+		{"retry", Event{Retry: 3 * time.Second, Data: []byte("x")},
+			"retry: 3000\ndata: x\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.event.writeTo(&buf)
+			if buf.String() != tt.want {
+				t.Errorf("writeTo = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRingStore(t *testing.T) {
+	tests := []struct {
+		size int
+		want int
+	}{
+		{-1, 3},
+		{0, 3},
+		{2, 2},
+	}
+	for _, tt := range tests {
+		s := NewRingStore(tt.size)
+		for range 3 {
+			if err := s.Append(context.Background(), "t", &Event{Data: []byte("x")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		events, err := s.Since(context.Background(), "t", "0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != tt.want {
+			t.Errorf("NewRingStore(%d): %d events replayed, want %d", tt.size, len(events), tt.want)
+		}
+	}
+}
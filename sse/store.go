@@ -0,0 +1,142 @@
+package sse
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-puzzles/puzzles/goredis"
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayStore keeps the published events so reconnecting clients can resume
+// from their Last-Event-ID. Append assigns the ID of the event.
+type ReplayStore interface {
+	Append(ctx context.Context, topic string, e *Event) error
+	Since(ctx context.Context, topic, lastID string) ([]Event, error)
+}
+
+// RingStore keeps the last size events of every topic in memory, IDs are a
+// sequence shared by all topics.
+type RingStore struct {
+	size int
+
+	mu     sync.Mutex
+	seq    uint64
+	topics map[string]*ring
+}
+
+type ring struct {
+	events []Event
+	seqs   []uint64
+	next   int
+	full   bool
+}
+
+// NewRingStore keeps size events per topic, 256 if size is not positive.
+func NewRingStore(size int) *RingStore {
+	if size <= 0 {
+		size = defaultReplaySize
+	}
+	return &RingStore{size: size, topics: make(map[string]*ring)}
+}
+
+func (s *RingStore) Append(_ context.Context, topic string, e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.topics[topic]
+	if !ok {
+		r = &ring{events: make([]Event, s.size), seqs: make([]uint64, s.size)}
+		s.topics[topic] = r
+	}
+
+	s.seq++
+	e.ID = strconv.FormatUint(s.seq, 10)
+	r.events[r.next] = *e
+	r.seqs[r.next] = s.seq
+	r.next = (r.next + 1) % s.size
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+func (s *RingStore) Since(_ context.Context, topic, lastID string) ([]Event, error) {
+	last, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.topics[topic]
+	if !ok {
+		return nil, nil
+	}
+
+	start, n := 0, r.next
+	if r.full {
+		start, n = r.next, s.size
+	}
+
+	var events []Event
+	for i := 0; i < n; i++ {
+		idx := (start + i) % s.size
+		if r.seqs[idx] > last {
+			events = append(events, r.events[idx])
+		}
+	}
+	return events, nil
+}
+
+// RedisStreamStore keeps the events in a redis stream per topic, trimmed to about maxLen entries.
+type RedisStreamStore struct {
+	client *goredis.PuzzleRedisClient
+	prefix string
+	maxLen int64
+}
+
+func NewRedisStreamStore(client *goredis.PuzzleRedisClient, prefix string, maxLen int64) *RedisStreamStore {
+	return &RedisStreamStore{client: client, prefix: prefix, maxLen: maxLen}
+}
+
+func (s *RedisStreamStore) key(topic string) string {
+	return s.prefix + ":" + topic
+}
+
+func (s *RedisStreamStore) Append(ctx context.Context, topic string, e *Event) error {
+	id, err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.key(topic),
+		MaxLen: s.maxLen,
+		Approx: true,
+		Values: map[string]any{"event": e.Event, "data": e.Data},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	e.ID = id
+	return nil
+}
+
+func (s *RedisStreamStore) Since(ctx context.Context, topic, lastID string) ([]Event, error) {
+	msgs, err := s.client.XRange(ctx, s.key(topic), "("+lastID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(msgs))
+	for _, msg := range msgs {
+		e := Event{ID: msg.ID}
+		if v, ok := msg.Values["event"].(string); ok {
+			e.Event = v
+		}
+		if v, ok := msg.Values["data"].(string); ok {
+			e.Data = []byte(v)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
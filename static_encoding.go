@@ -0,0 +1,105 @@
+package prouter
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// staticEncodings are the precompressed sidecars looked up next to a static file,
+// in order of preference.
+var staticEncodings = []struct {
+	name string
+	ext  string
+}{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+// acceptedEncodings parses Accept-Encoding into the q value of each coding, * included.
+func acceptedEncodings(header string) map[string]float64 {
+	ret := make(map[string]float64)
+	for _, enc := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		ret[name] = q
+	}
+	return ret
+}
+
+func acceptsEncoding(accepted map[string]float64, name string) bool {
+	if q, ok := accepted[name]; ok {
+		return q > 0
+	}
+	return accepted["*"] > 0
+}
+
+// servePrecompressed serves the .br or .gz sidecar of name, e.g. app.js.br for
+// app.js, when the client accepts its encoding. It reports false when the file
+// has no acceptable sidecar and the file server should serve it.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fs http.FileSystem, name string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	dir := strings.HasSuffix(name, "/")
+	name = path.Clean(name)
+	if dir {
+		name = path.Join(name, "index.html")
+	}
+	// the encoded bytes cannot be sniffed, the type comes from the extension
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		return false
+	}
+
+	if o, ok := fs.(*overlayFS); ok {
+		if fs = o.layerOf(name); fs == nil {
+			return false
+		}
+	}
+
+	accepted := acceptedEncodings(r.Header.Get("Accept-Encoding"))
+	vary := false
+	for _, enc := range staticEncodings {
+		f, err := fs.Open(name + enc.ext)
+		if err != nil {
+			continue
+		}
+		stat, err := f.Stat()
+		if err != nil || stat.IsDir() {
+			f.Close()
+			continue
+		}
+
+		// the representation differs by encoding whenever a sidecar exists
+		if !vary {
+			w.Header().Add("Vary", "Accept-Encoding")
+			vary = true
+		}
+		if !acceptsEncoding(accepted, enc.name) {
+			f.Close()
+			continue
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Encoding", enc.name)
+		http.ServeContent(w, r, name, stat.ModTime(), f)
+		f.Close()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package prouter
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"sort"
+)
+
+// OverlayLayer is a filesystem of an Overlay.
+type OverlayLayer struct {
+	fs        http.FileSystem
+	debugOnly bool
+}
+
+// Layer is a layer served in every mode.
+func Layer(fs http.FileSystem) OverlayLayer {
+	return OverlayLayer{fs: fs}
+}
+
+// DebugLayer is a layer skipped outside of DebugMode, e.g. a local directory
+// with development overrides.
+func DebugLayer(fs http.FileSystem) OverlayLayer {
+	return OverlayLayer{fs: fs, debugOnly: true}
+}
+
+type overlayFS struct {
+	layers []OverlayLayer
+}
+
+// Overlay stacks filesystems for StaticFS, a file is served from the first layer
+// which has it and directories list the files of all layers:
+//
+//	rg.StaticFS("/static", prouter.Overlay(
+//		prouter.DebugLayer(http.Dir("./web/dist")),
+//		prouter.Layer(http.FS(embedded)),
+//	))
+//
+// The mode is checked on every open, so SetMode may come after the mount.
+func Overlay(layers ...OverlayLayer) http.FileSystem {
+	return &overlayFS{layers: layers}
+}
+
+func (o *overlayFS) active() []http.FileSystem {
+	ret := make([]http.FileSystem, 0, len(o.layers))
+	for _, l := range o.layers {
+		if !l.debugOnly || prouterMode == DebugMode {
+			ret = append(ret, l.fs)
+		}
+	}
+	return ret
+}
+
+// layerOf returns the layer serving name, so its precompressed sidecars are not
+// taken from a layer it overrides
+func (o *overlayFS) layerOf(name string) http.FileSystem {
+	for _, layer := range o.active() {
+		f, err := layer.Open(name)
+		if err == nil {
+			f.Close()
+			return layer
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) Open(name string) (http.File, error) {
+	var (
+		first http.File
+		dirs  []http.File
+	)
+	for _, layer := range o.active() {
+		f, err := layer.Open(name)
+		if err != nil {
+			continue
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			f.Close()
+			continue
+		}
+		if first == nil {
+			first = f
+			if !stat.IsDir() {
+				return f, nil
+			}
+			continue
+		}
+		// a file below a directory is shadowed like any lower layer
+		if stat.IsDir() {
+			dirs = append(dirs, f)
+		} else {
+			f.Close()
+		}
+	}
+
+	if first == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if len(dirs) == 0 {
+		return first, nil
+	}
+	return &overlayDir{File: first, lower: dirs}, nil
+}
+
+// overlayDir is a directory present in several layers, it lists their entries
+// merged with the upper layers winning.
+type overlayDir struct {
+	http.File
+	lower   []http.File
+	entries []fs.FileInfo
+	read    bool
+	offset  int
+}
+
+func (d *overlayDir) Close() error {
+	for _, f := range d.lower {
+		f.Close()
+	}
+	return d.File.Close()
+}
+
+func (d *overlayDir) Readdir(count int) ([]fs.FileInfo, error) {
+	if !d.read {
+		seen := make(map[string]bool)
+		for _, f := range append([]http.File{d.File}, d.lower...) {
+			infos, err := f.Readdir(-1)
+			if err != nil {
+				return nil, err
+			}
+			for _, info := range infos {
+				if !seen[info.Name()] {
+					seen[info.Name()] = true
+					d.entries = append(d.entries, info)
+				}
+			}
+		}
+		sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].Name() < d.entries[j].Name() })
+		d.read = true
+	}
+
+	rest := d.entries[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
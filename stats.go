@@ -0,0 +1,240 @@
+package prouter
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type inflightCounter struct {
+	inflight atomic.Int64
+	peak     atomic.Int64
+	total    atomic.Uint64
+}
+
+func (c *inflightCounter) inc() {
+	n := c.inflight.Add(1)
+	c.total.Add(1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+func (c *inflightCounter) dec() {
+	c.inflight.Add(-1)
+}
+
+// groupStats counts the requests served by the routes of a group and its sub groups
+type groupStats struct {
+	inflightCounter
+	parent *groupStats
+}
+
+func (g *groupStats) inc() {
+	for ; g != nil; g = g.parent {
+		g.inflightCounter.inc()
+	}
+}
+
+func (g *groupStats) dec() {
+	for ; g != nil; g = g.parent {
+		g.inflightCounter.dec()
+	}
+}
+
+type routerStats struct {
+	mu         sync.RWMutex
+	groups     map[string]*groupStats
+	rejections map[string]*atomic.Uint64
+}
+
+func (s *routerStats) group(prefix string, parent *groupStats) *groupStats {
+	if prefix == "" {
+		prefix = "/"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.groups == nil {
+		s.groups = make(map[string]*groupStats)
+	}
+	if g, ok := s.groups[prefix]; ok {
+		return g
+	}
+	g := &groupStats{parent: parent}
+	s.groups[prefix] = g
+	return g
+}
+
+// RecordRejection counts a request rejected by source, e.g. a rate limiter or bulkhead.
+func (v *Prouter) RecordRejection(source string) {
+	v.stats.mu.RLock()
+	c, ok := v.stats.rejections[source]
+	v.stats.mu.RUnlock()
+	if ok {
+		c.Add(1)
+		return
+	}
+
+	v.stats.mu.Lock()
+	if v.stats.rejections == nil {
+		v.stats.rejections = make(map[string]*atomic.Uint64)
+	}
+	if c, ok = v.stats.rejections[source]; !ok {
+		c = new(atomic.Uint64)
+		v.stats.rejections[source] = c
+	}
+	v.stats.mu.Unlock()
+	c.Add(1)
+}
+
+type ConcurrencyStats struct {
+	InFlight int64  `json:"in_flight"`
+	Peak     int64  `json:"peak"`
+	Requests uint64 `json:"requests"`
+}
+
+func (c *inflightCounter) snapshot() ConcurrencyStats {
+	return ConcurrencyStats{
+		InFlight: c.inflight.Load(),
+		Peak:     c.peak.Load(),
+		Requests: c.total.Load(),
+	}
+}
+
+// RouterStats is a snapshot of the runtime counters, groups are keyed by prefix
+// and routes by "METHOD template".
+type RouterStats struct {
+	Groups     map[string]ConcurrencyStats `json:"groups"`
+	Routes     map[string]ConcurrencyStats `json:"routes"`
+	Rejections map[string]uint64           `json:"rejections"`
+	Traffic    map[string]TrafficStats     `json:"traffic"`
+	Conns      ConnectionStats             `json:"connections"`
+}
+
+func (v *Prouter) Stats() RouterStats {
+	st := RouterStats{
+		Groups:     make(map[string]ConcurrencyStats),
+		Routes:     make(map[string]ConcurrencyStats),
+		Rejections: make(map[string]uint64),
+		Traffic:    make(map[string]TrafficStats),
+		Conns:      v.conns.snapshot(),
+	}
+
+	v.stats.mu.RLock()
+	for prefix, g := range v.stats.groups {
+		st.Groups[prefix] = g.snapshot()
+	}
+	for source, c := range v.stats.rejections {
+		st.Rejections[source] = c.Load()
+	}
+	v.stats.mu.RUnlock()
+
+	v.routeSlots.mu.RLock()
+	for key, slot := range v.routeSlots.slots {
+		st.Routes[key] = slot.stats.snapshot()
+		st.Traffic[key] = slot.info.traffic.snapshot()
+	}
+	v.routeSlots.mu.RUnlock()
+
+	return st
+}
+
+// PublishExpvar publishes Stats under name in expvar, it panics if name is already published.
+func (v *Prouter) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return v.Stats()
+	}))
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+var promLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes Stats in the Prometheus text exposition format.
+func (v *Prouter) WritePrometheus(w io.Writer) error {
+	st := v.Stats()
+
+	var b strings.Builder
+	writeSeries := func(name, typ, help, label string, values map[string]ConcurrencyStats, value func(ConcurrencyStats) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, k := range sortedKeys(values) {
+			fmt.Fprintf(&b, "%s{%s=\"%s\"} %s\n", name, label, promLabelReplacer.Replace(k), value(values[k]))
+		}
+	}
+	inflight := func(c ConcurrencyStats) string { return fmt.Sprint(c.InFlight) }
+	peak := func(c ConcurrencyStats) string { return fmt.Sprint(c.Peak) }
+	total := func(c ConcurrencyStats) string { return fmt.Sprint(c.Requests) }
+
+	writeSeries("prouter_group_in_flight_requests", "gauge", "Requests in flight per group.", "group", st.Groups, inflight)
+	writeSeries("prouter_group_requests_total", "counter", "Requests served per group.", "group", st.Groups, total)
+	writeSeries("prouter_route_in_flight_requests", "gauge", "Requests in flight per route.", "route", st.Routes, inflight)
+	writeSeries("prouter_route_peak_in_flight_requests", "gauge", "Peak concurrency per route.", "route", st.Routes, peak)
+	writeSeries("prouter_route_requests_total", "counter", "Requests served per route.", "route", st.Routes, total)
+
+	writeTraffic := func(name, help string, value func(TrafficStats) uint64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, k := range sortedKeys(st.Traffic) {
+			fmt.Fprintf(&b, "%s{route=\"%s\"} %d\n", name, promLabelReplacer.Replace(k), value(st.Traffic[k]))
+		}
+	}
+	writeTraffic("prouter_route_request_bytes_total", "Request body bytes read per route.", func(t TrafficStats) uint64 { return t.BytesIn })
+	writeTraffic("prouter_route_response_bytes_total", "Response body bytes written per route.", func(t TrafficStats) uint64 { return t.BytesOut })
+
+	b.WriteString("# HELP prouter_rejections_total Requests rejected per source.\n# TYPE prouter_rejections_total counter\n")
+	for _, k := range sortedKeys(st.Rejections) {
+		fmt.Fprintf(&b, "prouter_rejections_total{source=\"%s\"} %d\n", promLabelReplacer.Replace(k), st.Rejections[k])
+	}
+
+	writeGauge := func(name, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	writeCounter := func(name, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %v\n", name, help, name, name, value)
+	}
+	b.WriteString("# HELP prouter_connections Open connections per state.\n# TYPE prouter_connections gauge\n")
+	for _, s := range []struct {
+		state string
+		n     int64
+	}{{"new", st.Conns.New}, {"active", st.Conns.Active}, {"idle", st.Conns.Idle}} {
+		fmt.Fprintf(&b, "prouter_connections{state=\"%s\"} %d\n", s.state, s.n)
+	}
+	writeGauge("prouter_connections_open", "Open connections.", st.Conns.Open)
+	writeCounter("prouter_connections_accepted_total", "Connections accepted.", st.Conns.Accepted)
+	writeCounter("prouter_connections_closed_total", "Connections closed.", st.Conns.Closed)
+	writeCounter("prouter_connections_hijacked_total", "Connections hijacked, e.g. by websockets.", st.Conns.Hijacked)
+	writeCounter("prouter_connections_limit_waits_total", "Times the accept loop blocked at the connection limit.", st.Conns.LimitWaits)
+	writeCounter("prouter_connections_rate_limited_total", "Connections closed at the per ip connection rate limit.", st.Conns.RateLimited)
+
+	if v.routeInfoMetric {
+		v.writeRouteInfo(&b)
+	}
+	v.writeCertExpiry(&b)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// StatsHandler serves WritePrometheus, to be mounted on the metrics endpoint.
+func (v *Prouter) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = v.WritePrometheus(w)
+	})
+}
@@ -0,0 +1,79 @@
+package prouter
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// SurrogateKeyHeader is the space separated tag header of Fastly
+	SurrogateKeyHeader = "Surrogate-Key"
+	// CacheTagHeader is the comma separated tag header of Cloudflare
+	CacheTagHeader = "Cache-Tag"
+)
+
+// WithSurrogateKeyHeader sets the header the surrogate keys are sent in,
+// Surrogate-Key by default or CacheTagHeader for Cloudflare.
+func WithSurrogateKeyHeader(header string) RouterOption {
+	return func(v *Prouter) {
+		v.surrogateHeader = http.CanonicalHeaderKey(header)
+	}
+}
+
+// WithSurrogateKeys tags the responses of the route for the CDN, a key can refer
+// to path variables: WithSurrogateKeys("users", "user-{id}").
+func WithSurrogateKeys(keys ...string) RouteOption {
+	return func(c *routeConfig) {
+		c.surrogateKeys = append(c.surrogateKeys, keys...)
+	}
+}
+
+func (c *Context) surrogateHeader() (string, string) {
+	if c.router != nil && c.router.surrogateHeader != "" && c.router.surrogateHeader != SurrogateKeyHeader {
+		return c.router.surrogateHeader, ","
+	}
+	return SurrogateKeyHeader, " "
+}
+
+// expandKey replaces the {var} placeholders of key by the path variables
+func (c *Context) expandKey(key string) string {
+	if !strings.Contains(key, "{") {
+		return key
+	}
+	for name, value := range c.Vars() {
+		key = strings.ReplaceAll(key, "{"+name+"}", value)
+	}
+	return key
+}
+
+// AddSurrogateKeys tags the response with keys in addition to the ones of the
+// route, so it can be purged by them. Call it before the response is written.
+func (c *Context) AddSurrogateKeys(keys ...string) {
+	header, sep := c.surrogateHeader()
+
+	h := c.Writer.Header()
+	var current []string
+	if v := h.Get(header); v != "" {
+		current = strings.Split(v, sep)
+	}
+	for _, k := range keys {
+		k = strings.TrimSpace(c.expandKey(k))
+		if k != "" && !slices.Contains(current, k) {
+			current = append(current, k)
+		}
+	}
+	if len(current) > 0 {
+		h.Set(header, strings.Join(current, sep))
+	}
+}
+
+// SurrogateKeys returns the keys the response is tagged with so far.
+func (c *Context) SurrogateKeys() []string {
+	header, sep := c.surrogateHeader()
+	v := c.Writer.Header().Get(header)
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, sep)
+}
@@ -0,0 +1,241 @@
+package prouter
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+const maxRewriteDepth = 32
+
+type tagTypeKey struct {
+	t   reflect.Type
+	tag string
+}
+
+var taggedTypes sync.Map // tagTypeKey -> bool
+
+// hasTaggedField reports whether values of t can hold a string or *string field
+// tagged with tag, interfaces are unknown until walked and report true.
+func hasTaggedField(t reflect.Type, tag string) bool {
+	key := tagTypeKey{t: t, tag: tag}
+	if ret, ok := taggedTypes.Load(key); ok {
+		return ret.(bool)
+	}
+
+	ret := scanTaggedField(t, tag, make(map[reflect.Type]bool))
+	taggedTypes.Store(key, ret)
+	return ret
+}
+
+func scanTaggedField(t reflect.Type, tag string, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return scanTaggedField(t.Elem(), tag, seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !jsonVisible(sf) {
+				continue
+			}
+			if _, ok := sf.Tag.Lookup(tag); ok && isStringField(sf.Type) {
+				return true
+			}
+			if scanTaggedField(sf.Type, tag, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonVisible reports whether encoding/json writes sf or the fields promoted
+// through it, embedded structs are walked even when unexported.
+func jsonVisible(sf reflect.StructField) bool {
+	if sf.IsExported() {
+		return true
+	}
+	if !sf.Anonymous {
+		return false
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func isStringField(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.String
+}
+
+// rewriteString rewrites a tagged string or *string field in place
+func rewriteString(fv reflect.Value, tagValue string, fn func(string, string) string) bool {
+	if fv.Kind() == reflect.String {
+		s := fn(tagValue, fv.String())
+		if s == fv.String() {
+			return false
+		}
+		fv.SetString(s)
+		return true
+	}
+
+	if fv.IsNil() {
+		return false
+	}
+	s := fn(tagValue, fv.Elem().String())
+	if s == fv.Elem().String() {
+		return false
+	}
+	p := reflect.New(fv.Type().Elem())
+	p.Elem().SetString(s)
+	fv.Set(p)
+	return true
+}
+
+// rewriteTagged returns a copy of data with every string or *string field tagged
+// with tag replaced by fn(tagValue, value), values nested deeper than
+// maxRewriteDepth are replaced by their zero value. data itself is left untouched as handlers
+// may return shared or cached values.
+func rewriteTagged(data any, tag string, fn func(tagValue, value string) string) any {
+	if data == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(data)
+	if !hasTaggedField(v.Type(), tag) {
+		return data
+	}
+
+	ret, changed := rewriteValue(v, tag, fn, 0)
+	if !changed {
+		return data
+	}
+	return ret.Interface()
+}
+
+// rewriteValue returns the rewritten copy of v and whether anything was rewritten
+func rewriteValue(v reflect.Value, tag string, fn func(string, string) string, depth int) (reflect.Value, bool) {
+	if !v.IsValid() || !hasTaggedField(v.Type(), tag) {
+		return v, false
+	}
+	// too deep to walk, it may hold tagged fields which are not rewritten
+	if depth > maxRewriteDepth {
+		return reflect.Zero(v.Type()), true
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := rewriteValue(v.Elem(), tag, fn, depth+1)
+		if !changed {
+			return v, false
+		}
+		ret := reflect.New(v.Type()).Elem()
+		ret.Set(elem)
+		return ret, true
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := rewriteValue(v.Elem(), tag, fn, depth+1)
+		if !changed {
+			return v, false
+		}
+		ret := reflect.New(v.Type().Elem())
+		ret.Elem().Set(elem)
+		return ret, true
+
+	case reflect.Slice, reflect.Array:
+		var ret reflect.Value
+		for i := range v.Len() {
+			elem, changed := rewriteValue(v.Index(i), tag, fn, depth+1)
+			if !changed {
+				continue
+			}
+			if !ret.IsValid() {
+				ret = copyList(v)
+			}
+			ret.Index(i).Set(elem)
+		}
+		if !ret.IsValid() {
+			return v, false
+		}
+		return ret, true
+
+	case reflect.Map:
+		var ret reflect.Value
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, changed := rewriteValue(iter.Value(), tag, fn, depth+1)
+			if !changed {
+				continue
+			}
+			if !ret.IsValid() {
+				ret = reflect.MakeMapWithSize(v.Type(), v.Len())
+				for _, k := range v.MapKeys() {
+					ret.SetMapIndex(k, v.MapIndex(k))
+				}
+			}
+			ret.SetMapIndex(iter.Key(), elem)
+		}
+		if !ret.IsValid() {
+			return v, false
+		}
+		return ret, true
+
+	case reflect.Struct:
+		ret := reflect.New(v.Type()).Elem()
+		ret.Set(v)
+		changed := false
+
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !jsonVisible(sf) {
+				continue
+			}
+
+			fv := ret.Field(i)
+			if !sf.IsExported() {
+				// ret is a copy, its unexported embedded structs may be rewritten
+				fv = reflect.NewAt(fv.Type(), unsafe.Pointer(fv.UnsafeAddr())).Elem()
+			}
+			if tagValue, ok := sf.Tag.Lookup(tag); ok && isStringField(sf.Type) {
+				if rewriteString(fv, tagValue, fn) {
+					changed = true
+				}
+				continue
+			}
+
+			if elem, ok := rewriteValue(fv, tag, fn, depth+1); ok {
+				fv.Set(elem)
+				changed = true
+			}
+		}
+		return ret, changed
+	}
+
+	return v, false
+}
+
+func copyList(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Array {
+		ret := reflect.New(v.Type()).Elem()
+		ret.Set(v)
+		return ret
+	}
+	ret := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(ret, v)
+	return ret
+}
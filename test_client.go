@@ -0,0 +1,71 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// TestHook sees the result of a handler before it is written, e.g. to assert on
+// the error behind a 500 or on context values which are not part of the response.
+type TestHook func(ctx *Context, resp Response, err error)
+
+// OnTest registers hooks run after every handler in TestMode, register them
+// before serving requests.
+func (v *Prouter) OnTest(hooks ...TestHook) {
+	v.testHooks = append(v.testHooks, hooks...)
+}
+
+func (v *Prouter) runTestHooks(ctx *Context, resp Response, err error) {
+	for _, hook := range v.testHooks {
+		hook(ctx, resp, err)
+	}
+}
+
+type testTransport struct {
+	handler http.Handler
+}
+
+func (t *testTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.RequestURI = r.URL.RequestURI()
+	if r.RemoteAddr == "" {
+		r.RemoteAddr = "192.0.2.1:1234"
+	}
+
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+
+	resp := rec.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+// TestClient returns a client which serves its requests in memory by the router,
+// any host works:
+//
+//	resp, err := router.TestClient().Get("http://test/users/1")
+//
+// The response is returned once the handler finished, streaming routes are
+// better tested with httptest.NewServer.
+func (v *Prouter) TestClient() *http.Client {
+	return &http.Client{Transport: &testTransport{handler: v}}
+}
+
+type observeMiddleware struct {
+	fn func(ctx *Context, resp Response, err error)
+}
+
+func (m *observeMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		resp, err := handler.Handle(ctx)
+		m.fn(ctx, resp, err)
+		return resp, err
+	})
+}
+
+// Observe returns a middleware passing the result of the rest of the chain to
+// fn, e.g. to assert on what the middlewares after it returned. Unlike OnTest
+// it works in every mode.
+func Observe(fn func(ctx *Context, resp Response, err error)) Middleware {
+	return &observeMiddleware{fn: fn}
+}
@@ -0,0 +1,99 @@
+package prouter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// ThrottleBypassHeader carries the token of BypassToken.
+const ThrottleBypassHeader = "X-Throttle-Bypass"
+
+type bypassKey struct{}
+
+type bypassResult struct {
+	caller string
+	ok     bool
+}
+
+// WithBypassKey sets the key of BypassToken, requests with a valid token are not
+// throttled: the ban engine does not count them. Bans and the other DenyCheckers
+// of the IPFilterMiddleware still apply. Rate limiters of the application check
+// ctx.ThrottleBypassed.
+func WithBypassKey(key []byte) RouterOption {
+	return func(v *Prouter) {
+		v.bypassKey = key
+	}
+}
+
+func (v *Prouter) signBypass(caller string, expires int64) string {
+	mac := hmac.New(sha256.New, v.bypassKey)
+	mac.Write([]byte(caller + "." + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// BypassToken returns a token for the internal caller valid for expiry, e.g. a
+// batch job or a health probe, sent in the ThrottleBypassHeader. The caller is
+// named in the audit log of every bypassed request.
+func (v *Prouter) BypassToken(caller string, expiry time.Duration) (string, error) {
+	if len(v.bypassKey) == 0 {
+		return "", errors.New("prouter: no bypass key, see WithBypassKey")
+	}
+	if caller == "" || strings.Contains(caller, ".") {
+		return "", errors.New("prouter: bypass caller must be a non empty name without dots")
+	}
+
+	expires := v.now().Add(expiry).Unix()
+	return caller + "." + strconv.FormatInt(expires, 10) + "." + v.signBypass(caller, expires), nil
+}
+
+func (v *Prouter) verifyBypass(token string) (string, error) {
+	caller, rest, ok := strings.Cut(token, ".")
+	exp, signature, ok2 := strings.Cut(rest, ".")
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if !ok || !ok2 || err != nil {
+		return "", errors.New("malformed bypass token")
+	}
+	if !hmac.Equal([]byte(v.signBypass(caller, expires)), []byte(signature)) {
+		return "", errors.New("invalid bypass token signature")
+	}
+	if v.now().Unix() > expires {
+		return caller, errors.New("bypass token expired")
+	}
+	return caller, nil
+}
+
+// BypassCaller returns the caller of a valid bypass token sent with the request.
+// The token is checked once per request, its use is logged and invalid tokens
+// are logged and ignored.
+func (c *Context) BypassCaller() (string, bool) {
+	if ret, ok := c.Value(bypassKey{}).(*bypassResult); ok {
+		return ret.caller, ret.ok
+	}
+
+	ret := &bypassResult{}
+	token := c.Request.Header.Get(ThrottleBypassHeader)
+	if token != "" && c.router != nil && len(c.router.bypassKey) > 0 {
+		caller, err := c.router.verifyBypass(token)
+		if err != nil {
+			plog.Warnc(c, "throttle bypass rejected for %s on %s %s: %v", c.ClientIp, c.Request.Method, c.RouteTemplate(), err)
+		} else {
+			ret.caller, ret.ok = caller, true
+			plog.Infoc(c, "throttle bypass by %s from %s on %s %s", caller, c.ClientIp, c.Request.Method, c.RouteTemplate())
+		}
+	}
+	c.WithValue(bypassKey{}, ret)
+	return ret.caller, ret.ok
+}
+
+// ThrottleBypassed reports whether the request has a valid bypass token.
+func (c *Context) ThrottleBypassed() bool {
+	_, ok := c.BypassCaller()
+	return ok
+}
@@ -0,0 +1,82 @@
+package prouter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottleBypass(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New(WithClock(clock), WithBypassKey([]byte("bypass-key")))
+	router.GET("/", func(ctx *Context) (Response, error) {
+		caller, _ := ctx.BypassCaller()
+		return SuccessResponse(caller), nil
+	})
+
+	token, err := router.BypassToken("batch", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := New(WithClock(clock), WithBypassKey([]byte("other-key"))).BypassToken("batch", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caller, rest, _ := strings.Cut(token, ".")
+	expires, signature, _ := strings.Cut(rest, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		advance time.Duration
+		caller  string
+	}{
+		{"valid", token, 0, "batch"},
+		{"valid until expiry", token, time.Minute, "batch"},
+		{"expired", token, time.Minute + time.Second, ""},
+		{"other key", other, 0, ""},
+		{"other caller", "probe." + expires + "." + signature, 0, ""},
+		{"extended expiry", caller + ".9999999999." + signature, 0, ""},
+		{"malformed", "batch", 0, ""},
+		{"no token", "", 0, ""},
+	}
+	start := clock.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Set(start.Add(tt.advance))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				req.Header.Set(ThrottleBypassHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("code = %d, want %d", rec.Code, http.StatusOK)
+			}
+			var body struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Data != tt.caller {
+				t.Errorf("caller = %q, want %q", body.Data, tt.caller)
+			}
+		})
+	}
+}
+
+func TestBypassTokenErrors(t *testing.T) {
+	if _, err := New().BypassToken("batch", time.Minute); err == nil {
+		t.Error("BypassToken without key: want error")
+	}
+	router := New(WithBypassKey([]byte("bypass-key")))
+	for _, caller := range []string{"", "batch.job"} {
+		if _, err := router.BypassToken(caller, time.Minute); err == nil {
+			t.Errorf("BypassToken(%q): want error", caller)
+		}
+	}
+}
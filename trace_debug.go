@@ -0,0 +1,132 @@
+package prouter
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+
+	defaultDebugBaggageKey = "debug"
+	// verboseDumpBody is the body logged for verbose requests without WithRequestDump
+	verboseDumpBody = 4 << 10
+)
+
+type traceParentKey struct{}
+
+// TraceParent is the W3C trace context of the request.
+type TraceParent struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+func isHexID(s string, n int) bool {
+	if len(s) != n || strings.Trim(s, "0") == "" {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
+
+// ParseTraceParent parses a traceparent header, ok is false if it is invalid.
+func ParseTraceParent(header string) (tp TraceParent, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return tp, false
+	}
+	// version 00 has exactly four fields, later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return tp, false
+	}
+	if !isHexID(parts[1], 32) || !isHexID(parts[2], 16) {
+		return tp, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return tp, false
+	}
+	return TraceParent{TraceID: parts[1], SpanID: parts[2], Sampled: flags[0]&1 == 1}, true
+}
+
+// TraceParent returns the trace context of the request, ok is false if it has none.
+func (c *Context) TraceParent() (TraceParent, bool) {
+	if tp, ok := c.Value(traceParentKey{}).(*TraceParent); ok {
+		return *tp, tp.TraceID != ""
+	}
+
+	tp := &TraceParent{}
+	if c.Request != nil {
+		*tp, _ = ParseTraceParent(c.Request.Header.Get(TraceParentHeader))
+	}
+	c.WithValue(traceParentKey{}, tp)
+	return *tp, tp.TraceID != ""
+}
+
+// TraceID is the trace id of the traceparent header, empty if there is none.
+func (c *Context) TraceID() string {
+	tp, _ := c.TraceParent()
+	return tp.TraceID
+}
+
+// WithTraceDebug raises the verbosity of the requests of a sampled trace, or
+// whose baggage sets baggageKey ("debug" if empty) to true: the access log dumps
+// the request, Context.Debugf logs at info level and the trace id is added to
+// the meta of the response envelope.
+func WithTraceDebug(baggageKey string) RouterOption {
+	return func(v *Prouter) {
+		if baggageKey == "" {
+			baggageKey = defaultDebugBaggageKey
+		}
+		v.debugBaggageKey = baggageKey
+	}
+}
+
+// Verbose reports whether the request is debugged as configured by WithTraceDebug.
+func (c *Context) Verbose() bool {
+	if c.router == nil || c.router.debugBaggageKey == "" {
+		return false
+	}
+	if tp, ok := c.TraceParent(); ok && tp.Sampled {
+		return true
+	}
+	debug, _ := c.Baggage().Bool(c.router.debugBaggageKey)
+	return debug
+}
+
+// Debugf logs at debug level, or at info level for Verbose requests so a single
+// request can be followed without lowering the level of the whole service.
+func (c *Context) Debugf(msg string, v ...any) {
+	if c.Verbose() {
+		plog.Infoc(c, msg, v...)
+		return
+	}
+	plog.Debugc(c, msg, v...)
+}
+
+// MetaResponse is implemented by response templates with a meta section.
+type MetaResponse interface {
+	SetMeta(map[string]any) Response
+	GetMeta() map[string]any
+}
+
+// applyTraceMeta adds the trace id to the envelope of Verbose requests
+func applyTraceMeta(ctx *Context, tmpl ResponseTmpl) {
+	mr, ok := tmpl.(MetaResponse)
+	if !ok || !ctx.Verbose() {
+		return
+	}
+	id := ctx.TraceID()
+	if id == "" {
+		return
+	}
+	meta := mr.GetMeta()
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	meta["traceId"] = id
+	mr.SetMeta(meta)
+}
@@ -0,0 +1,68 @@
+package prouter
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// countingBody counts the bytes the handler read from the request body
+type countingBody struct {
+	io.ReadCloser
+	n   atomic.Int64
+	eof atomic.Bool
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	if err == io.EOF {
+		b.eof.Store(true)
+	}
+	return n, err
+}
+
+// routeTraffic sums up the body bytes of the requests served by a route
+type routeTraffic struct {
+	requests atomic.Uint64
+	bytesIn  atomic.Uint64
+	bytesOut atomic.Uint64
+}
+
+func (t *routeTraffic) record(in, out int64) {
+	t.requests.Add(1)
+	t.bytesIn.Add(uint64(in))
+	t.bytesOut.Add(uint64(out))
+}
+
+// TrafficStats are the body bytes read from requests and written to responses of a route.
+type TrafficStats struct {
+	Requests uint64 `json:"requests"`
+	BytesIn  uint64 `json:"bytes_in"`
+	BytesOut uint64 `json:"bytes_out"`
+}
+
+func (t *routeTraffic) snapshot() TrafficStats {
+	return TrafficStats{
+		Requests: t.requests.Load(),
+		BytesIn:  t.bytesIn.Load(),
+		BytesOut: t.bytesOut.Load(),
+	}
+}
+
+// countRequestBody replaces the request body by a counting one
+func (c *Context) countRequestBody() {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+	c.body = &countingBody{ReadCloser: c.Request.Body}
+	c.Request.Body = c.body
+}
+
+// RequestSize returns the bytes read from the request body so far.
+func (c *Context) RequestSize() int64 {
+	if c.body == nil {
+		return 0
+	}
+	return c.body.n.Load()
+}
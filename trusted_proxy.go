@@ -0,0 +1,44 @@
+package prouter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WithTrustedProxies takes the client ip of requests coming from cidrs, e.g. the
+// load balancer, from X-Forwarded-For or X-Real-IP instead of the remote address.
+func WithTrustedProxies(cidrs ...string) RouterOption {
+	return func(v *Prouter) {
+		v.trustedProxies = append(v.trustedProxies, parseCIDRs(cidrs)...)
+	}
+}
+
+func (v *Prouter) clientIP(r *http.Request) string {
+	ip := clientIP(r)
+	if len(v.trustedProxies) == 0 || !containsIP(v.trustedProxies, net.ParseIP(ip)) {
+		return ip
+	}
+
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		if real := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); real != nil {
+			return real.String()
+		}
+		return ip
+	}
+
+	// walk the hops from the nearest, the first untrusted one is the client
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop.String()
+		if !containsIP(v.trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
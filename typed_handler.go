@@ -0,0 +1,60 @@
+package prouter
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// TypedFunc is a handler taking its request as a value, so it can be called
+// directly in tests.
+type TypedFunc[Req any, Resp any] func(ctx *Context, req Req) (Resp, error)
+
+func (f TypedFunc[Req, Resp]) Name() string {
+	funcName := plog.GetFuncName(f)
+	fs := strings.Split(funcName, ".")
+
+	return fs[len(fs)-1]
+}
+
+// Handle binds the request into Req like BodyParser, the body by its
+// Content-Type, the path variables by `uri`, the headers by `header` and the
+// query by `form` tags, and answers the returned Resp with SuccessResponse. A
+// Resp which is a Response is answered as it is, a nil pointer means fn wrote
+// the response itself. Errors are answered like those of a HandleFunc.
+func (f TypedFunc[Req, Resp]) Handle(ctx *Context) (Response, error) {
+	var req Req
+	if err := bindRequest(ctx, &req); err != nil {
+		return nil, err
+	}
+
+	resp, err := f(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r := any(resp).(type) {
+	case nil:
+		return nil, nil
+	case Response:
+		return r, nil
+	}
+	if v := reflect.ValueOf(resp); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, nil
+	}
+	return SuccessResponse(resp), nil
+}
+
+// Handle registers fn for method and path of rg, see TypedFunc.Handle:
+//
+//	type GetUser struct {
+//		ID int `uri:"id"`
+//	}
+//
+//	prouter.Handle(api, http.MethodGet, "/users/{id}", func(ctx *prouter.Context, req GetUser) (*User, error) {
+//		return users.Get(ctx, req.ID)
+//	})
+func Handle[Req any, Resp any](rg *RouterGroup, method, path string, fn func(ctx *Context, req Req) (Resp, error), opts ...RouteOption) {
+	rg.handleRoute(method, path, TypedFunc[Req, Resp](fn), opts...)
+}
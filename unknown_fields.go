@@ -0,0 +1,149 @@
+package prouter
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+// UnknownFieldPolicy is how Bind treats JSON body fields without a struct field.
+type UnknownFieldPolicy int
+
+const (
+	// AllowUnknownFields ignores them, the default
+	AllowUnknownFields UnknownFieldPolicy = iota + 1
+	// DisallowUnknownFields answers 400 with the unknown fields as ValidationErrors
+	DisallowUnknownFields
+	// ReportUnknownFields logs them and binds the request, to learn which
+	// clients would break before switching to DisallowUnknownFields
+	ReportUnknownFields
+)
+
+type unknownFieldsKey struct{}
+
+// WithUnknownFields sets the policy of the route, it takes precedence over the
+// policy of the group.
+func WithUnknownFields(policy UnknownFieldPolicy) RouteOption {
+	return func(c *routeConfig) {
+		c.unknownFields = policy
+	}
+}
+
+// UnknownFields sets the policy for the routes of a group:
+//
+//	v2 := router.Group("/v2", prouter.UnknownFields(prouter.ReportUnknownFields))
+func UnknownFields(policy UnknownFieldPolicy) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		ctx.WithValue(unknownFieldsKey{}, policy)
+		return nil, nil
+	}
+}
+
+func (c *Context) unknownFieldPolicy() UnknownFieldPolicy {
+	if c.route != nil && c.route.unknownFields != 0 {
+		return c.route.unknownFields
+	}
+	if p, ok := c.Value(unknownFieldsKey{}).(UnknownFieldPolicy); ok {
+		return p
+	}
+	return AllowUnknownFields
+}
+
+// checkUnknownFields applies the policy to the JSON body bound into obj, bodies
+// which cannot be buffered or parsed are left to the binder.
+func (c *Context) checkUnknownFields(obj any) error {
+	policy := c.unknownFieldPolicy()
+	if policy == AllowUnknownFields {
+		return nil
+	}
+
+	body, err := c.BodyBytes()
+	if err != nil || len(body) == 0 {
+		return nil
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil
+	}
+
+	fields := unknownJSONFields(reflect.TypeOf(obj), data, "", nil)
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Strings(fields)
+
+	if policy == ReportUnknownFields {
+		plog.Warnc(c, "unknown request fields route=%s fields=%s clientIp=%s",
+			routeKey(c.Request.Method, c.RouteTemplate()), strings.Join(fields, ","), c.ClientIp)
+		return nil
+	}
+
+	ve := ValidationErrors{}
+	for _, f := range fields {
+		ve.Add(f, "is not a known field")
+	}
+	return ve
+}
+
+// structJSONFields maps the lower cased JSON names of the fields of t to their
+// types, encoding/json matches the keys case insensitively
+func structJSONFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for _, f := range jsonFields(t) {
+		fields[strings.ToLower(f.name)] = t.FieldByIndex(f.index).Type
+	}
+	return fields
+}
+
+func unknownJSONFields(t reflect.Type, data any, prefix string, found []string) []string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return found
+	}
+	// types decoding themselves take anything
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || t.Kind() == reflect.Interface {
+		return found
+	}
+
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := data.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := structJSONFields(t)
+			for key, value := range v {
+				ft, ok := fields[strings.ToLower(key)]
+				if !ok {
+					found = append(found, join(key))
+					continue
+				}
+				found = unknownJSONFields(ft, value, join(key), found)
+			}
+		case reflect.Map:
+			for key, value := range v {
+				found = unknownJSONFields(t.Elem(), value, join(key), found)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, value := range v {
+				found = unknownJSONFields(t.Elem(), value, prefix+"["+strconv.Itoa(i)+"]", found)
+			}
+		}
+	}
+	return found
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
@@ -6,6 +6,7 @@ import (
 	"mime"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	
 	"github.com/pkg/errors"
@@ -61,12 +62,24 @@ func ReadJSON(r *http.Request, out interface{}) error {
 }
 
 // WriteJSON writes the value v to the http response stream as json with standard json encoding.
+// The value is encoded into a pooled buffer first, so an encoding error results in a
+// 500 response instead of a truncated body.
 func WriteJSON(w http.ResponseWriter, code int, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return errors.Wrap(err, "encode response")
+	}
+	
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
 	w.WriteHeader(code)
-	enc := json.NewEncoder(w)
-	enc.SetEscapeHTML(false)
-	return enc.Encode(v)
+	_, err := w.Write(buf.Bytes())
+	return err
 }
 
 func remoteIP(r *http.Request) string {
@@ -0,0 +1,198 @@
+package prouter
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationErrors maps the invalid fields of a request to their messages. It is
+// answered with 400 and the map in the errors key of the envelope, or in data if
+// the response template has no errors key. Bind produces it from failed binding
+// tags, handlers build their own:
+//
+//	errs := prouter.ValidationErrors{}
+//	if taken {
+//		errs.Add("email", "is already registered")
+//	}
+//	if err := errs.Err(); err != nil {
+//		return nil, err
+//	}
+type ValidationErrors map[string]string
+
+func (e ValidationErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + ": " + e[field]
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add sets the message of field, a field keeps its first message.
+func (e ValidationErrors) Add(field, msg string) ValidationErrors {
+	if _, ok := e[field]; !ok {
+		e[field] = msg
+	}
+	return e
+}
+
+// Err returns nil if no field was added.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validator validates a request struct once it is bound. An error which is
+// ValidationErrors, or validator.ValidationErrors of go-playground, is answered
+// with the invalid fields, e.g. a validator with custom tags:
+//
+//	v := validator.New()
+//	v.SetTagName("binding")
+//	v.RegisterValidation("sku", validateSKU)
+//	router := prouter.New(prouter.WithValidator(prouter.ValidatorFunc(v.Struct)))
+type Validator interface {
+	ValidateStruct(obj any) error
+}
+
+// ValidatorFunc is a Validator function.
+type ValidatorFunc func(obj any) error
+
+func (f ValidatorFunc) ValidateStruct(obj any) error {
+	return f(obj)
+}
+
+// WithValidator replaces binding.Validator of gin for the requests of the
+// router, in Bind, the Bind methods, BodyParser and the check of
+// WithResponseModel. Protobuf, msgpack, yaml and toml bodies are still
+// validated by binding.Validator while decoded.
+func WithValidator(val Validator) RouterOption {
+	return func(v *Prouter) {
+		v.validator = val
+	}
+}
+
+// ErrorsResponse is implemented by response templates with a dedicated key for
+// ValidationErrors.
+type ErrorsResponse interface {
+	SetErrors(errs map[string]string) Response
+	GetErrors() map[string]string
+}
+
+// asValidationErrors finds ValidationErrors in err or the cause of a prouter error
+func asValidationErrors(err error) (ValidationErrors, bool) {
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		return ve, true
+	}
+	rErr := new(prouterError)
+	if errors.As(err, &rErr) && rErr.Cause() != nil && errors.As(rErr.Cause(), &ve) {
+		return ve, true
+	}
+	return nil, false
+}
+
+// validationCause translates the validator errors of binding obj into ValidationErrors
+func validationCause(obj any, err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	ve := ValidationErrors{}
+	t := reflect.TypeOf(obj)
+	for _, fe := range fieldErrs {
+		ve.Add(fieldPath(t, fe.StructNamespace()), fieldMessage(fe))
+	}
+	return ve
+}
+
+func tagName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri", "header"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// fieldPath turns the namespace User.Items[0].Name into the request field names
+// of the path, e.g. items[0].name
+func fieldPath(t reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 1 {
+		// the first segment is the type name
+		segments = segments[1:]
+	}
+
+	parts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		name, index, _ := strings.Cut(seg, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			parts = append(parts, name+index)
+			t = nil
+			continue
+		}
+
+		f, ok := t.FieldByName(name)
+		if !ok {
+			parts = append(parts, name+index)
+			t = nil
+			continue
+		}
+		parts = append(parts, tagName(f)+index)
+		t = f.Type
+	}
+	return strings.Join(parts, ".")
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "uri":
+		return "must be a valid url"
+	case "uuid", "uuid4":
+		return "must be a valid uuid"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "len":
+		return "must have a length of " + param
+	case "min":
+		return "must be at least " + param
+	case "max":
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be greater than or equal to " + param
+	case "lt":
+		return "must be less than " + param
+	case "lte":
+		return "must be less than or equal to " + param
+	}
+	if param != "" {
+		return "failed the " + fe.Tag() + "=" + param + " validation"
+	}
+	return "failed the " + fe.Tag() + " validation"
+}
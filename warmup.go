@@ -0,0 +1,157 @@
+package prouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const defaultWarmupConcurrency = 4
+
+// WarmupFunc prefills the caches of a route before it takes traffic.
+type WarmupFunc func(ctx context.Context) error
+
+type warmupState struct {
+	concurrency int
+	timeout     time.Duration
+	registered  atomic.Bool
+	done        atomic.Bool
+}
+
+// WithWarmup runs fn when the router starts serving, e.g. to load the hot keys
+// of the route into its cache. Until every warmup of the router returned, the
+// ReadinessHandler answers 503. A failed warmup is logged, it does not keep
+// the router unready.
+func WithWarmup(fn WarmupFunc) RouteOption {
+	return func(c *routeConfig) {
+		c.warmup = fn
+	}
+}
+
+// WithWarmupConcurrency sets the warmups run at the same time, 4 by default.
+func WithWarmupConcurrency(n int) RouterOption {
+	return func(v *Prouter) {
+		v.warmup.concurrency = n
+	}
+}
+
+// WithWarmupTimeout cancels the ctx of a warmup running longer than d.
+func WithWarmupTimeout(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.warmup.timeout = d
+	}
+}
+
+// registerWarmup runs the warmups as a Background task once a route declares one
+func (v *Prouter) registerWarmup() {
+	if v.warmup.registered.CompareAndSwap(false, true) {
+		v.Background("warmup", func(ctx context.Context) {
+			start := time.Now()
+			if err := v.Warmup(ctx); err != nil {
+				plog.Errorf("warmup finished with errors in %v: %v", time.Since(start), err)
+				return
+			}
+			plog.Infof("warmup finished in %v", time.Since(start))
+		})
+	}
+}
+
+type routeWarmup struct {
+	route string
+	fn    WarmupFunc
+}
+
+func (v *Prouter) warmups() []routeWarmup {
+	v.routeSlots.mu.RLock()
+	defer v.routeSlots.mu.RUnlock()
+
+	var ret []routeWarmup
+	for key, slot := range v.routeSlots.slots {
+		if slot.info.warmup != nil && !slot.state.Load().removed {
+			ret = append(ret, routeWarmup{route: key, fn: slot.info.warmup})
+		}
+	}
+	return ret
+}
+
+// Warmup runs the warmups of the routes and marks the router ready, it is run
+// by Run, RunTLS and RunListener, call it directly for a server set up by hand.
+// The errors of the failed warmups are joined.
+func (v *Prouter) Warmup(ctx context.Context) error {
+	defer v.warmup.done.Store(true)
+
+	sem := make(chan struct{}, max(v.warmup.concurrency, 1))
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, w := range v.warmups() {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := v.runWarmup(ctx, w); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", w.route, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (v *Prouter) runWarmup(ctx context.Context, w routeWarmup) (err error) {
+	if v.warmup.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.warmup.timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("warmup panic: %v", r)
+		}
+	}()
+	return w.fn(ctx)
+}
+
+// Ready reports whether the warmups returned and the router is not shutting down.
+func (v *Prouter) Ready() bool {
+	if v.shutdown.active.Load() {
+		return false
+	}
+	return !v.warmup.registered.Load() || v.warmup.done.Load()
+}
+
+// ReadinessHandler answers 200 once the router is Ready and 503 before, for the
+// readiness probe:
+//
+//	router.GET("/readyz", router.ReadinessHandler())
+func (v *Prouter) ReadinessHandler() HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		switch {
+		case v.shutdown.active.Load():
+			msg, _ := v.message(ctx.Request, MsgShuttingDown)
+			return nil, MsgError(http.StatusServiceUnavailable, msg).SetComponent(ErrProuter)
+		case !v.Ready():
+			return nil, MsgError(http.StatusServiceUnavailable, "warming up").SetComponent(ErrProuter)
+		}
+		return SuccessResponse(map[string]bool{"ready": true}), nil
+	}
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func adminError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return prouter.MsgError(http.StatusNotFound, err.Error()).
+			SetComponent(prouter.ErrProuter).
+			SetResponseType(prouter.NotFound)
+	}
+	return prouter.NewErr(http.StatusInternalServerError, err).
+		SetComponent(prouter.ErrProuter).
+		SetResponseType(prouter.InternalServerError)
+}
+
+// Mount registers the admin routes to manage endpoints and inspect the delivery
+// attempts in rg, protect the group with an auth middleware:
+//
+//	GET    /endpoints
+//	POST   /endpoints
+//	DELETE /endpoints/{id}
+//	GET    /deliveries?status=dead&limit=50
+//	GET    /deliveries/{id}
+//	POST   /deliveries/{id}/redeliver
+func (s *Sender) Mount(rg *prouter.RouterGroup) {
+	rg.GET("/endpoints", func(ctx *prouter.Context) (prouter.Response, error) {
+		endpoints, err := s.store.Endpoints(ctx)
+		if err != nil {
+			return nil, adminError(err)
+		}
+		for _, e := range endpoints {
+			e.Secret = ""
+		}
+		return prouter.SuccessResponse(endpoints), nil
+	})
+
+	rg.POST("/endpoints", func(ctx *prouter.Context) (prouter.Response, error) {
+		e := new(Endpoint)
+		if err := ctx.Bind(e); err != nil {
+			return nil, err
+		}
+		if err := s.Register(ctx, e); err != nil {
+			return nil, adminError(err)
+		}
+		e.Secret = ""
+		return prouter.SuccessResponse(e), nil
+	})
+
+	rg.DELETE("/endpoints/{id}", func(ctx *prouter.Context) (prouter.Response, error) {
+		if err := s.Unregister(ctx, ctx.Var("id")); err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(nil), nil
+	})
+
+	rg.GET("/deliveries", func(ctx *prouter.Context) (prouter.Response, error) {
+		query := ctx.QueryValues()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit <= 0 {
+			limit = 50
+		}
+
+		deliveries, err := s.store.Deliveries(ctx, DeliveryStatus(query.Get("status")), limit)
+		if err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(deliveries), nil
+	})
+
+	rg.GET("/deliveries/{id}", func(ctx *prouter.Context) (prouter.Response, error) {
+		d, err := s.store.Delivery(ctx, ctx.Var("id"))
+		if err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(d), nil
+	})
+
+	rg.POST("/deliveries/{id}/redeliver", func(ctx *prouter.Context) (prouter.Response, error) {
+		d, err := s.Redeliver(ctx, ctx.Var("id"))
+		if err != nil {
+			return nil, adminError(err)
+		}
+		return prouter.SuccessResponse(d), nil
+	})
+}
@@ -0,0 +1,350 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/google/uuid"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	IDHeader        = "X-Webhook-Id"
+	EventHeader     = "X-Webhook-Event"
+
+	defaultMaxAttempts  = 8
+	defaultBaseBackoff  = 10 * time.Second
+	defaultMaxBackoff   = time.Hour
+	defaultPollInterval = time.Second
+	defaultWorkers      = 4
+	claimLease          = time.Minute
+)
+
+var ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+// Sign returns the signature header value of payload: t=<unix>,v1=<hex hmac-sha256 of "t.payload">.
+func Sign(secret string, ts time.Time, payload []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature made by Sign, signatures whose timestamp is more than
+// tolerance in the past or the future are rejected.
+func Verify(secret, signature string, payload []byte, tolerance time.Duration) error {
+	var t, v1 string
+	for _, part := range strings.Split(signature, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			v1 = v
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || v1 == "" {
+		return ErrInvalidSignature
+	}
+	ts := time.Unix(unix, 0)
+	if age := time.Since(ts); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, payload)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type Sender struct {
+	store        Store
+	client       *http.Client
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	workers      int
+	deadLetter   func(d *Delivery)
+
+	kick   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type SenderOption func(*Sender)
+
+func WithHTTPClient(client *http.Client) SenderOption {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// WithMaxAttempts sets how often a delivery is tried before it is dead lettered.
+func WithMaxAttempts(n int) SenderOption {
+	return func(s *Sender) {
+		s.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the exponential backoff between attempts, it is jittered by up to 20%.
+func WithBackoff(base, max time.Duration) SenderOption {
+	return func(s *Sender) {
+		s.baseBackoff = base
+		s.maxBackoff = max
+	}
+}
+
+func WithWorkers(n int) SenderOption {
+	return func(s *Sender) {
+		s.workers = n
+	}
+}
+
+func WithPollInterval(d time.Duration) SenderOption {
+	return func(s *Sender) {
+		s.pollInterval = d
+	}
+}
+
+// WithDeadLetter calls fn when a delivery used up its attempts.
+func WithDeadLetter(fn func(d *Delivery)) SenderOption {
+	return func(s *Sender) {
+		s.deadLetter = fn
+	}
+}
+
+func NewSender(store Store, opts ...SenderOption) *Sender {
+	s := &Sender{
+		store:        store,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		maxAttempts:  defaultMaxAttempts,
+		baseBackoff:  defaultBaseBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		pollInterval: defaultPollInterval,
+		workers:      defaultWorkers,
+		kick:         make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Register saves the endpoint, an ID is generated if it has none.
+func (s *Sender) Register(ctx context.Context, e *Endpoint) error {
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	return s.store.SaveEndpoint(ctx, e)
+}
+
+func (s *Sender) Unregister(ctx context.Context, id string) error {
+	return s.store.DeleteEndpoint(ctx, id)
+}
+
+// Send queues a delivery of payload, encoded as JSON, to every endpoint subscribed to event.
+func (s *Sender) Send(ctx context.Context, event string, payload any) ([]*Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := s.store.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var deliveries []*Delivery
+	for _, e := range endpoints {
+		if !e.Subscribed(event) {
+			continue
+		}
+
+		d := &Delivery{
+			ID:          uuid.NewString(),
+			EndpointID:  e.ID,
+			Event:       event,
+			Payload:     body,
+			Status:      StatusPending,
+			NextAttempt: now,
+			CreatedAt:   now,
+		}
+		if err := s.store.SaveDelivery(ctx, d); err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	s.wake()
+	return deliveries, nil
+}
+
+// Redeliver queues the delivery again with a fresh set of attempts.
+func (s *Sender) Redeliver(ctx context.Context, id string) (*Delivery, error) {
+	d, err := s.store.Delivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Status = StatusPending
+	d.NextAttempt = time.Now()
+	d.Attempts = d.Attempts[:0]
+	if err := s.store.SaveDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	s.wake()
+	return d, nil
+}
+
+func (s *Sender) wake() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the delivery workers until Stop is called or ctx is done.
+func (s *Sender) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.dispatch(ctx)
+	}()
+}
+
+// Stop stops the workers and waits for the running attempts.
+func (s *Sender) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Sender) dispatch(ctx context.Context) {
+	sem := make(chan struct{}, s.workers)
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliveries, err := s.store.ClaimDue(ctx, time.Now(), s.workers, claimLease)
+		if err != nil {
+			plog.Errorc(ctx, "webhook claim deliveries error: %v", err)
+		}
+		for _, d := range deliveries {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			s.wg.Add(1)
+			go func(d *Delivery) {
+				defer func() {
+					<-sem
+					s.wg.Done()
+				}()
+				s.attempt(ctx, d)
+			}(d)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.kick:
+		}
+	}
+}
+
+func (s *Sender) backoff(attempts int) time.Duration {
+	d := s.baseBackoff << min(attempts-1, 30)
+	if d <= 0 || d > s.maxBackoff {
+		d = s.maxBackoff
+	}
+	return d + time.Duration(rand.Int64N(int64(d)/5+1))
+}
+
+func (s *Sender) post(ctx context.Context, e *Endpoint, d *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, d.ID)
+	req.Header.Set(EventHeader, d.Event)
+	if e.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(e.Secret, time.Now(), d.Payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *Sender) attempt(ctx context.Context, d *Delivery) {
+	start := time.Now()
+	attempt := Attempt{At: start}
+
+	e, err := s.store.Endpoint(ctx, d.EndpointID)
+	if err == nil {
+		attempt.StatusCode, err = s.post(ctx, e, d)
+	}
+	if ctx.Err() != nil {
+		// stopped while sending, the claim lease runs out and the delivery is retried
+		return
+	}
+
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	d.Attempts = append(d.Attempts, attempt)
+
+	switch {
+	case err == nil:
+		d.Status = StatusSucceeded
+	case errors.Is(err, ErrNotFound) || len(d.Attempts) >= s.maxAttempts:
+		d.Status = StatusDead
+	default:
+		d.NextAttempt = time.Now().Add(s.backoff(len(d.Attempts)))
+	}
+
+	if err := s.store.SaveDelivery(ctx, d); err != nil {
+		plog.Errorc(ctx, "webhook save delivery %s error: %v", d.ID, err)
+		return
+	}
+	if d.Status == StatusDead && s.deadLetter != nil {
+		s.deadLetter(d)
+	}
+}
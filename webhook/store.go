@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("webhook: not found")
+
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusSucceeded DeliveryStatus = "succeeded"
+	// StatusDead deliveries used up their attempts, they can be redelivered from the admin routes
+	StatusDead DeliveryStatus = "dead"
+)
+
+type Endpoint struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// Subscribed reports whether the endpoint receives event, an endpoint without events receives all.
+func (e *Endpoint) Subscribed(event string) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, event)
+}
+
+type Attempt struct {
+	At         time.Time     `json:"at"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+type Delivery struct {
+	ID          string         `json:"id"`
+	EndpointID  string         `json:"endpoint_id"`
+	Event       string         `json:"event"`
+	Payload     []byte         `json:"payload"`
+	Status      DeliveryStatus `json:"status"`
+	Attempts    []Attempt      `json:"attempts"`
+	NextAttempt time.Time      `json:"next_attempt"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// Store persists endpoints and deliveries. ClaimDue must hand a due delivery to
+// one caller only, by moving its NextAttempt past the lease.
+type Store interface {
+	SaveEndpoint(ctx context.Context, e *Endpoint) error
+	DeleteEndpoint(ctx context.Context, id string) error
+	Endpoint(ctx context.Context, id string) (*Endpoint, error)
+	Endpoints(ctx context.Context) ([]*Endpoint, error)
+
+	SaveDelivery(ctx context.Context, d *Delivery) error
+	Delivery(ctx context.Context, id string) (*Delivery, error)
+	// Deliveries lists the deliveries with status, all if status is empty, newest first
+	Deliveries(ctx context.Context, status DeliveryStatus, limit int) ([]*Delivery, error)
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error)
+}
+
+// MemoryStore keeps everything in memory, for tests and single instance setups.
+type MemoryStore struct {
+	mu         sync.Mutex
+	endpoints  map[string]*Endpoint
+	deliveries map[string]*Delivery
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		endpoints:  make(map[string]*Endpoint),
+		deliveries: make(map[string]*Delivery),
+	}
+}
+
+func cloneDelivery(d *Delivery) *Delivery {
+	c := *d
+	c.Attempts = slices.Clone(d.Attempts)
+	return &c
+}
+
+func (s *MemoryStore) SaveEndpoint(_ context.Context, e *Endpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *e
+	s.endpoints[e.ID] = &c
+	return nil
+}
+
+func (s *MemoryStore) DeleteEndpoint(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.endpoints[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.endpoints, id)
+	return nil
+}
+
+func (s *MemoryStore) Endpoint(_ context.Context, id string) (*Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.endpoints[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *e
+	return &c, nil
+}
+
+func (s *MemoryStore) Endpoints(_ context.Context) ([]*Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]*Endpoint, 0, len(s.endpoints))
+	for _, e := range s.endpoints {
+		c := *e
+		ret = append(ret, &c)
+	}
+	slices.SortFunc(ret, func(a, b *Endpoint) int { return strings.Compare(a.ID, b.ID) })
+	return ret, nil
+}
+
+func (s *MemoryStore) SaveDelivery(_ context.Context, d *Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[d.ID] = cloneDelivery(d)
+	return nil
+}
+
+func (s *MemoryStore) Delivery(_ context.Context, id string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneDelivery(d), nil
+}
+
+func (s *MemoryStore) Deliveries(_ context.Context, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]*Delivery, 0)
+	for _, d := range s.deliveries {
+		if status == "" || d.Status == status {
+			ret = append(ret, cloneDelivery(d))
+		}
+	}
+	slices.SortFunc(ret, func(a, b *Delivery) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	if limit > 0 && len(ret) > limit {
+		ret = ret[:limit]
+	}
+	return ret, nil
+}
+
+func (s *MemoryStore) ClaimDue(_ context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ret []*Delivery
+	for _, d := range s.deliveries {
+		if len(ret) >= limit {
+			break
+		}
+		if d.Status != StatusPending || d.NextAttempt.After(now) {
+			continue
+		}
+		d.NextAttempt = now.Add(lease)
+		ret = append(ret, cloneDelivery(d))
+	}
+	return ret, nil
+}
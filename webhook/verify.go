@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+)
+
+const maxVerifyBody = 1 << 20
+
+// VerifyMiddleware rejects inbound webhooks whose SignatureHeader does not match secret, the
+// body is restored for the handler. Bodies over 1MB are answered with 413.
+func VerifyMiddleware(secret string, tolerance time.Duration) prouter.HandleFunc {
+	return func(ctx *prouter.Context) (prouter.Response, error) {
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxVerifyBody+1))
+		if err != nil {
+			return nil, prouter.NewErr(http.StatusBadRequest, err, "read body failed").
+				SetComponent(prouter.ErrProuter).
+				SetResponseType(prouter.BadRequest)
+		}
+		if len(body) > maxVerifyBody {
+			return nil, prouter.MsgError(http.StatusRequestEntityTooLarge, "webhook body too large").SetComponent(prouter.ErrProuter)
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err := Verify(secret, ctx.Request.Header.Get(SignatureHeader), body, tolerance); err != nil {
+			return nil, prouter.MsgError(http.StatusUnauthorized, err.Error()).SetComponent(prouter.ErrProuter)
+		}
+		return nil, nil
+	}
+}
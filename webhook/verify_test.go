@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+)
+
+func TestVerify(t *testing.T) {
+	payload := []byte(`{"event":"paid"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		signature string
+		ok        bool
+	}{
+		{"valid", Sign("secret", now, payload), true},
+		{"wrong secret", Sign("other", now, payload), false},
+		{"too old", Sign("secret", now.Add(-10*time.Minute), payload), false},
+		{"too far in the future", Sign("secret", now.Add(10*time.Minute), payload), false},
+		{"slight clock skew", Sign("secret", now.Add(30*time.Second), payload), true},
+		{"malformed", "t=abc,v1=00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify("secret", tt.signature, payload, 5*time.Minute)
+			if (err == nil) != tt.ok {
+				t.Errorf("Verify = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestVerifyMiddleware(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), maxVerifyBody+1)
+	small := []byte(`{"event":"paid"}`)
+
+	tests := []struct {
+		name string
+		body []byte
+		code int
+	}{
+		{"valid", small, http.StatusOK},
+		{"full size", large[:maxVerifyBody], http.StatusOK},
+		{"too large", large, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := prouter.New()
+			router.UseMiddleware(VerifyMiddleware("secret", time.Minute))
+			router.POST("/hook", func(ctx *prouter.Context) (prouter.Response, error) {
+				body, _ := io.ReadAll(ctx.Request.Body)
+				if !bytes.Equal(body, tt.body) {
+					t.Errorf("handler read %d bytes, want %d", len(body), len(tt.body))
+				}
+				return nil, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(tt.body))
+			req.Header.Set(SignatureHeader, Sign("secret", time.Now(), tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("code = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package ws
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	ErrClosed    = errors.New("ws: connection closed")
+	ErrQueueFull = errors.New("ws: send queue full")
+)
+
+type message struct {
+	typ  int
+	data []byte
+}
+
+// Conn is a connection served by the Hub, writes go through its send queue.
+type Conn struct {
+	hub  *Hub
+	conn *websocket.Conn
+	ctx  *prouter.Context
+
+	send       chan message
+	done       chan struct{}
+	drainCh    chan struct{}
+	writerDone chan struct{}
+	closeOnce  sync.Once
+	drainOnce  sync.Once
+
+	// rooms is guarded by hub.mu
+	rooms map[string]struct{}
+}
+
+func newConn(h *Hub, conn *websocket.Conn, ctx *prouter.Context) *Conn {
+	return &Conn{
+		hub:        h,
+		conn:       conn,
+		ctx:        ctx,
+		send:       make(chan message, h.queueSize),
+		done:       make(chan struct{}),
+		drainCh:    make(chan struct{}),
+		writerDone: make(chan struct{}),
+		rooms:      make(map[string]struct{}),
+	}
+}
+
+// Context returns the Context of the upgraded request, it is valid until the connection closed.
+func (c *Conn) Context() *prouter.Context {
+	return c.ctx
+}
+
+func (c *Conn) Request() *http.Request {
+	return c.ctx.Request
+}
+
+func (c *Conn) Join(room string) {
+	c.hub.Join(c, room)
+}
+
+func (c *Conn) Leave(room string) {
+	c.hub.Leave(c, room)
+}
+
+// Send queues a text message.
+func (c *Conn) Send(data []byte) error {
+	return c.enqueue(message{typ: websocket.TextMessage, data: data})
+}
+
+// SendBinary queues a binary message.
+func (c *Conn) SendBinary(data []byte) error {
+	return c.enqueue(message{typ: websocket.BinaryMessage, data: data})
+}
+
+func (c *Conn) enqueue(m message) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- m:
+		return nil
+	default:
+	}
+
+	if c.hub.slowPolicy == CloseSlow {
+		c.Close()
+	}
+	return ErrQueueFull
+}
+
+// Close closes the connection without flushing its send queue.
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// drain makes the writer flush the send queue and close the connection
+func (c *Conn) drain() {
+	c.drainOnce.Do(func() {
+		close(c.drainCh)
+	})
+}
+
+func (c *Conn) write(m message) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.hub.writeWait))
+	return c.conn.WriteMessage(m.typ, m.data)
+}
+
+func (c *Conn) writeClose(code int, text string) {
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(c.hub.writeWait))
+}
+
+func (c *Conn) writePump() {
+	ticker := time.NewTicker(c.hub.pingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+		close(c.writerDone)
+	}()
+
+	for {
+		select {
+		case m := <-c.send:
+			if err := c.write(m); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.hub.writeWait)); err != nil {
+				return
+			}
+		case <-c.drainCh:
+			for len(c.send) > 0 {
+				if err := c.write(<-c.send); err != nil {
+					return
+				}
+			}
+			c.writeClose(websocket.CloseGoingAway, "server is shutting down")
+			return
+		case <-c.done:
+			c.writeClose(websocket.CloseNormalClosure, "")
+			return
+		}
+	}
+}
+
+func (c *Conn) readPump() {
+	pongWait := 2 * c.hub.pingInterval
+
+	c.conn.SetReadLimit(c.hub.maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		typ, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		if c.hub.onMessage != nil {
+			c.hub.onMessage(c, typ, data)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultSendQueueSize  = 64
+	defaultPingInterval   = 30 * time.Second
+	defaultWriteWait      = 10 * time.Second
+	defaultMaxMessageSize = 1 << 20
+)
+
+// SlowConsumerPolicy decides what happens when the send queue of a connection is full
+type SlowConsumerPolicy int
+
+const (
+	// DropMessage drops the message and Send returns ErrQueueFull
+	DropMessage SlowConsumerPolicy = iota
+	// CloseSlow closes the connection so it can reconnect and resync
+	CloseSlow
+)
+
+type Hub struct {
+	upgrader websocket.Upgrader
+
+	queueSize      int
+	pingInterval   time.Duration
+	writeWait      time.Duration
+	maxMessageSize int64
+	slowPolicy     SlowConsumerPolicy
+
+	onConnect func(c *Conn)
+	onMessage func(c *Conn, messageType int, data []byte)
+	onClose   func(c *Conn)
+
+	mu      sync.RWMutex
+	conns   map[*Conn]struct{}
+	rooms   map[string]map[*Conn]struct{}
+	closing bool
+	wg      sync.WaitGroup
+}
+
+type HubOption func(*Hub)
+
+// WithSendQueueSize sets the number of messages buffered for each connection.
+func WithSendQueueSize(n int) HubOption {
+	return func(h *Hub) {
+		h.queueSize = n
+	}
+}
+
+// WithPingInterval sets how often pings are sent, a connection without a pong
+// within two intervals is closed.
+func WithPingInterval(d time.Duration) HubOption {
+	return func(h *Hub) {
+		h.pingInterval = d
+	}
+}
+
+func WithWriteWait(d time.Duration) HubOption {
+	return func(h *Hub) {
+		h.writeWait = d
+	}
+}
+
+func WithMaxMessageSize(n int64) HubOption {
+	return func(h *Hub) {
+		h.maxMessageSize = n
+	}
+}
+
+func WithSlowConsumerPolicy(p SlowConsumerPolicy) HubOption {
+	return func(h *Hub) {
+		h.slowPolicy = p
+	}
+}
+
+// WithCheckOrigin sets the origin check of the upgrade, same origin requests only by default.
+func WithCheckOrigin(fn func(r *http.Request) bool) HubOption {
+	return func(h *Hub) {
+		h.upgrader.CheckOrigin = fn
+	}
+}
+
+func WithOnConnect(fn func(c *Conn)) HubOption {
+	return func(h *Hub) {
+		h.onConnect = fn
+	}
+}
+
+func WithOnMessage(fn func(c *Conn, messageType int, data []byte)) HubOption {
+	return func(h *Hub) {
+		h.onMessage = fn
+	}
+}
+
+func WithOnClose(fn func(c *Conn)) HubOption {
+	return func(h *Hub) {
+		h.onClose = fn
+	}
+}
+
+func NewHub(opts ...HubOption) *Hub {
+	h := &Hub{
+		queueSize:      defaultSendQueueSize,
+		pingInterval:   defaultPingInterval,
+		writeWait:      defaultWriteWait,
+		maxMessageSize: defaultMaxMessageSize,
+		conns:          make(map[*Conn]struct{}),
+		rooms:          make(map[string]map[*Conn]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Handle upgrades the request and serves the connection until it is closed,
+// it is meant to be registered as route handler: r.GET("/ws", hub.Handle).
+func (h *Hub) Handle(ctx *prouter.Context) (prouter.Response, error) {
+	h.mu.RLock()
+	closing := h.closing
+	h.mu.RUnlock()
+	if closing {
+		return nil, prouter.MsgError(http.StatusServiceUnavailable, "server is shutting down").SetComponent(prouter.ErrProuter)
+	}
+
+	wsConn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader already answered the request
+		plog.Errorc(ctx, "websocket upgrade error: %v", err)
+		return nil, nil
+	}
+
+	c := newConn(h, wsConn, ctx)
+	if !h.register(c) {
+		_ = wsConn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"), time.Now().Add(h.writeWait))
+		_ = wsConn.Close()
+		return nil, nil
+	}
+	defer h.unregister(c)
+
+	go c.writePump()
+	if h.onConnect != nil {
+		h.onConnect(c)
+	}
+	c.readPump()
+	return nil, nil
+}
+
+func (h *Hub) register(c *Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closing {
+		return false
+	}
+	h.conns[c] = struct{}{}
+	h.wg.Add(1)
+	return true
+}
+
+func (h *Hub) unregister(c *Conn) {
+	c.Close()
+	<-c.writerDone
+
+	h.mu.Lock()
+	delete(h.conns, c)
+	for room := range c.rooms {
+		h.leave(c, room)
+	}
+	h.mu.Unlock()
+
+	if h.onClose != nil {
+		h.onClose(c)
+	}
+	h.wg.Done()
+}
+
+// leave must be called with h.mu held
+func (h *Hub) leave(c *Conn, room string) {
+	delete(c.rooms, room)
+	members := h.rooms[room]
+	delete(members, c)
+	if len(members) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Join adds c to room.
+func (h *Hub) Join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[*Conn]struct{})
+		h.rooms[room] = members
+	}
+	members[c] = struct{}{}
+	c.rooms[room] = struct{}{}
+}
+
+// Leave removes c from room.
+func (h *Hub) Leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.leave(c, room)
+}
+
+func (h *Hub) snapshot(room string) []*Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	members := h.conns
+	if room != "" {
+		members = h.rooms[room]
+	}
+	conns := make([]*Conn, 0, len(members))
+	for c := range members {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// Broadcast queues the text message on every connection.
+func (h *Hub) Broadcast(data []byte) {
+	for _, c := range h.snapshot("") {
+		_ = c.Send(data)
+	}
+}
+
+// BroadcastRoom queues the text message on every connection in room.
+func (h *Hub) BroadcastRoom(room string, data []byte) {
+	if room == "" {
+		return
+	}
+	for _, c := range h.snapshot(room) {
+		_ = c.Send(data)
+	}
+}
+
+// Rooms returns the rooms with at least one member.
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Len returns the number of open connections.
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.conns)
+}
+
+// Shutdown rejects new connections, lets every connection flush its send queue
+// and closes it with a going away frame. It returns when all connections are
+// closed or ctx is done.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	h.mu.Unlock()
+
+	for _, c := range h.snapshot("") {
+		c.drain()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, c := range h.snapshot("") {
+			c.Close()
+		}
+		return ctx.Err()
+	}
+}
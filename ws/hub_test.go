@@ -0,0 +1,162 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-puzzles/prouter"
+	"github.com/gorilla/websocket"
+)
+
+func TestSlowConsumer(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SlowConsumerPolicy
+		next   error
+	}{
+		{"drop message", DropMessage, ErrQueueFull},
+		{"close slow", CloseSlow, ErrClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub(WithSendQueueSize(2), WithSlowConsumerPolicy(tt.policy))
+			// no writer runs, the queue is never drained
+			c := newConn(h, nil, nil)
+			for i := 0; i < 2; i++ {
+				if err := c.Send([]byte("m")); err != nil {
+					t.Fatalf("Send %d = %v", i, err)
+				}
+			}
+			if err := c.Send([]byte("m")); !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("Send on full queue = %v, want %v", err, ErrQueueFull)
+			}
+			if err := c.Send([]byte("m")); !errors.Is(err, tt.next) {
+				t.Errorf("next Send = %v, want %v", err, tt.next)
+			}
+			if got := len(c.send); got != 2 {
+				t.Errorf("queued = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func newTestServer(t *testing.T, h *Hub) *httptest.Server {
+	t.Helper()
+	router := prouter.New()
+	router.GET("/ws", h.Handle)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, srv *httptest.Server) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+}
+
+func TestRooms(t *testing.T) {
+	connected := make(chan *Conn, 1)
+	h := NewHub(WithOnConnect(func(c *Conn) {
+		c.Join("news")
+		connected <- c
+	}))
+	srv := newTestServer(t, h)
+
+	member, _, err := dial(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer member.Close()
+	<-connected
+
+	if rooms := h.Rooms(); len(rooms) != 1 || rooms[0] != "news" {
+		t.Fatalf("Rooms = %v, want [news]", rooms)
+	}
+	h.BroadcastRoom("news", []byte("hello"))
+
+	member.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, data, err := member.ReadMessage(); err != nil || string(data) != "hello" {
+		t.Fatalf("ReadMessage = %q, %v", data, err)
+	}
+
+	member.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for h.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if h.Len() != 0 || len(h.Rooms()) != 0 {
+		t.Errorf("after close: Len = %d, Rooms = %v", h.Len(), h.Rooms())
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	connected := make(chan *Conn, 1)
+	closed := make(chan *Conn, 1)
+	h := NewHub(
+		WithSendQueueSize(16),
+		WithOnConnect(func(c *Conn) { connected <- c }),
+		WithOnClose(func(c *Conn) { closed <- c }),
+	)
+	srv := newTestServer(t, h)
+
+	client, _, err := dial(t, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	<-connected
+
+	want := []string{"1", "2", "3", "4", "5"}
+	for _, m := range want {
+		h.Broadcast([]byte(m))
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- h.Shutdown(ctx)
+	}()
+
+	// every queued message arrives before the going away frame
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var got []string
+	for {
+		_, data, err := client.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				t.Fatalf("ReadMessage = %v, want going away close", err)
+			}
+			break
+		}
+		got = append(got, string(data))
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("received %v, want %v", got, want)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	select {
+	case <-closed:
+	default:
+		t.Error("Shutdown returned before the connection was closed")
+	}
+	if h.Len() != 0 {
+		t.Errorf("Len = %d after Shutdown", h.Len())
+	}
+
+	_, resp, err := dial(t, srv)
+	if err == nil {
+		t.Fatal("dial after Shutdown succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("dial after Shutdown: response %v, want %d", resp, http.StatusServiceUnavailable)
+	}
+}